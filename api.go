@@ -1,6 +1,10 @@
 package art
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"math"
+)
 
 // NodeKV types.
 const (
@@ -24,6 +28,12 @@ const (
 
 	// Iterate in reverse order.
 	TraverseReverse = 4
+
+	// Iterate over the values of a MultiTree one by one instead of once per key.
+	TraverseValues = 8
+
+	// Iterate across the modifications of the tree, see ResilientIterator.
+	TraverseResilient = 16
 )
 
 // These errors can be returned when iteration over the tree.
@@ -32,6 +42,42 @@ var (
 	ErrNoMoreNodes            = errors.New("there are no more nodes in the tree")
 )
 
+// These errors can be returned by operations combining several trees.
+var (
+	ErrOverlappingKeys = errors.New("trees have overlapping key ranges")
+	ErrUnsupportedTree = errors.New("unsupported tree implementation")
+)
+
+// ErrBadPattern is returned by NewGlobAutomaton when the glob pattern is malformed.
+var ErrBadPattern = errors.New("syntax error in pattern")
+
+// ErrInvalidTree is returned by Validate when the tree structure is corrupted,
+// the returned error is a *ValidationError wrapping it.
+var ErrInvalidTree = errors.New("invalid tree structure")
+
+// ValidationError describes the first violation of the tree invariants found by Validate.
+type ValidationError struct {
+	// Path is the sequence of key bytes leading to the invalid Node,
+	// the bytes of the Node prefixes included.
+	Path Key
+
+	// Kind is the kind of the invalid Node.
+	Kind Kind
+
+	// Reason describes the violated invariant.
+	Reason string
+}
+
+// Error returns the description of the violation.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v: %v at path %q: %s", ErrInvalidTree, e.Kind, []byte(e.Path), e.Reason)
+}
+
+// Unwrap returns ErrInvalidTree.
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidTree
+}
+
 // Kind is a Node type.
 type Kind int
 
@@ -83,6 +129,85 @@ type Iterator interface {
 	Next() (NodeKV, error)
 }
 
+// ResilientIterator is the Iterator returned by the Iterator method of the trees created by New and NewCompact
+// when the TraverseResilient option is passed. Once the tree has been modified, the iterator re-seeks
+// to the first key following the key of the last returned LeafKind Node and continues from there,
+// instead of returning ErrConcurrentModification. The keys present in the tree throughout the iteration
+// are returned exactly once, the inserted and deleted keys are returned depending on their position.
+// The inner nodes are returned again if the iteration resumes within their subtree.
+// The iterators of a MultiTree and of a tree using a KeyTransformer resume the same way and implement Delete too,
+// the Delete method of a MultiTree iterator removes the last returned value when iterating with TraverseValues,
+// and the key with all its values, returned as a []Value, otherwise.
+type ResilientIterator interface {
+	Iterator
+
+	// Delete removes the key of the last LeafKind Node returned by Next from the tree,
+	// the iteration continues with the following key.
+	// It returns the removed value and true, or nil and false if there is no such key in the tree.
+	Delete() (value Value, deleted bool)
+}
+
+// KindStats holds the statistics of the nodes of a single Kind.
+type KindStats struct {
+	// Count is the number of nodes of the Kind.
+	Count int
+
+	// Bytes is the approximate memory used by the nodes,
+	// computed from the size of the Node structures.
+	// For LeafKind it includes the key bytes stored by the leaves.
+	Bytes int
+
+	// Children is the total number of children of the nodes, zero byte children excluded.
+	Children int
+
+	// FillFactor is the ratio of Children to the capacity of the nodes,
+	// it is zero for LeafKind.
+	FillFactor float64
+}
+
+// TreeStats describes the shape and the approximate memory usage of a tree.
+type TreeStats struct {
+	// Kinds holds the statistics per Node kind, indexed by Kind.
+	Kinds [kindCount]KindStats
+
+	// Leaves is the number of Leaf nodes, which is the number of keys.
+	Leaves int
+
+	// LeafKeyBytes is the total length of the keys stored in the leaves,
+	// the key suffixes only for a tree created with WithLeafSuffixes.
+	LeafKeyBytes int
+
+	// Bytes is the approximate memory used by all nodes and keys, values excluded.
+	Bytes int
+
+	// MaxDepth is the maximum number of inner nodes on a path from the root to a Leaf.
+	MaxDepth int
+
+	// AvgDepth is the average number of inner nodes on a path from the root to a Leaf.
+	AvgDepth float64
+
+	// PrefixLens is the histogram of the inner Node prefix lengths,
+	// it maps a prefix length to the number of inner nodes with that prefix length.
+	PrefixLens map[int]int
+}
+
+// ListResult is a page of the keys listed by List.
+type ListResult struct {
+	// Leaves holds the LeafKind nodes with no delimiter after the listed prefix, in ascending key order.
+	Leaves []NodeKV
+
+	// CommonPrefixes holds the distinct key prefixes ending with the first delimiter
+	// after the listed prefix, in ascending order. Each one stands for all the keys starting with it.
+	CommonPrefixes []Key
+
+	// IsTruncated reports whether more entries follow the page.
+	IsTruncated bool
+
+	// NextStartAfter is the last key or common prefix of the page,
+	// pass it to WithListStartAfter to fetch the next page.
+	NextStartAfter Key
+}
+
 // Tree is an Adaptive Radix Tree interface.
 type Tree interface {
 	// Insert adds a new key-value pair into the tree.
@@ -100,6 +225,11 @@ type Tree interface {
 	// If the key does not exist, it returns nil and false.
 	Search(key Key) (value Value, found bool)
 
+	// LongestPrefix retrieves the longest key of the tree which is a prefix of the specified key,
+	// the key itself included. It returns the found key, its value and true,
+	// or nil, nil and false if no key of the tree is a prefix of the key.
+	LongestPrefix(key Key) (prefix Key, value Value, found bool)
+
 	// ForEach iterates over all the nodes in the tree, invoking a provided callback function for each Node.
 	// By default, it processes LeafKind nodes in ascending order.
 	// The iteration can be customized using options:
@@ -117,6 +247,7 @@ type Tree interface {
 	// Iterator returns an iterator for traversing LeafKind nodes in the tree.
 	// By default, the iteration occurs in ascending order.
 	// To traverse nodes in reverse (descending) order, pass the TraverseReverse option.
+	// Pass the TraverseResilient option to keep iterating while the tree is modified.
 	Iterator(options ...int) Iterator
 
 	// Minimum retrieves the LeafKind Node with the smallest key in the tree.
@@ -132,6 +263,41 @@ type Tree interface {
 	// Size returns the number of key-value pairs stored in the tree.
 	Size() int
 
+	// SplitAt moves all keys greater than or equal to the specified key into a new tree.
+	// It returns the current tree as left, holding the keys less than the key,
+	// and the new tree as right.
+	// Nodes are re-linked along a single root-to-leaf path instead of being reinserted.
+	SplitAt(key Key) (left, right Tree)
+
+	// Clone returns a deep copy of the tree preserving its structure.
+	// Values are shared between the tree and its copy.
+	// The copy of a tree using NewArenaAllocator gets its own arena with the same chunk size,
+	// so that both trees can be used from different goroutines. Other allocators are shared.
+	Clone() Tree
+
+	// CloneWith returns a deep copy of the tree preserving its structure,
+	// each value is copied with the provided cloneValue function.
+	CloneWith(cloneValue func(Value) Value) Tree
+
+	// Stats returns the Node counts, the depth, the prefix length distribution
+	// and the approximate memory usage of the tree.
+	// The statistics are collected by walking the whole tree on each call, which takes O(n) time,
+	// unless the tree is created with WithStatsTracking.
+	// Stats doesn't modify the tree, so it can run concurrently with the other read-only calls.
+	Stats() TreeStats
+
+	// Validate walks the tree and verifies its structural invariants:
+	// the children counts and the key order inside the nodes, the Node kind bounds,
+	// the prefix path of every Leaf, the global key order and the tree size.
+	// It returns a *ValidationError describing the first violation, or nil.
+	Validate() error
+
+	// ForEachPrefixWithSeparator iterates over all LeafKind nodes whose keys start with the specified keyPrefix
+	// and hold at most maxDepth separators after it, as counted by countSeparator(keyPrefix, key).
+	// A negative maxDepth doesn't limit the depth. countSeparator must not decrease when the key is extended,
+	// so that the subtrees whose path already exceeds maxDepth are skipped.
+	// An empty keyPrefix matches all keys.
+	// Iteration stops if the callback function returns false, allowing for early termination.
 	ForEachPrefixWithSeparator(
 		keyPrefix Key,
 		callback Callback,
@@ -139,9 +305,338 @@ type Tree interface {
 		maxDepth int,
 		reverse bool,
 	)
+
+	// List returns the keys starting with the prefix one level below it, like listing a directory:
+	// the keys holding the delimiter after the prefix are rolled up into common prefixes
+	// ending with the first such delimiter, and the subtrees below a common prefix are not visited.
+	// The keys and the common prefixes are listed in ascending order.
+	// Use WithListMaxKeys and WithListStartAfter to page through the results.
+	List(prefix Key, delimiter byte, opts ...ListOption) ListResult
+
+	// Children enumerates the distinct path segments following the prefix, like the entries of a directory.
+	// A segment is the part of a key after the prefix up to the separator, excluded.
+	// The keys without a separator after the prefix are reported as leaf segments,
+	// the other keys are grouped under their first segment, whose subtree is not descended into.
+	// The segments are reported in the order of their keys.
+	// Iteration stops if the callback function returns false.
+	Children(prefix Key, sep byte, cb ChildrenCallback, opts ...ChildrenOption)
+
+	// FuzzySearch invokes the callback for every LeafKind Node whose key is within maxEdits
+	// insertions, deletions or substitutions of the query, in ascending key order.
+	// The edit distance is counted in bytes, pass WithFuzzyRunes to count it in UTF-8 runes.
+	// Iteration stops if the callback function returns false.
+	FuzzySearch(query Key, maxEdits int, cb Callback, opts ...FuzzyOption)
+
+	// ForEachMatch invokes the callback for every LeafKind Node whose key is accepted by the automaton,
+	// in ascending key order. The automaton consumes the key bytes along the tree descent,
+	// the subtrees reached in a state which can't match are skipped.
+	// Iteration stops if the callback function returns false.
+	ForEachMatch(a Automaton, cb Callback)
+
+	// Glob invokes the callback for every LeafKind Node whose key matches the glob pattern,
+	// in ascending key order, see NewGlobAutomaton for the pattern syntax.
+	// The traversal starts at the subtree holding the literal prefix of the pattern,
+	// the keys not ending with its literal suffix are rejected without running the automaton.
+	// Iteration stops if the callback function returns false.
+	// ErrBadPattern is returned if the pattern is malformed.
+	Glob(pattern string, cb Callback, opts ...GlobOption) error
+}
+
+// GlobOption is a function that sets an option for NewGlobAutomaton and Glob.
+type GlobOption func(opts *globOptions)
+
+// WithGlobSeparator sets the ASCII path separator not matched by '*' and '?', '/' by default.
+func WithGlobSeparator(sep byte) GlobOption {
+	return func(opts *globOptions) {
+		opts.sep = sep
+	}
+}
+
+// Automaton is a deterministic automaton over the key bytes guiding ForEachMatch.
+// The states are integers chosen by the automaton, see NewRegexpAutomaton and NewGlobAutomaton.
+type Automaton interface {
+	// Start returns the initial state, before any key byte is consumed.
+	Start() int
+
+	// Step returns the state reached by consuming the byte in the state.
+	Step(state int, b byte) int
+
+	// IsMatch reports whether the key consumed up to the state is accepted.
+	IsMatch(state int) bool
+
+	// CanMatch reports whether the key consumed up to the state, or any key extending it, may be accepted.
+	CanMatch(state int) bool
+}
+
+// FuzzyOption is a function that sets an option for FuzzySearch.
+type FuzzyOption func(opts *fuzzyOptions)
+
+// WithFuzzyRunes counts the edit distance in UTF-8 runes instead of bytes,
+// so that replacing a multibyte character costs a single edit.
+// Invalid UTF-8 bytes are compared as utf8.RuneError.
+func WithFuzzyRunes() FuzzyOption {
+	return func(opts *fuzzyOptions) {
+		opts.runes = true
+	}
+}
+
+// ChildrenCallback receives the path segments enumerated by Children.
+// The count is 1 for a leaf segment. For the other segments it is the number of keys grouped under the segment
+// if WithChildrenCount is set, 0 otherwise.
+// If the callback function returns false, the enumeration is terminated early.
+type ChildrenCallback func(segment Key, isLeaf bool, count int) (cont bool)
+
+// ChildrenOption is a function that sets an option for Children.
+type ChildrenOption func(opts *childrenOptions)
+
+// WithChildrenCount counts the keys grouped under each segment enumerated by Children,
+// at the cost of walking their subtrees.
+func WithChildrenCount() ChildrenOption {
+	return func(opts *childrenOptions) {
+		opts.count = true
+	}
+}
+
+// ListOption is a function that sets an option for List.
+type ListOption func(opts *listOptions)
+
+// WithListStartAfter lists only the keys and the common prefixes greater than the key,
+// the keys rolled up into a skipped common prefix are skipped as well.
+func WithListStartAfter(key Key) ListOption {
+	return func(opts *listOptions) {
+		opts.startAfter = key
+	}
+}
+
+// WithListMaxKeys limits the number of keys and common prefixes returned by List.
+// Zero or a negative number doesn't limit the results, it is the default.
+func WithListMaxKeys(n int) ListOption {
+	return func(opts *listOptions) {
+		opts.maxKeys = n
+	}
+}
+
+// MultiTree is an Adaptive Radix Tree holding an ordered collection of values per key.
+// The values of a key are stored in its LeafKind Node in insertion order.
+type MultiTree interface {
+	// Insert appends the value to the values of the key, creating the key if it doesn't exist.
+	// With WithDeduplication, a value already held by the key is not appended again and false is returned.
+	Insert(key Key, value Value) (inserted bool)
+
+	// DeleteValue removes the first value of the key equal to the specified value.
+	// The key is removed along with its last value.
+	// It returns false if the key doesn't hold the value.
+	DeleteValue(key Key, value Value) (deleted bool)
+
+	// Delete removes the key with all its values and returns them.
+	// If the key does not exist, it returns nil and false.
+	Delete(key Key) (values []Value, deleted bool)
+
+	// SearchAll retrieves the values of the key in insertion order.
+	// The returned slice is not affected by the later modifications of the tree, it must not be modified.
+	// If the key does not exist, it returns nil and false.
+	SearchAll(key Key) (values []Value, found bool)
+
+	// Size returns the number of values stored in the tree,
+	// or the number of keys if the tree is created with WithKeyCount.
+	Size() int
+
+	// ForEach iterates over all the nodes in the tree like Tree.ForEach.
+	// A LeafKind Node is visited once per key and its value is the []Value of the key,
+	// pass TraverseValues to visit it once per value, in insertion order or in reverse with TraverseReverse.
+	ForEach(callback Callback, options ...int)
+
+	// ForEachPrefix iterates over all LeafKind nodes whose keys start with the specified keyPrefix
+	// like Tree.ForEachPrefix, the values are visited like ForEach does.
+	ForEachPrefix(keyPrefix Key, callback Callback, options ...int)
+
+	// Iterator returns an iterator for traversing LeafKind nodes in the tree,
+	// the values are visited like ForEach does.
+	Iterator(options ...int) Iterator
+}
+
+// MultiTreeOption is a function that sets an option for the tree created by NewMultiTree.
+type MultiTreeOption func(opts *multiTreeOptions)
+
+// WithDeduplication makes a key hold each value at most once.
+func WithDeduplication() MultiTreeOption {
+	return func(opts *multiTreeOptions) {
+		opts.dedup = true
+	}
+}
+
+// WithValueEqual sets the function comparing the values for the deduplication and DeleteValue,
+// the values are compared with reflect.DeepEqual by default.
+func WithValueEqual(eq func(a, b Value) bool) MultiTreeOption {
+	return func(opts *multiTreeOptions) {
+		if eq != nil {
+			opts.eq = eq
+		}
+	}
+}
+
+// WithKeyCount makes Size count the keys instead of the values.
+func WithKeyCount() MultiTreeOption {
+	return func(opts *multiTreeOptions) {
+		opts.countKeys = true
+	}
+}
+
+// WithTreeOptions configures the underlying tree with the options of New, such as WithAllocator,
+// WithMaxPrefixLen, WithKeyTransformer or WithLeafSuffixes.
+func WithTreeOptions(opts ...TreeOption) MultiTreeOption {
+	return func(multiOpts *multiTreeOptions) {
+		multiOpts.treeOpts = append(multiOpts.treeOpts, opts...)
+	}
+}
+
+// NewMultiTree creates a new adaptive radix tree holding several values per key.
+func NewMultiTree(opts ...MultiTreeOption) MultiTree {
+	return newMultiTree(opts...)
+}
+
+// KeyCallback defines the function type used during the Set traversal, it is invoked for each key.
+// The key must not be modified.
+// If the callback function returns false, the iteration is terminated early.
+type KeyCallback func(key Key) (cont bool)
+
+// Set is a sorted set of byte strings stored in an adaptive radix tree whose leaves hold no value.
+type Set interface {
+	// Add adds the key to the set, it returns false if the key is already in the set.
+	Add(key Key) (added bool)
+
+	// Remove removes the key from the set, it returns false if the key is not in the set.
+	Remove(key Key) (removed bool)
+
+	// Contains reports whether the key is in the set.
+	Contains(key Key) bool
+
+	// Size returns the number of keys in the set.
+	Size() int
+
+	// ForEach invokes the callback for every key in ascending order,
+	// pass TraverseReverse to iterate in descending order.
+	ForEach(callback KeyCallback, options ...int)
+
+	// ForEachPrefix invokes the callback for every key starting with the prefix like ForEach,
+	// an empty prefix matches all keys.
+	ForEachPrefix(prefix Key, callback KeyCallback, options ...int)
+
+	// Range invokes the callback for every key greater than or equal to start and less than end like ForEach.
+	// A nil start or end leaves the range unbounded on that side.
+	// The subtrees out of the range are not visited.
+	Range(start, end Key, callback KeyCallback, options ...int)
+
+	// Union returns a new set holding the keys of the set and of the other set.
+	Union(other Set) Set
+
+	// Intersection returns a new set holding the keys of the set which are in the other set.
+	Intersection(other Set) Set
+
+	// Difference returns a new set holding the keys of the set which are not in the other set.
+	Difference(other Set) Set
+
+	// Clone returns a copy of the set.
+	Clone() Set
+}
+
+// NewSet creates a new empty Set.
+// The keys are stored in a compact tree, see NewCompact, which allocates no value storage for the set.
+func NewSet() Set {
+	return newSet()
+}
+
+// TreeOption is a function that sets an option for the tree created by New.
+type TreeOption func(opts *treeOptions)
+
+// Prefix storage modes, see WithMaxPrefixLen.
+const (
+	// OptimisticPrefixLen is the default number of prefix bytes stored in nodes.
+	OptimisticPrefixLen = maxPrefixLen
+
+	// PessimisticPrefixLen stores the complete prefix in nodes.
+	PessimisticPrefixLen = -1
+)
+
+// WithMaxPrefixLen sets the maximum number of prefix bytes stored in inner nodes.
+// Prefixes longer than that are truncated (optimistic path compression) and the missing bytes
+// are verified against a Leaf key on insertion or when a search reaches a Leaf.
+// PessimisticPrefixLen stores complete prefixes, prefixes up to OptimisticPrefixLen bytes
+// are kept inline and longer ones are heap-allocated, so the search never has to load a Leaf
+// to verify the path.
+func WithMaxPrefixLen(n int) TreeOption {
+	return func(opts *treeOptions) {
+		if n < 0 || n > math.MaxUint16 {
+			n = math.MaxUint16
+		}
+
+		opts.maxPrefixLen = n
+	}
+}
+
+// WithAllocator sets the NodeFactory used to allocate and recycle the tree nodes.
+// By default, nodes are allocated on the heap one by one, see NewArenaAllocator
+// for an allocator reducing the garbage collector pressure of large trees.
+func WithAllocator(f NodeFactory) TreeOption {
+	return func(opts *treeOptions) {
+		if f != nil {
+			opts.factory = f
+		}
+	}
+}
+
+// KeyTransformer maps a key to the sort key used to store and order it in the tree,
+// see FoldCase and ChainKeyTransformers, and the collation package for the Unicode normalization
+// and a simple collation. The composed normalization forms, NFC and NFKC, are not supported
+// since they don't preserve the prefixes.
+// A KeyTransformer must be deterministic and idempotent, transforming a sort key returns it unchanged,
+// and it must preserve the prefixes, the sort key of a prefix is a prefix of the sort keys of its extensions.
+// It must not modify the key, but it may return the key itself if it is its own sort key.
+type KeyTransformer func(key []byte) []byte
+
+// WithKeyTransformer makes the tree store and order the keys by their sort key.
+// All tree operations transform the keys, the prefixes and the queries they are given,
+// while the LeafKind nodes keep the key they were first inserted with for NodeKV.Key.
+// Updating a key through another key with the same sort key replaces the value and keeps the original key.
+// The common prefixes of List, the segments of Children, the keys passed to the separator function
+// of ForEachPrefixWithSeparator and the keys consumed by an Automaton are sort keys.
+// The glob pattern of Glob is transformed like a key.
+func WithKeyTransformer(transform KeyTransformer) TreeOption {
+	return func(opts *treeOptions) {
+		opts.transform = transform
+	}
+}
+
+// WithLeafSuffixes makes the leaves store only the key suffix below their depth,
+// the bytes already implied by the path and the Node prefixes are not duplicated in every Leaf.
+// It saves most of the Leaf key memory for long keys sharing long prefixes, such as URLs or file paths,
+// and the short suffixes are stored in the same allocation as their Leaf.
+// The complete prefixes are stored in the inner nodes, like with WithMaxPrefixLen(PessimisticPrefixLen),
+// since a truncated prefix could not be restored from the Leaf keys. The option overrides WithMaxPrefixLen.
+// The keys of the LeafKind nodes passed to the callbacks and returned by the iterators are restored
+// from the traversal path, each of them is a new allocation.
+func WithLeafSuffixes() TreeOption {
+	return func(opts *treeOptions) {
+		opts.leafSuffixes = true
+	}
+}
+
+// WithStatsTracking makes the tree update its statistics on every insertion and deletion,
+// so that Stats returns them without walking the tree. The tracking costs a few counter updates
+// per modified Node. MaxDepth and AvgDepth are not tracked, they are zero.
+// SplitAt, Join, Clone and CloneWith collect the statistics of the trees they return by walking them.
+func WithStatsTracking() TreeOption {
+	return func(opts *treeOptions) {
+		opts.stats = &statsCollector{}
+	}
 }
 
 // New creates a new adaptive radix tree.
-func New() Tree {
-	return newTree()
+func New(opts ...TreeOption) Tree {
+	tr := newTree(opts...)
+	if tr.opts.transform != nil {
+		return newTransformedTree(tr)
+	}
+
+	return tr
 }
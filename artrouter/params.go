@@ -0,0 +1,32 @@
+package artrouter
+
+// Param is a route parameter extracted from the request path.
+type Param struct {
+	Key   string // Key is the parameter name, without the ':' or '*' marker
+	Value string // Value is the path segment, or the rest of the path for a catch-all parameter
+}
+
+// Params holds the route parameters in the order of the route pattern.
+// The values are substrings of the request path, they are extracted without allocations.
+type Params []Param
+
+// ByName returns the value of the named parameter, or an empty string if there is none.
+func (ps Params) ByName(name string) string {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value
+		}
+	}
+
+	return ""
+}
+
+// Clone returns a copy of the parameters which outlives the request handling,
+// see Handle.
+func (ps Params) Clone() Params {
+	if ps == nil {
+		return nil
+	}
+
+	return append(make(Params, 0, len(ps)), ps...)
+}
@@ -0,0 +1,350 @@
+// Package artrouter implements an HTTP request router on top of the adaptive radix tree.
+//
+// The route patterns are made of path segments separated by '/', a segment is either static,
+// a named parameter ":name" matching a non-empty segment, or a catch-all parameter "*name"
+// matching the rest of the path, which must end the pattern:
+//
+//	router := artrouter.New()
+//	router.Handle(http.MethodGet, "/users/:id/posts/*rest",
+//	    func(w http.ResponseWriter, r *http.Request, ps artrouter.Params) {
+//	        fmt.Fprintf(w, "user %s, post %s", ps.ByName("id"), ps.ByName("rest"))
+//	    })
+//	http.ListenAndServe(":8080", router)
+//
+// The static segments take precedence over the parameters, which take precedence over the catch-all parameters,
+// the router backtracks when a more specific route doesn't match the rest of the path.
+//
+// The consecutive static segments of the routes are stored as single keys in an adaptive radix tree per position,
+// they are matched by a longest prefix search falling back to the shorter prefixes ending at a segment boundary.
+package artrouter
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"unsafe"
+
+	art "github.com/alexisvisco/go-adaptive-radix-tree/v2"
+)
+
+// These errors can be returned by Router.Handle.
+var (
+	ErrInvalidPattern   = errors.New("invalid route pattern")
+	ErrDuplicateRoute   = errors.New("route already registered")
+	ErrConflictingRoute = errors.New("route parameter conflicts with an existing route")
+)
+
+// Handle handles a request along with the route parameters.
+// The parameters are recycled by the router once the handle returns,
+// a handle keeping them longer, e.g. in a goroutine it starts, must copy them with Params.Clone.
+type Handle func(w http.ResponseWriter, r *http.Request, ps Params)
+
+// node is a position at the start of a path segment.
+type node struct {
+	statics  art.Tree   // statics maps the static segment runs starting at the node to the node following them
+	param    *paramEdge // param is the named parameter segment starting at the node
+	catchAll *catchAll  // catchAll is the catch-all parameter starting at the node
+	handle   Handle     // handle handles the path ending at the node
+}
+
+// paramEdge is a named parameter segment.
+type paramEdge struct {
+	name   string // name is the parameter name
+	handle Handle // handle handles the path ending with the parameter
+	next   *node  // next is the position following the parameter and a '/'
+}
+
+// catchAll is a catch-all parameter.
+type catchAll struct {
+	name   string // name is the parameter name
+	handle Handle // handle handles the path ending with the parameter
+}
+
+func newNode() *node {
+	return &node{statics: art.New()}
+}
+
+// Router dispatches the requests to the handles registered for their method and path.
+// The routes must be registered before serving the requests.
+type Router struct {
+	trees     map[string]*node // trees holds the root node of each method
+	maxParams int              // maxParams is the maximum number of parameters of a route
+	params    sync.Pool        // params recycles the Params of the served requests
+
+	// NotFound handles the requests matching no route, http.NotFound is used if it is nil.
+	NotFound http.Handler
+}
+
+// assert that Router implements the http.Handler interface.
+var _ http.Handler = (*Router)(nil)
+
+// New creates an empty Router.
+func New() *Router {
+	r := &Router{trees: make(map[string]*node)}
+	r.params.New = func() interface{} {
+		ps := make(Params, 0, r.maxParams)
+
+		return &ps
+	}
+
+	return r
+}
+
+// Handle registers the handle for the method and the route pattern.
+// ErrInvalidPattern is returned if the pattern is malformed, ErrDuplicateRoute if it is already registered
+// and ErrConflictingRoute if a parameter has a different name than the one of another route at the same position.
+func (r *Router) Handle(method, pattern string, handle Handle) error {
+	if !strings.HasPrefix(pattern, "/") || handle == nil {
+		return ErrInvalidPattern
+	}
+
+	root, ok := r.trees[method]
+	if !ok {
+		root = newNode()
+		r.trees[method] = root
+	}
+
+	params, err := root.add(pattern[1:], handle)
+	if err != nil {
+		return err
+	}
+
+	if params > r.maxParams {
+		r.maxParams = params
+	}
+
+	return nil
+}
+
+// HandlerFunc registers the handler function for the method and the route pattern,
+// the route parameters are ignored.
+func (r *Router) HandlerFunc(method, pattern string, handler http.HandlerFunc) error {
+	return r.Handle(method, pattern, func(w http.ResponseWriter, req *http.Request, _ Params) {
+		handler(w, req)
+	})
+}
+
+// add registers the handle for the pattern starting at the node and returns the number of parameters.
+func (n *node) add(pattern string, handle Handle) (int, error) {
+	// the static run ends before the first parameter segment
+	static := pattern
+	for i := 0; i < len(pattern); {
+		if pattern[i] == ':' || pattern[i] == '*' {
+			static = pattern[:i]
+
+			break
+		}
+
+		next := strings.IndexByte(pattern[i:], '/')
+		if next < 0 {
+			break
+		}
+
+		i += next + 1
+	}
+
+	if strings.ContainsAny(static, ":*") {
+		return 0, ErrInvalidPattern
+	}
+
+	if static != "" {
+		child := newNode()
+		if v, found := n.statics.Search(art.Key(static)); found {
+			child = v.(*node) //nolint:forcetypeassert
+		} else {
+			n.statics.Insert(art.Key(static), child)
+		}
+
+		return child.add(pattern[len(static):], handle)
+	}
+
+	if pattern == "" {
+		if n.handle != nil {
+			return 0, ErrDuplicateRoute
+		}
+
+		n.handle = handle
+
+		return 0, nil
+	}
+
+	segment, rest, hasRest := strings.Cut(pattern, "/")
+	name := segment[1:]
+
+	if name == "" || strings.ContainsAny(name, ":*") {
+		return 0, ErrInvalidPattern
+	}
+
+	if segment[0] == '*' {
+		return 1, n.addCatchAll(name, hasRest, handle)
+	}
+
+	if n.param == nil {
+		n.param = &paramEdge{name: name}
+	} else if n.param.name != name {
+		return 0, ErrConflictingRoute
+	}
+
+	if !hasRest {
+		if n.param.handle != nil {
+			return 0, ErrDuplicateRoute
+		}
+
+		n.param.handle = handle
+
+		return 1, nil
+	}
+
+	if n.param.next == nil {
+		n.param.next = newNode()
+	}
+
+	params, err := n.param.next.add(rest, handle)
+
+	return params + 1, err
+}
+
+// addCatchAll registers the handle for the catch-all parameter ending the pattern.
+func (n *node) addCatchAll(name string, hasRest bool, handle Handle) error {
+	if hasRest {
+		return ErrInvalidPattern
+	}
+
+	if n.catchAll != nil {
+		if n.catchAll.name != name {
+			return ErrConflictingRoute
+		}
+
+		return ErrDuplicateRoute
+	}
+
+	n.catchAll = &catchAll{name: name, handle: handle}
+
+	return nil
+}
+
+// Lookup returns the handle of the route matching the method and the path,
+// the route parameters are appended to ps. It doesn't allocate if ps has enough capacity.
+func (r *Router) Lookup(method, path string, ps Params) (Handle, Params) {
+	root, ok := r.trees[method]
+	if !ok || !strings.HasPrefix(path, "/") {
+		return nil, ps
+	}
+
+	return root.match(path[1:], ps)
+}
+
+// match matches the path starting at the node, the static segments first,
+// then the parameter and finally the catch-all parameter.
+func (n *node) match(path string, ps Params) (Handle, Params) {
+	if path == "" && n.handle != nil {
+		return n.handle, ps
+	}
+
+	if handle, mps := n.matchStatic(path, ps); handle != nil {
+		return handle, mps
+	}
+
+	if handle, mps := n.matchParam(path, ps); handle != nil {
+		return handle, mps
+	}
+
+	if n.catchAll != nil {
+		return n.catchAll.handle, append(ps, Param{Key: n.catchAll.name, Value: path})
+	}
+
+	return nil, ps
+}
+
+// matchStatic matches the static runs which are prefixes of the path ending at a segment boundary,
+// from the longest to the shortest.
+func (n *node) matchStatic(path string, ps Params) (Handle, Params) {
+	key := path
+
+	for key != "" {
+		prefix, v, found := n.statics.LongestPrefix(unsafeKey(key))
+		if !found || len(prefix) == 0 {
+			break
+		}
+
+		// a run ends with '/' before a parameter or at the end of the route
+		if prefix[len(prefix)-1] == '/' || len(prefix) == len(path) || path[len(prefix)] == '/' {
+			child := v.(*node) //nolint:forcetypeassert
+			if handle, mps := child.match(path[len(prefix):], ps); handle != nil {
+				return handle, mps
+			}
+		}
+
+		key = path[:len(prefix)-1]
+	}
+
+	return nil, ps
+}
+
+// matchParam matches the parameter with the first segment of the path.
+func (n *node) matchParam(path string, ps Params) (Handle, Params) {
+	if n.param == nil {
+		return nil, ps
+	}
+
+	end := strings.IndexByte(path, '/')
+	if end == 0 || path == "" {
+		return nil, ps
+	}
+
+	if end < 0 {
+		if n.param.handle == nil {
+			return nil, ps
+		}
+
+		return n.param.handle, append(ps, Param{Key: n.param.name, Value: path})
+	}
+
+	if n.param.next == nil {
+		return nil, ps
+	}
+
+	handle, mps := n.param.next.match(path[end+1:], append(ps, Param{Key: n.param.name, Value: path[:end]}))
+	if handle == nil {
+		return nil, ps
+	}
+
+	return handle, mps
+}
+
+// unsafeKey returns the bytes of the string without copying them, the key must not be modified.
+func unsafeKey(s string) art.Key {
+	if s == "" {
+		return art.Key{}
+	}
+
+	return unsafe.Slice(*(**byte)(unsafe.Pointer(&s)), len(s))
+}
+
+// ServeHTTP dispatches the request to the handle of the matching route.
+// The Params passed to the handle are reused for the next requests after it returns.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	psp := r.params.Get().(*Params) //nolint:forcetypeassert
+	defer func() {
+		*psp = (*psp)[:0]
+		r.params.Put(psp)
+	}()
+
+	handle, ps := r.Lookup(req.Method, req.URL.Path, (*psp)[:0])
+	*psp = ps
+
+	if handle != nil {
+		handle(w, req, ps)
+
+		return
+	}
+
+	if r.NotFound != nil {
+		r.NotFound.ServeHTTP(w, req)
+
+		return
+	}
+
+	http.NotFound(w, req)
+}
@@ -0,0 +1,244 @@
+package artrouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// namedHandle returns a handle writing the route name followed by the route parameters.
+func namedHandle(name string) Handle {
+	return func(w http.ResponseWriter, _ *http.Request, ps Params) {
+		_, _ = w.Write([]byte(name))
+		for _, p := range ps {
+			_, _ = w.Write([]byte(" " + p.Key + "=" + p.Value))
+		}
+	}
+}
+
+// newTestRouter registers the GET routes, each handle writes its pattern.
+func newTestRouter(t *testing.T, patterns ...string) *Router {
+	t.Helper()
+
+	r := New()
+	for _, pattern := range patterns {
+		require.NoError(t, r.Handle(http.MethodGet, pattern, namedHandle(pattern)))
+	}
+
+	return r
+}
+
+// serve returns the body written for the GET request of the path.
+func serve(r *Router, path string) string {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return rec.Body.String()
+}
+
+func TestRouterLookup(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRouter(t,
+		"/",
+		"/users",
+		"/users/new",
+		"/users/:id",
+		"/users/:id/posts",
+		"/users/:id/posts/*rest",
+		"/users/admin/posts",
+		"/files/*path",
+		"/static/css/main.css",
+		"/static/:file",
+	)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", "/"},
+		{"/users", "/users"},
+		{"/users/new", "/users/new"},
+		{"/users/42", "/users/:id id=42"},
+		{"/users/newer", "/users/:id id=newer"},
+		{"/users/42/posts", "/users/:id/posts id=42"},
+		{"/users/42/posts/", "/users/:id/posts/*rest id=42 rest="},
+		{"/users/42/posts/2024/01/hello", "/users/:id/posts/*rest id=42 rest=2024/01/hello"},
+		{"/users/admin/posts", "/users/admin/posts"},
+		{"/users/admin/posts/1", "/users/:id/posts/*rest id=admin rest=1"},
+		{"/files/", "/files/*path path="},
+		{"/files/a/b/c.txt", "/files/*path path=a/b/c.txt"},
+		{"/static/css/main.css", "/static/css/main.css"},
+		{"/static/css", "/static/:file file=css"},
+		{"/static/main.css", "/static/:file file=main.css"},
+		{"/users/", ""},
+		{"/users//posts", ""},
+		{"/files", ""},
+		{"/static/css/other.css", ""},
+		{"/unknown", ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			handle, ps := r.Lookup(http.MethodGet, tt.path, nil)
+			if tt.want == "" {
+				assert.Nil(t, handle)
+				assert.Empty(t, ps)
+
+				return
+			}
+
+			require.NotNil(t, handle)
+			assert.Equal(t, tt.want, serve(r, tt.path))
+		})
+	}
+}
+
+func TestRouterPriority(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRouter(t, "/a/*rest", "/a/:p", "/a/b")
+
+	assert.Equal(t, "/a/b", serve(r, "/a/b"))
+	assert.Equal(t, "/a/:p p=c", serve(r, "/a/c"))
+	assert.Equal(t, "/a/*rest rest=b/c", serve(r, "/a/b/c"))
+	assert.Equal(t, "/a/*rest rest=", serve(r, "/a/"))
+}
+
+func TestRouterBacktracking(t *testing.T) {
+	t.Parallel()
+
+	// the static runs "x/y/z" and "x/" both prefix the paths below
+	r := newTestRouter(t, "/x/y/z", "/x/:a/w", "/x/y/:b/v")
+
+	assert.Equal(t, "/x/y/z", serve(r, "/x/y/z"))
+	assert.Equal(t, "/x/:a/w a=y", serve(r, "/x/y/w"))
+	assert.Equal(t, "/x/y/:b/v b=z", serve(r, "/x/y/z/v"))
+	assert.Equal(t, "/x/:a/w a=yy", serve(r, "/x/yy/w"))
+	assert.Equal(t, "404 page not found\n", serve(r, "/x/y/z/w"))
+}
+
+func TestRouterMethods(t *testing.T) {
+	t.Parallel()
+
+	r := New()
+	require.NoError(t, r.Handle(http.MethodGet, "/items/:id", namedHandle("get")))
+	require.NoError(t, r.Handle(http.MethodPost, "/items", namedHandle("post")))
+	require.NoError(t, r.HandlerFunc(http.MethodDelete, "/items/:id", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	handle, ps := r.Lookup(http.MethodGet, "/items/7", nil)
+	require.NotNil(t, handle)
+	assert.Equal(t, "7", ps.ByName("id"))
+	assert.Equal(t, "", ps.ByName("missing"))
+
+	handle, _ = r.Lookup(http.MethodPost, "/items/7", nil)
+	assert.Nil(t, handle)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/items/7", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/items/7", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	r.NotFound = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/nothing", nil))
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+}
+
+func TestRouterParamsClone(t *testing.T) {
+	t.Parallel()
+
+	var kept, cloned Params
+
+	r := New()
+	require.NoError(t, r.Handle(http.MethodGet, "/users/:id", func(_ http.ResponseWriter, _ *http.Request, ps Params) {
+		if kept == nil {
+			kept, cloned = ps, ps.Clone()
+		}
+	}))
+
+	serve(r, "/users/1")
+	serve(r, "/users/2")
+
+	assert.Equal(t, Params{{Key: "id", Value: "1"}}, cloned)
+	assert.Nil(t, Params(nil).Clone())
+}
+
+func TestRouterHandleErrors(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRouter(t, "/users/:id", "/users/:id/edit", "/files/*path")
+
+	tests := []struct {
+		pattern string
+		err     error
+	}{
+		{"", ErrInvalidPattern},
+		{"users", ErrInvalidPattern},
+		{"/users/:", ErrInvalidPattern},
+		{"/users/:id:x", ErrInvalidPattern},
+		{"/users/a:b", ErrInvalidPattern},
+		{"/files/*", ErrInvalidPattern},
+		{"/files/*path/more", ErrInvalidPattern},
+		{"/users/:id", ErrDuplicateRoute},
+		{"/users/:id/edit", ErrDuplicateRoute},
+		{"/files/*path", ErrDuplicateRoute},
+		{"/users/:name", ErrConflictingRoute},
+		{"/users/:name/edit", ErrConflictingRoute},
+		{"/files/*rest", ErrConflictingRoute},
+	}
+
+	for _, tt := range tests {
+		assert.ErrorIs(t, r.Handle(http.MethodGet, tt.pattern, namedHandle(tt.pattern)), tt.err, tt.pattern)
+	}
+
+	assert.ErrorIs(t, r.Handle(http.MethodGet, "/nil", nil), ErrInvalidPattern)
+}
+
+func TestRouterLookupAllocs(t *testing.T) {
+	r := newTestRouter(t, "/users/:id/posts/:post", "/users/:id/posts/:post/*rest", "/static/css/main.css")
+	ps := make(Params, 0, 3)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		handle, mps := r.Lookup(http.MethodGet, "/users/42/posts/7/comments/1", ps)
+		if handle == nil || len(mps) != 3 {
+			t.Fatal("route not found")
+		}
+
+		if handle, _ = r.Lookup(http.MethodGet, "/static/css/main.css", ps); handle == nil {
+			t.Fatal("route not found")
+		}
+	})
+
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkRouterLookup(b *testing.B) {
+	r := New()
+	for _, pattern := range []string{"/", "/users", "/users/:id", "/users/:id/posts/*rest", "/static/css/main.css"} {
+		_ = r.Handle(http.MethodGet, pattern, namedHandle(pattern))
+	}
+
+	ps := make(Params, 0, 2)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r.Lookup(http.MethodGet, "/users/42/posts/2024/01/hello", ps)
+	}
+}
@@ -0,0 +1,227 @@
+package art
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// regexpDeadState is the state of the regexpAutomaton which can't match anymore.
+const regexpDeadState = 0
+
+// regexpState is a state of the lazily built DFA.
+type regexpState struct {
+	pcs     []uint32          // pcs are the NFA threads waiting for a rune or an empty-width assertion
+	flags   syntax.EmptyOp    // flags are the empty-width assertions satisfied at the state position
+	pending string            // pending holds the bytes of an incomplete UTF-8 rune
+	next    [node256Max]int32 // next caches the transitions, the target state + 1 or 0 if not computed yet
+}
+
+// regexpAutomaton is a DFA over the key bytes built lazily from a compiled regular expression.
+// Each DFA state is the set of the NFA threads alive after the consumed runes,
+// the bytes of a rune are accumulated until the rune is complete.
+type regexpAutomaton struct {
+	mu     sync.Mutex     // mu guards the lazily built states
+	prog   *syntax.Prog   // prog is the compiled expression
+	states []*regexpState // states are the built states, indexed by their identifier
+	ids    map[string]int // ids maps the state keys to the state identifiers
+	start  int            // start is the initial state
+}
+
+// assert that regexpAutomaton implements the Automaton interface.
+var _ Automaton = (*regexpAutomaton)(nil)
+
+// NewRegexpAutomaton compiles the regular expression, in the syntax of the regexp package,
+// into an Automaton accepting the keys entirely matched by the expression.
+// The keys are decoded as UTF-8, the word boundary assertions are not supported.
+// The automaton builds its states on demand and is safe for concurrent use.
+func NewRegexpAutomaton(expr string) (Automaton, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range prog.Inst {
+		if inst.Op == syntax.InstEmptyWidth &&
+			syntax.EmptyOp(inst.Arg)&(syntax.EmptyWordBoundary|syntax.EmptyNoWordBoundary) != 0 {
+			return nil, fmt.Errorf("unsupported word boundary assertion in %q", expr)
+		}
+	}
+
+	a := &regexpAutomaton{
+		prog: prog,
+		ids:  make(map[string]int),
+	}
+
+	a.intern(nil, 0, "") // regexpDeadState
+
+	beginFlags := syntax.EmptyBeginText | syntax.EmptyBeginLine
+	a.start = a.intern(a.closure([]uint32{uint32(prog.Start)}, beginFlags), beginFlags, "")
+
+	return a, nil
+}
+
+// intern returns the identifier of the state, creating the state if it doesn't exist yet.
+func (a *regexpAutomaton) intern(pcs []uint32, flags syntax.EmptyOp, pending string) int {
+	if len(pcs) == 0 {
+		pcs, flags, pending = nil, 0, ""
+	}
+
+	var key strings.Builder
+
+	key.WriteByte(byte(flags))
+
+	for _, pc := range pcs {
+		fmt.Fprintf(&key, "%d,", pc)
+	}
+
+	key.WriteByte('|')
+	key.WriteString(pending)
+
+	if id, ok := a.ids[key.String()]; ok {
+		return id
+	}
+
+	id := len(a.states)
+	a.states = append(a.states, &regexpState{pcs: pcs, flags: flags, pending: pending})
+	a.ids[key.String()] = id
+
+	return id
+}
+
+// closure follows the NFA threads through the instructions which don't consume a rune.
+// The threads stopped by an empty-width assertion which isn't satisfied by the flags are kept,
+// so that the end of text assertions can be satisfied later.
+func (a *regexpAutomaton) closure(pcs []uint32, flags syntax.EmptyOp) []uint32 {
+	seen := make(map[uint32]bool)
+
+	var (
+		result []uint32
+		add    func(pc uint32)
+	)
+
+	add = func(pc uint32) {
+		if seen[pc] {
+			return
+		}
+
+		seen[pc] = true
+
+		inst := &a.prog.Inst[pc]
+
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			add(inst.Out)
+			add(inst.Arg)
+		case syntax.InstCapture, syntax.InstNop:
+			add(inst.Out)
+		case syntax.InstEmptyWidth:
+			if syntax.EmptyOp(inst.Arg)&^flags == 0 {
+				add(inst.Out)
+			} else {
+				result = append(result, pc)
+			}
+		case syntax.InstFail:
+		case syntax.InstMatch, syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+			result = append(result, pc)
+		}
+	}
+
+	for _, pc := range pcs {
+		add(pc)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+
+	return result
+}
+
+// stepRune advances the NFA threads over the rune.
+func (a *regexpAutomaton) stepRune(pcs []uint32, r rune) []uint32 {
+	var next []uint32
+
+	for _, pc := range pcs {
+		inst := &a.prog.Inst[pc]
+
+		var ok bool
+
+		switch inst.Op { //nolint:exhaustive
+		case syntax.InstRune, syntax.InstRune1:
+			ok = inst.MatchRune(r)
+		case syntax.InstRuneAny:
+			ok = true
+		case syntax.InstRuneAnyNotNL:
+			ok = r != '\n'
+		}
+
+		if ok {
+			next = append(next, inst.Out)
+		}
+	}
+
+	return a.closure(next, 0)
+}
+
+// Start returns the initial state.
+func (a *regexpAutomaton) Start() int {
+	return a.start
+}
+
+// Step returns the state reached by consuming the byte.
+func (a *regexpAutomaton) Step(state int, b byte) int {
+	if state == regexpDeadState {
+		return regexpDeadState
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	st := a.states[state]
+	if next := st.next[b]; next != 0 {
+		return int(next - 1)
+	}
+
+	pcs, flags, pending := st.pcs, st.flags, st.pending+string([]byte{b})
+	for len(pcs) > 0 && utf8.FullRuneInString(pending) {
+		r, size := utf8.DecodeRuneInString(pending)
+		pcs, flags, pending = a.stepRune(pcs, r), 0, pending[size:]
+	}
+
+	next := a.intern(pcs, flags, pending)
+	st.next[b] = int32(next + 1) //nolint:gosec
+
+	return next
+}
+
+// IsMatch reports whether the key ending at the state is accepted.
+// A key ending with an incomplete rune is not accepted.
+func (a *regexpAutomaton) IsMatch(state int) bool {
+	a.mu.Lock()
+	st := a.states[state]
+	a.mu.Unlock()
+
+	if st.pending != "" {
+		return false
+	}
+
+	for _, pc := range a.closure(st.pcs, st.flags|syntax.EmptyEndText|syntax.EmptyEndLine) {
+		if a.prog.Inst[pc].Op == syntax.InstMatch {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanMatch reports whether an NFA thread is still alive.
+func (a *regexpAutomaton) CanMatch(state int) bool {
+	return state != regexpDeadState
+}
@@ -0,0 +1,191 @@
+package art
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// automatonMatch runs the automaton over the key.
+func automatonMatch(a Automaton, key string) bool {
+	state, ok := stepAutomaton(a, a.Start(), Key(key))
+
+	return ok && a.IsMatch(state)
+}
+
+func TestRegexpAutomaton(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{
+		"", "a", "ab", "abc", "abcabc", "b", "ba", "aaa", "x\ny", "héllo", "hello", "日本", "日本語", "\xff",
+	}
+
+	exprs := []string{
+		"", "a", "ab*c?", "(abc)+", "[a-c]+", "^ab$", "a|b", ".*", ".+", "h.llo", "h[^e]llo",
+		"日本.?", "(?i)AB", "a{2,3}", "x.y", "(?s)x.y", "\\xff", ".", "a$|b",
+	}
+
+	for _, expr := range exprs {
+		a, err := NewRegexpAutomaton(expr)
+		require.NoError(t, err)
+
+		re := regexp.MustCompile("^(?:" + expr + ")$")
+
+		for _, key := range keys {
+			assert.Equal(t, re.MatchString(key), automatonMatch(a, key), "expr %q key %q", expr, key)
+		}
+	}
+}
+
+func TestRegexpAutomatonErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewRegexpAutomaton("a(")
+	assert.Error(t, err)
+
+	_, err = NewRegexpAutomaton(`\bword\b`)
+	assert.Error(t, err)
+}
+
+func TestRegexpAutomatonCanMatch(t *testing.T) {
+	t.Parallel()
+
+	a, err := NewRegexpAutomaton("user/[0-9]+/settings")
+	require.NoError(t, err)
+
+	state, ok := stepAutomaton(a, a.Start(), Key("user/12"))
+	assert.True(t, ok)
+	assert.False(t, a.IsMatch(state))
+
+	_, ok = stepAutomaton(a, a.Start(), Key("user/x"))
+	assert.False(t, ok)
+
+	_, ok = stepAutomaton(a, a.Start(), Key("admin"))
+	assert.False(t, ok)
+}
+
+// countingAutomaton counts the bytes consumed by the wrapped automaton.
+type countingAutomaton struct {
+	Automaton
+	steps int
+}
+
+func (a *countingAutomaton) Step(state int, b byte) int {
+	a.steps++
+
+	return a.Automaton.Step(state, b)
+}
+
+func TestTreeForEachMatch(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{
+		"admin/1/settings", "user/1/profile", "user/1/settings", "user/2/settings", "user/22/x/settings", "users",
+	}
+
+	for _, e := range engines {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := e.newTree()
+			for _, k := range keys {
+				tree.Insert(Key(k), k)
+			}
+
+			a, err := NewGlobAutomaton("user/*/settings")
+			require.NoError(t, err)
+
+			var matched []string
+
+			tree.ForEachMatch(a, func(node NodeKV) bool {
+				matched = append(matched, string(node.Key()))
+
+				return true
+			})
+			assert.Equal(t, []string{"user/1/settings", "user/2/settings"}, matched)
+
+			matched = nil
+
+			tree.ForEachMatch(a, func(node NodeKV) bool {
+				matched = append(matched, string(node.Key()))
+
+				return false
+			})
+			assert.Equal(t, []string{"user/1/settings"}, matched)
+		})
+	}
+}
+
+func TestTreeForEachMatchPrunes(t *testing.T) {
+	t.Parallel()
+
+	tree := New()
+	for i := 0; i < 1000; i++ {
+		tree.Insert(Key{'a', byte(i / 256), byte(i), 'z'}, i)
+	}
+
+	tree.Insert(Key("bz"), "bz")
+
+	re, err := NewRegexpAutomaton("b.*")
+	require.NoError(t, err)
+
+	a := &countingAutomaton{Automaton: re}
+
+	var matched []string
+
+	tree.ForEachMatch(a, func(node NodeKV) bool {
+		matched = append(matched, string(node.Key()))
+
+		return true
+	})
+
+	assert.Equal(t, []string{"bz"}, matched)
+	assert.Less(t, a.steps, 10)
+}
+
+func TestTreeForEachMatchWords(t *testing.T) {
+	t.Parallel()
+
+	words := loadTestFile("test/assets/words.txt")
+	re := regexp.MustCompile("^(?:a[lm].*ing|z.z.*)$")
+
+	for _, e := range engines {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := e.newTree()
+
+			var expected []string
+
+			for _, w := range words {
+				tree.Insert(w, w)
+			}
+
+			tree.ForEach(func(node NodeKV) bool {
+				if re.Match(node.Key()) {
+					expected = append(expected, string(node.Key()))
+				}
+
+				return true
+			})
+
+			a, err := NewRegexpAutomaton("a[lm].*ing|z.z.*")
+			require.NoError(t, err)
+
+			var matched []string
+
+			tree.ForEachMatch(a, func(node NodeKV) bool {
+				matched = append(matched, string(node.Key()))
+
+				return true
+			})
+
+			assert.NotEmpty(t, matched)
+			assert.Equal(t, expected, matched)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package cidr
+
+import "math/bits"
+
+// bitset256 is a set of the byte values.
+type bitset256 [4]uint64
+
+// test reports whether the value is in the set.
+func (b *bitset256) test(i uint8) bool {
+	return b[i>>6]&(1<<(i&63)) != 0
+}
+
+// set adds the value to the set.
+func (b *bitset256) set(i uint8) {
+	b[i>>6] |= 1 << (i & 63)
+}
+
+// clear removes the value from the set.
+func (b *bitset256) clear(i uint8) {
+	b[i>>6] &^= 1 << (i & 63)
+}
+
+// rank returns the number of values in the set less than i.
+func (b *bitset256) rank(i uint8) int {
+	word := int(i >> 6)
+
+	n := 0
+	for w := 0; w < word; w++ {
+		n += bits.OnesCount64(b[w])
+	}
+
+	return n + bits.OnesCount64(b[word]&(1<<(i&63)-1))
+}
+
+// isEmpty reports whether the set holds no value.
+func (b *bitset256) isEmpty() bool {
+	return b[0]|b[1]|b[2]|b[3] == 0
+}
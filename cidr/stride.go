@@ -0,0 +1,100 @@
+package cidr
+
+// Allotment indexes number the prefixes within an octet as the nodes of a complete binary tree,
+// as in Knuth's allotment routing tables: the prefix of length bits of the octet has the index
+// 1<<bits + octet>>(8-bits). The default route of the octet is 1, its children are 2 and 3, and so on,
+// the index of a prefix of 7 bits is within [128, 255].
+const maxStrideBits = 7
+
+// allotmentIndex returns the index of the prefix made of the first bits of the octet.
+func allotmentIndex(octet uint8, bits int) uint8 {
+	return uint8(1<<bits | int(octet)>>(8-bits)) //nolint:gosec
+}
+
+// indexBits returns the length of the prefix with the allotment index.
+func indexBits(idx uint8) int {
+	bits := 0
+	for idx > 1 {
+		idx >>= 1
+		bits++
+	}
+
+	return bits
+}
+
+// indexOctet returns the octet holding the prefix with the allotment index, its host bits cleared.
+func indexOctet(idx uint8) uint8 {
+	bits := indexBits(idx)
+
+	return uint8((int(idx) - 1<<bits) << (8 - bits)) //nolint:gosec
+}
+
+// stride holds the prefixes whose length ends within the same octet of an address,
+// stored in allotment index order. The values are kept in a slice as dense as the index set.
+type stride[V any] struct {
+	indexes bitset256
+	values  []V
+}
+
+// get returns the value of the prefix with the allotment index.
+func (s *stride[V]) get(idx uint8) (V, bool) {
+	if !s.indexes.test(idx) {
+		var zero V
+
+		return zero, false
+	}
+
+	return s.values[s.indexes.rank(idx)], true
+}
+
+// insert sets the value of the prefix with the allotment index,
+// it returns the old value and true if the prefix was already present.
+func (s *stride[V]) insert(idx uint8, val V) (V, bool) {
+	pos := s.indexes.rank(idx)
+	if s.indexes.test(idx) {
+		old := s.values[pos]
+		s.values[pos] = val
+
+		return old, true
+	}
+
+	var zero V
+
+	s.indexes.set(idx)
+	s.values = append(s.values, zero)
+	copy(s.values[pos+1:], s.values[pos:])
+	s.values[pos] = val
+
+	return zero, false
+}
+
+// delete removes the prefix with the allotment index, it returns its value and true if it was present.
+func (s *stride[V]) delete(idx uint8) (V, bool) {
+	var zero V
+
+	if !s.indexes.test(idx) {
+		return zero, false
+	}
+
+	pos := s.indexes.rank(idx)
+	old := s.values[pos]
+
+	copy(s.values[pos:], s.values[pos+1:])
+	s.values[len(s.values)-1] = zero
+	s.values = s.values[:len(s.values)-1]
+	s.indexes.clear(idx)
+
+	return old, true
+}
+
+// longest returns the index of the longest prefix of the stride holding the octet.
+// Only the prefixes of at most maxBits bits are considered.
+func (s *stride[V]) longest(octet uint8, maxBits int) (uint8, bool) {
+	for bits := maxBits; bits >= 0; bits-- {
+		if idx := allotmentIndex(octet, bits); s.indexes.test(idx) {
+			return idx, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,64 @@
+package cidr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllotmentIndex(t *testing.T) {
+	t.Parallel()
+
+	seen := make(map[uint8]bool)
+
+	for bits := 0; bits <= maxStrideBits; bits++ {
+		for octet := 0; octet < 256; octet += 1 << (8 - bits) {
+			idx := allotmentIndex(uint8(octet), bits)
+			assert.Equal(t, bits, indexBits(idx))
+			assert.Equal(t, uint8(octet), indexOctet(idx))
+
+			seen[idx] = true
+		}
+	}
+
+	assert.Len(t, seen, 255)
+	assert.Equal(t, uint8(1), allotmentIndex(0xff, 0))
+	assert.Equal(t, uint8(3), allotmentIndex(0x80, 1))
+	assert.Equal(t, uint8(255), allotmentIndex(0xff, 7))
+}
+
+func TestStride(t *testing.T) {
+	t.Parallel()
+
+	var s stride[int]
+
+	for _, idx := range []uint8{200, 1, 64, 3, 255} {
+		_, updated := s.insert(idx, int(idx))
+		assert.False(t, updated)
+	}
+
+	old, updated := s.insert(64, 640)
+	assert.True(t, updated)
+	assert.Equal(t, 64, old)
+	assert.Equal(t, []int{1, 3, 640, 200, 255}, s.values)
+
+	idx, ok := s.longest(0b1001_0000, maxStrideBits)
+	assert.True(t, ok)
+	assert.Equal(t, uint8(200), idx)
+
+	idx, ok = s.longest(0b0100_0000, maxStrideBits)
+	assert.True(t, ok)
+	assert.Equal(t, uint8(1), idx)
+
+	val, deleted := s.delete(200)
+	assert.True(t, deleted)
+	assert.Equal(t, 200, val)
+	assert.Equal(t, []int{1, 3, 640, 255}, s.values)
+
+	idx, ok = s.longest(0b1001_0000, maxStrideBits)
+	assert.True(t, ok)
+	assert.Equal(t, uint8(3), idx)
+
+	_, deleted = s.delete(200)
+	assert.False(t, deleted)
+}
@@ -0,0 +1,344 @@
+// Package cidr implements an IP routing table on top of the adaptive radix tree.
+//
+// The tree is byte-granular while the IP prefixes are bit-granular, so the table
+// stores one tree key per octet path, the address family followed by the whole octets
+// of the prefixes. Its value is a stride holding the prefixes whose length ends within
+// the next octet, indexed as in Knuth's allotment routing tables.
+// A lookup searches at most one key per octet of the address.
+//
+// Usage:
+//
+//	table := cidr.New[string]()
+//	table.Insert(netip.MustParsePrefix("10.0.0.0/8"), "private")
+//	table.Insert(netip.MustParsePrefix("10.1.0.0/16"), "office")
+//
+//	pfx, val, ok := table.Lookup(netip.MustParseAddr("10.1.2.3")) // 10.1.0.0/16, "office", true
+package cidr
+
+import (
+	"bytes"
+	"net/netip"
+
+	art "github.com/alexisvisco/go-adaptive-radix-tree/v2"
+)
+
+// Address family markers starting the tree keys.
+const (
+	familyIPv4 = 4
+	familyIPv6 = 6
+)
+
+// Table is a routing table mapping IPv4 and IPv6 prefixes to values.
+// The IPv4-mapped IPv6 addresses and prefixes belong to the IPv6 family.
+// The zone of the addresses is ignored. The table is not safe for concurrent use.
+type Table[V any] struct {
+	tree art.Tree // tree maps the octet paths to their strides
+	size int      // size is the number of prefixes
+}
+
+// New creates an empty routing table.
+func New[V any]() *Table[V] {
+	return &Table[V]{tree: art.New()}
+}
+
+// strideKey returns the tree key of the octet path made of the first octets of the address.
+func strideKey(addr netip.Addr, octets int) art.Key {
+	family := byte(familyIPv6)
+	if addr.Is4() {
+		family = familyIPv4
+	}
+
+	b := addr.AsSlice()
+
+	return append(art.Key{family}, b[:octets]...)
+}
+
+// locate returns the tree key of the stride holding the prefix and the prefix allotment index.
+func locate(pfx netip.Prefix) (art.Key, uint8) {
+	addr, bits := pfx.Addr(), pfx.Bits()
+	octets := bits / 8
+
+	var octet uint8
+	if octets < addr.BitLen()/8 {
+		octet = addr.AsSlice()[octets]
+	}
+
+	return strideKey(addr, octets), allotmentIndex(octet, bits%8)
+}
+
+// normalize returns the prefix with its host bits and its zone cleared, and false if it is invalid.
+func normalize(pfx netip.Prefix) (netip.Prefix, bool) {
+	if !pfx.IsValid() {
+		return netip.Prefix{}, false
+	}
+
+	return netip.PrefixFrom(pfx.Addr().WithZone(""), pfx.Bits()).Masked(), true
+}
+
+// stride returns the stride stored under the key.
+func (t *Table[V]) stride(key art.Key) *stride[V] {
+	if v, ok := t.tree.Search(key); ok {
+		return v.(*stride[V]) //nolint:forcetypeassert
+	}
+
+	return nil
+}
+
+// Size returns the number of prefixes in the table.
+func (t *Table[V]) Size() int {
+	return t.size
+}
+
+// Insert adds the prefix with the value, the host bits of the prefix are ignored.
+// If the prefix already exists, it updates its value and returns the old value along with true.
+// Invalid prefixes are ignored.
+func (t *Table[V]) Insert(pfx netip.Prefix, val V) (V, bool) {
+	var zero V
+
+	pfx, ok := normalize(pfx)
+	if !ok {
+		return zero, false
+	}
+
+	key, idx := locate(pfx)
+
+	s := t.stride(key)
+	if s == nil {
+		s = &stride[V]{}
+		t.tree.Insert(key, s)
+	}
+
+	old, updated := s.insert(idx, val)
+	if !updated {
+		t.size++
+	}
+
+	return old, updated
+}
+
+// Delete removes the prefix, the host bits of the prefix are ignored.
+// If the prefix is found and deleted, it returns the removed value and true.
+func (t *Table[V]) Delete(pfx netip.Prefix) (V, bool) {
+	var zero V
+
+	pfx, ok := normalize(pfx)
+	if !ok {
+		return zero, false
+	}
+
+	key, idx := locate(pfx)
+
+	s := t.stride(key)
+	if s == nil {
+		return zero, false
+	}
+
+	old, deleted := s.delete(idx)
+	if !deleted {
+		return zero, false
+	}
+
+	t.size--
+
+	if s.indexes.isEmpty() {
+		t.tree.Delete(key)
+	}
+
+	return old, true
+}
+
+// Get returns the value of the prefix, the host bits of the prefix are ignored.
+func (t *Table[V]) Get(pfx netip.Prefix) (V, bool) {
+	var zero V
+
+	pfx, ok := normalize(pfx)
+	if !ok {
+		return zero, false
+	}
+
+	key, idx := locate(pfx)
+	if s := t.stride(key); s != nil {
+		return s.get(idx)
+	}
+
+	return zero, false
+}
+
+// Lookup returns the longest prefix holding the address along with its value.
+// It returns false if no prefix holds the address.
+func (t *Table[V]) Lookup(addr netip.Addr) (netip.Prefix, V, bool) {
+	var zero V
+
+	if !addr.IsValid() {
+		return netip.Prefix{}, zero, false
+	}
+
+	addr = addr.WithZone("")
+	b := addr.AsSlice()
+
+	for octets := len(b); octets >= 0; octets-- {
+		s := t.stride(strideKey(addr, octets))
+		if s == nil {
+			continue
+		}
+
+		// the prefixes of the whole address length are stored as the default route of the last stride
+		octet, maxBits := uint8(0), 0
+		if octets < len(b) {
+			octet, maxBits = b[octets], maxStrideBits
+		}
+
+		if idx, ok := s.longest(octet, maxBits); ok {
+			pfx := netip.PrefixFrom(addr, octets*8+indexBits(idx)).Masked()
+			val, _ := s.get(idx)
+
+			return pfx, val, true
+		}
+	}
+
+	return netip.Prefix{}, zero, false
+}
+
+// Covering invokes the callback for every prefix of the table holding the prefix, the prefix itself included,
+// from the shortest to the longest. The host bits of the prefix are ignored.
+// The iteration stops if the callback function returns false.
+func (t *Table[V]) Covering(pfx netip.Prefix, cb func(netip.Prefix, V) bool) {
+	pfx, ok := normalize(pfx)
+	if !ok {
+		return
+	}
+
+	addr, bits := pfx.Addr(), pfx.Bits()
+	b := addr.AsSlice()
+
+	for octets := 0; octets*8 <= bits; octets++ {
+		s := t.stride(strideKey(addr, octets))
+		if s == nil {
+			continue
+		}
+
+		var octet uint8
+		if octets < len(b) {
+			octet = b[octets]
+		}
+
+		for n := 0; n <= maxStrideBits && octets*8+n <= bits; n++ {
+			idx := allotmentIndex(octet, n)
+			if val, ok := s.get(idx); ok && !cb(netip.PrefixFrom(addr, octets*8+n).Masked(), val) {
+				return
+			}
+		}
+	}
+}
+
+// CoveredBy invokes the callback for every prefix of the table held by the prefix, the prefix itself included,
+// in ascending order of their addresses, the shorter prefixes first. The host bits of the prefix are ignored.
+// The iteration stops if the callback function returns false.
+func (t *Table[V]) CoveredBy(pfx netip.Prefix, cb func(netip.Prefix, V) bool) {
+	pfx, ok := normalize(pfx)
+	if !ok {
+		return
+	}
+
+	key, idx := locate(pfx)
+
+	w := &coveredWalker[V]{cb: cb}
+
+	t.tree.ForEachPrefix(key, func(node art.NodeKV) bool {
+		w.strides = append(w.strides, strideEntry[V]{key: node.Key(), stride: node.Value().(*stride[V])})
+
+		return true
+	})
+
+	root := strideEntry[V]{key: key, stride: &stride[V]{}}
+	if len(w.strides) > 0 && bytes.Equal(w.strides[0].key, key) {
+		root = w.strides[0]
+		w.next++
+	}
+
+	if pfx.Bits() == pfx.Addr().BitLen() {
+		// a prefix of the whole address length holds no other prefix
+		if val, ok := root.stride.get(idx); ok {
+			cb(pfx, val)
+		}
+
+		return
+	}
+
+	w.visit(root, idx)
+}
+
+// strideEntry is a stride along with its tree key.
+type strideEntry[V any] struct {
+	key    art.Key
+	stride *stride[V]
+}
+
+// coveredWalker reports the prefixes held by a prefix in address order.
+// The strides are visited in tree key order while walking the allotment tree of their parent stride,
+// so that the prefixes of a deeper stride are reported between the ones of the octets around it.
+type coveredWalker[V any] struct {
+	strides []strideEntry[V]           // strides are the strides below the prefix in tree key order
+	next    int                        // next is the index of the next stride to visit
+	cb      func(netip.Prefix, V) bool // cb receives the prefixes
+	stopped bool                       // stopped indicates the callback returned false
+}
+
+// visit reports the prefixes of the allotment subtree rooted at the index
+// along with the deeper strides of the octets it holds.
+func (w *coveredWalker[V]) visit(s strideEntry[V], idx uint8) {
+	if w.stopped {
+		return
+	}
+
+	if val, ok := s.stride.get(idx); ok && !w.cb(prefixFrom(s.key, idx), val) {
+		w.stopped = true
+
+		return
+	}
+
+	if indexBits(idx) < maxStrideBits {
+		w.visit(s, idx<<1)
+		w.visit(s, idx<<1|1)
+
+		return
+	}
+
+	for octet := int(indexOctet(idx)); octet <= int(indexOctet(idx))+1 && !w.stopped; octet++ {
+		w.visitOctet(s.key, uint8(octet)) //nolint:gosec
+	}
+}
+
+// visitOctet visits the strides whose key extends the key of the parent stride with the octet.
+func (w *coveredWalker[V]) visitOctet(parent art.Key, octet uint8) {
+	child := append(parent[:len(parent):len(parent)], octet)
+
+	// skip the strides of the octets out of the visited prefix
+	for w.next < len(w.strides) && bytes.Compare(w.strides[w.next].key, child) < 0 {
+		w.next++
+	}
+
+	for w.next < len(w.strides) && bytes.HasPrefix(w.strides[w.next].key, child) && !w.stopped {
+		s := w.strides[w.next]
+		w.next++
+
+		w.visit(s, 1)
+	}
+}
+
+// prefixFrom returns the prefix with the allotment index in the stride stored under the key.
+func prefixFrom(key art.Key, idx uint8) netip.Prefix {
+	var b [16]byte
+
+	octets := copy(b[:], key[1:])
+	if octets < len(b) {
+		b[octets] = indexOctet(idx)
+	}
+
+	addr := netip.AddrFrom16(b)
+	if key[0] == familyIPv4 {
+		addr = netip.AddrFrom4([4]byte{b[0], b[1], b[2], b[3]})
+	}
+
+	return netip.PrefixFrom(addr, octets*8+indexBits(idx))
+}
@@ -0,0 +1,279 @@
+package cidr
+
+import (
+	"math/rand"
+	"net/netip"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustPrefixes(t *testing.T, s ...string) []netip.Prefix {
+	t.Helper()
+
+	prefixes := make([]netip.Prefix, 0, len(s))
+	for _, p := range s {
+		prefixes = append(prefixes, netip.MustParsePrefix(p))
+	}
+
+	return prefixes
+}
+
+func newTestTable(t *testing.T, s ...string) *Table[string] {
+	t.Helper()
+
+	table := New[string]()
+	for _, pfx := range mustPrefixes(t, s...) {
+		table.Insert(pfx, pfx.String())
+	}
+
+	return table
+}
+
+func TestTableLookup(t *testing.T) {
+	t.Parallel()
+
+	table := newTestTable(t,
+		"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/23", "10.1.2.3/32", "192.168.0.0/17",
+		"::/0", "2001:db8::/32", "2001:db8:1::/48", "2001:db8:1::1/128",
+	)
+
+	tests := []struct {
+		addr     string
+		expected string
+	}{
+		{"10.1.2.3", "10.1.2.3/32"},
+		{"10.1.2.4", "10.1.2.0/23"},
+		{"10.1.3.255", "10.1.2.0/23"},
+		{"10.1.4.0", "10.1.0.0/16"},
+		{"10.2.0.0", "10.0.0.0/8"},
+		{"192.168.127.1", "192.168.0.0/17"},
+		{"192.168.128.1", "0.0.0.0/0"},
+		{"2001:db8:1::1", "2001:db8:1::1/128"},
+		{"2001:db8:1::2", "2001:db8:1::/48"},
+		{"2001:db8:2::", "2001:db8::/32"},
+		{"fe80::1%eth0", "::/0"},
+		{"::ffff:10.1.2.3", "::/0"},
+	}
+
+	for _, tt := range tests {
+		pfx, val, ok := table.Lookup(netip.MustParseAddr(tt.addr))
+		require.True(t, ok, tt.addr)
+		assert.Equal(t, tt.expected, pfx.String(), tt.addr)
+		assert.Equal(t, tt.expected, val, tt.addr)
+	}
+
+	_, _, ok := New[int]().Lookup(netip.MustParseAddr("10.0.0.1"))
+	assert.False(t, ok)
+
+	_, _, ok = table.Lookup(netip.Addr{})
+	assert.False(t, ok)
+}
+
+func TestTableInsertDelete(t *testing.T) {
+	t.Parallel()
+
+	table := New[int]()
+	pfx := netip.MustParsePrefix("10.1.2.3/23")
+
+	old, updated := table.Insert(pfx, 1)
+	assert.False(t, updated)
+	assert.Zero(t, old)
+
+	old, updated = table.Insert(netip.MustParsePrefix("10.1.2.0/23"), 2)
+	assert.True(t, updated)
+	assert.Equal(t, 1, old)
+	assert.Equal(t, 1, table.Size())
+
+	val, ok := table.Get(netip.MustParsePrefix("10.1.3.0/23"))
+	assert.True(t, ok)
+	assert.Equal(t, 2, val)
+
+	_, ok = table.Get(netip.MustParsePrefix("10.1.2.0/24"))
+	assert.False(t, ok)
+
+	_, deleted := table.Delete(netip.MustParsePrefix("10.1.2.0/24"))
+	assert.False(t, deleted)
+
+	val, deleted = table.Delete(pfx)
+	assert.True(t, deleted)
+	assert.Equal(t, 2, val)
+	assert.Equal(t, 0, table.Size())
+	assert.Equal(t, 0, table.tree.Size())
+
+	_, updated = table.Insert(netip.Prefix{}, 3)
+	assert.False(t, updated)
+	assert.Equal(t, 0, table.Size())
+}
+
+func TestTableCovering(t *testing.T) {
+	t.Parallel()
+
+	table := newTestTable(t,
+		"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/23", "10.1.2.3/32", "10.2.0.0/16", "::/0",
+	)
+
+	var covering []string
+
+	table.Covering(netip.MustParsePrefix("10.1.2.3/32"), func(pfx netip.Prefix, val string) bool {
+		assert.Equal(t, pfx.String(), val)
+		covering = append(covering, val)
+
+		return true
+	})
+	assert.Equal(t, []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/23", "10.1.2.3/32"}, covering)
+
+	covering = nil
+
+	table.Covering(netip.MustParsePrefix("10.1.0.0/17"), func(pfx netip.Prefix, _ string) bool {
+		covering = append(covering, pfx.String())
+
+		return len(covering) < 2
+	})
+	assert.Equal(t, []string{"0.0.0.0/0", "10.0.0.0/8"}, covering)
+}
+
+func TestTableCoveredBy(t *testing.T) {
+	t.Parallel()
+
+	table := newTestTable(t,
+		"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/23", "10.1.2.3/32", "10.1.2.128/25", "10.1.128.0/17",
+		"10.2.0.0/16", "11.0.0.0/8", "::/0", "2001:db8::/32",
+	)
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{"10.1.0.0/16", []string{"10.1.0.0/16", "10.1.2.0/23", "10.1.2.3/32", "10.1.2.128/25", "10.1.128.0/17"}},
+		{"10.0.0.0/8", []string{
+			"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/23", "10.1.2.3/32", "10.1.2.128/25", "10.1.128.0/17", "10.2.0.0/16",
+		}},
+		{"10.1.2.0/24", []string{"10.1.2.3/32", "10.1.2.128/25"}},
+		{"10.1.2.3/32", []string{"10.1.2.3/32"}},
+		{"10.1.2.4/32", nil},
+		{"12.0.0.0/8", nil},
+		{"2000::/3", []string{"2001:db8::/32"}},
+	}
+
+	for _, tt := range tests {
+		var covered []string
+
+		table.CoveredBy(netip.MustParsePrefix(tt.prefix), func(pfx netip.Prefix, val string) bool {
+			assert.Equal(t, pfx.String(), val)
+			covered = append(covered, val)
+
+			return true
+		})
+
+		assert.Equal(t, tt.expected, covered, tt.prefix)
+	}
+}
+
+// randomPrefix returns a random prefix biased towards a few shared leading octets.
+func randomPrefix(rnd *rand.Rand, ipv6 bool) netip.Prefix {
+	var b [16]byte
+
+	n := 4
+	if ipv6 {
+		n = 16
+	}
+
+	for i := 0; i < n; i++ {
+		b[i] = byte(rnd.Intn(4))
+		if i >= 2 {
+			b[i] = byte(rnd.Intn(256))
+		}
+	}
+
+	addr := netip.AddrFrom16(b)
+	if !ipv6 {
+		addr = netip.AddrFrom4([4]byte{b[0], b[1], b[2], b[3]})
+	}
+
+	return netip.PrefixFrom(addr, rnd.Intn(n*8+1)).Masked()
+}
+
+func TestTableRandom(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(42)) //nolint:gosec
+	table := New[netip.Prefix]()
+	reference := make(map[netip.Prefix]bool)
+
+	for i := 0; i < 4000; i++ {
+		pfx := randomPrefix(rnd, i%2 == 0)
+		if i%5 == 0 {
+			table.Delete(pfx)
+			delete(reference, pfx)
+
+			continue
+		}
+
+		table.Insert(pfx, pfx)
+		reference[pfx] = true
+	}
+
+	require.Equal(t, len(reference), table.Size())
+
+	for i := 0; i < 1000; i++ {
+		query := randomPrefix(rnd, i%2 == 0)
+		addr := query.Addr()
+
+		var (
+			longest             netip.Prefix
+			covering, coveredBy []netip.Prefix
+		)
+
+		for pfx := range reference {
+			if pfx.Addr().Is4() != addr.Is4() {
+				continue
+			}
+
+			if pfx.Contains(addr) && (!longest.IsValid() || pfx.Bits() > longest.Bits()) {
+				longest = pfx
+			}
+
+			if pfx.Bits() <= query.Bits() && pfx.Contains(addr) {
+				covering = append(covering, pfx)
+			}
+
+			if pfx.Bits() >= query.Bits() && query.Contains(pfx.Addr()) {
+				coveredBy = append(coveredBy, pfx)
+			}
+		}
+
+		pfx, val, ok := table.Lookup(addr)
+		assert.Equal(t, longest.IsValid(), ok, addr)
+		assert.Equal(t, longest, pfx, addr)
+		assert.Equal(t, longest, val, addr)
+
+		sort.Slice(covering, func(i, j int) bool { return covering[i].Bits() < covering[j].Bits() })
+		sort.Slice(coveredBy, func(i, j int) bool {
+			if c := coveredBy[i].Addr().Compare(coveredBy[j].Addr()); c != 0 {
+				return c < 0
+			}
+
+			return coveredBy[i].Bits() < coveredBy[j].Bits()
+		})
+
+		var actualCovering, actualCoveredBy []netip.Prefix
+
+		table.Covering(query, func(pfx netip.Prefix, _ netip.Prefix) bool {
+			actualCovering = append(actualCovering, pfx)
+
+			return true
+		})
+
+		table.CoveredBy(query, func(pfx netip.Prefix, _ netip.Prefix) bool {
+			actualCoveredBy = append(actualCoveredBy, pfx)
+
+			return true
+		})
+
+		assert.Equal(t, covering, actualCovering, query)
+		assert.Equal(t, coveredBy, actualCoveredBy, query)
+	}
+}
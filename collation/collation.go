@@ -0,0 +1,65 @@
+// Package collation implements key transformers normalizing the Unicode keys of a tree,
+// to be passed to art.WithKeyTransformer:
+//
+//	tree := art.New(art.WithKeyTransformer(collation.Simple))
+//	tree.Insert(art.Key("Résumé"), value)
+//	value, found := tree.Search(art.Key("resume"))
+//
+// The composed normalization forms, NFC and NFKC, are not provided since they don't preserve the prefixes
+// the tree relies on: the NFC form of "e" followed by U+0301 is "é", so the sort key of "e" is not a prefix of it.
+// The decomposed forms only reorder the combining marks following a rune, see NFD for the consequence.
+package collation
+
+import (
+	art "github.com/alexisvisco/go-adaptive-radix-tree/v2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// assert that the transformers implement the art.KeyTransformer type.
+var (
+	_ art.KeyTransformer = NFD
+	_ art.KeyTransformer = NFKD
+	_ art.KeyTransformer = Simple
+)
+
+// NFD maps the keys to their canonical decomposition, the canonically equivalent keys have the same sort key.
+// The canonical ordering of the combining marks may move a mark of an extension before the trailing marks
+// of the key, so a prefix ending with combining marks may miss the keys adding marks of a lower combining class.
+// The prefixes ending with a rune without combining class, such as a letter, a digit or a separator, are safe.
+func NFD(key []byte) []byte {
+	return norm.NFD.Bytes(key)
+}
+
+// NFKD maps the keys to their compatibility decomposition, the compatibility equivalent keys,
+// such as "ﬁ" and "fi", have the same sort key. It has the same prefix caveat as NFD.
+func NFKD(key []byte) []byte {
+	return norm.NFKD.Bytes(key)
+}
+
+// Simple is a primary strength collation, like the one of ICU: the keys differing only by their case,
+// their diacritics or their compatibility variants have the same sort key.
+// The keys are decomposed with NFKD, the combining marks with a combining class, such as the accents,
+// are removed and the case is folded with art.FoldCase.
+// Removing the reordered marks makes it preserve the prefixes without the caveat of NFD.
+// The keys are ordered by their base letters, but not following the alphabetical order of a language.
+func Simple(key []byte) []byte {
+	decomposed := norm.NFKD.Bytes(key)
+	stripped := make([]byte, 0, len(decomposed))
+
+	for i := 0; i < len(decomposed); {
+		props := norm.NFKD.Properties(decomposed[i:])
+
+		size := props.Size()
+		if size == 0 {
+			size = 1 // invalid UTF-8 byte, kept as is
+		}
+
+		if props.CCC() == 0 {
+			stripped = append(stripped, decomposed[i:i+size]...)
+		}
+
+		i += size
+	}
+
+	return art.FoldCase(stripped)
+}
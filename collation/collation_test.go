@@ -0,0 +1,84 @@
+package collation
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	art "github.com/alexisvisco/go-adaptive-radix-tree/v2"
+)
+
+func TestTransformers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		transform art.KeyTransformer
+		key       string
+		want      string
+	}{
+		{"NFD", NFD, "é", "e\u0301"},
+		{"NFD", NFD, "e\u0301", "e\u0301"},
+		{"NFD", NFD, "ﬁ", "ﬁ"},
+		{"NFKD", NFKD, "ﬁ", "fi"},
+		{"NFKD", NFKD, "Å", "A\u030a"},
+		{"Simple", Simple, "Résumé", "resume"},
+		{"Simple", Simple, "RE\u0301SUME\u0301", "resume"},
+		{"Simple", Simple, "ﬁancé", "fiance"},
+		{"Simple", Simple, "İstanbul", "istanbul"},
+		{"Simple", Simple, "Straße", "straße"},
+		{"Simple", Simple, "a\xffb", "a\xffb"},
+		{"Simple", Simple, "", ""},
+	}
+
+	for _, tt := range tests {
+		got := tt.transform([]byte(tt.key))
+		assert.Equal(t, tt.want, string(got), "%s(%q)", tt.name, tt.key)
+		assert.Equal(t, got, tt.transform(got), "%s is idempotent for %q", tt.name, tt.key)
+	}
+}
+
+func TestSimplePreservesPrefixes(t *testing.T) {
+	t.Parallel()
+
+	runes := []rune{'a', 'E', 'é', 'ﬁ', 'İ', '/', '\u0301', '\u0323', '\u0308', 'Å', 'ß'}
+	rnd := rand.New(rand.NewSource(1)) //nolint:gosec
+
+	for round := 0; round < 10000; round++ {
+		key := make([]rune, rnd.Intn(8))
+		for i := range key {
+			key[i] = runes[rnd.Intn(len(runes))]
+		}
+
+		full := []byte(string(key))
+		cut := len(string(key[:rnd.Intn(len(key)+1)]))
+
+		require.True(t, bytes.HasPrefix(Simple(full), Simple(full[:cut])), "%q", full)
+	}
+}
+
+func TestSimpleTree(t *testing.T) {
+	t.Parallel()
+
+	tree := art.New(art.WithKeyTransformer(Simple))
+	tree.Insert(art.Key("Résumé"), 1)
+	tree.Insert(art.Key("resumes"), 2)
+	tree.Insert(art.Key("rest"), 3)
+
+	val, found := tree.Search(art.Key("RESUME"))
+	assert.True(t, found)
+	assert.Equal(t, 1, val)
+
+	var keys []string
+
+	tree.ForEachPrefix(art.Key("résu"), func(node art.NodeKV) bool {
+		keys = append(keys, string(node.Key()))
+
+		return true
+	})
+
+	assert.Equal(t, []string{"Résumé", "resumes"}, keys)
+}
@@ -0,0 +1,252 @@
+package art
+
+const (
+	crefKindBits = 3                        // crefKindBits is the number of bits storing the Node kind.
+	crefKindMask = 1<<crefKindBits - 1      // crefKindMask extracts the Node kind from a reference.
+	crefMaxIndex = 1<<(32-crefKindBits) - 1 // crefMaxIndex is the maximum index of a Node in its pool.
+)
+
+// cref is a reference to a compact Node.
+// The low bits store the Node kind plus one and the high bits the index of the Node in its pool,
+// so the zero value is the nil reference.
+type cref uint32
+
+// newCref creates a reference to the Node of the given kind stored at the index.
+func newCref(kind Kind, idx uint32) cref {
+	if idx > crefMaxIndex {
+		panic("art: too many nodes for the compact tree")
+	}
+
+	return cref(idx<<crefKindBits | uint32(kind+1)) //nolint:gosec
+}
+
+// kind returns the kind of the referenced Node.
+func (r cref) kind() Kind {
+	return Kind(r&crefKindMask) - 1
+}
+
+// index returns the index of the referenced Node in its pool.
+func (r cref) index() uint32 {
+	return uint32(r >> crefKindBits)
+}
+
+// isLeaf returns true if the reference points to a Leaf.
+func (r cref) isLeaf() bool {
+	return r.kind() == LeafKind
+}
+
+// compactLeaf locates the key of a Leaf in the key pages,
+// the value is stored in the value pages at the index of the Leaf.
+type compactLeaf struct {
+	keyPage uint32
+	keyOff  uint32
+	keyLen  uint32
+	keyCap  uint32 // keyCap is the space reserved for the key, it is reused by the next Leaf
+}
+
+// compactHeader is the part shared by all compact inner nodes.
+type compactHeader struct {
+	prefixLen   uint16
+	childrenLen uint16
+	zeroChild   cref
+	prefix      [maxPrefixLen]byte
+}
+
+// storedPrefix returns the prefix bytes stored in the Node.
+func (h *compactHeader) storedPrefix() []byte {
+	return h.prefix[:minInt(int(h.prefixLen), maxPrefixLen)]
+}
+
+// setPrefix sets the prefix length and stores its first bytes.
+func (h *compactHeader) setPrefix(src []byte, prefixLen int) {
+	h.prefixLen = uint16(prefixLen) //nolint:gosec
+	copy(h.prefix[:], src[:minInt(prefixLen, maxPrefixLen)])
+}
+
+// Compact nodes store the keys of the children in ascending order,
+// except compactNode256 which indexes the children by key.
+type (
+	compactNode4 struct {
+		compactHeader
+		keys     [node4Max]byte
+		children [node4Max]cref
+	}
+
+	compactNode16 struct {
+		compactHeader
+		keys     [node16Max]byte
+		children [node16Max]cref
+	}
+
+	compactNode48 struct {
+		compactHeader
+		keys     [node48Max]byte
+		children [node48Max]cref
+	}
+
+	compactNode256 struct {
+		compactHeader
+		children [node256Max]cref
+	}
+)
+
+// compactView gives the same access to all kinds of compact inner nodes.
+type compactView struct {
+	hdr      *compactHeader
+	keys     []byte // keys are the sorted keys of the children, nil for Node256
+	children []cref // children are indexed by key for Node256
+}
+
+// numChildren returns the number of children including the zero byte child.
+func (v compactView) numChildren() int {
+	return int(v.hdr.childrenLen) + ternary(v.hdr.zeroChild != 0, 1, 0)
+}
+
+// isFull returns true if there is no room for another child.
+func (v compactView) isFull() bool {
+	return v.keys != nil && int(v.hdr.childrenLen) == len(v.keys)
+}
+
+// find returns the child slot for the key character or nil if there is no such child.
+func (v compactView) find(kc keyChar) *cref {
+	if kc.invalid {
+		return ternary(v.hdr.zeroChild != 0, &v.hdr.zeroChild, nil)
+	}
+
+	if v.keys == nil {
+		return ternary(v.children[kc.ch] != 0, &v.children[kc.ch], nil)
+	}
+
+	for i := 0; i < int(v.hdr.childrenLen) && v.keys[i] <= kc.ch; i++ {
+		if v.keys[i] == kc.ch {
+			return &v.children[i]
+		}
+	}
+
+	return nil
+}
+
+// add adds the child for the key character, the Node must not be full.
+func (v compactView) add(kc keyChar, child cref) {
+	if kc.invalid {
+		v.hdr.zeroChild = child
+
+		return
+	}
+
+	numChildren := int(v.hdr.childrenLen)
+	v.hdr.childrenLen++
+
+	if v.keys == nil {
+		v.children[kc.ch] = child
+
+		return
+	}
+
+	pos := 0
+	for pos < numChildren && v.keys[pos] < kc.ch {
+		pos++
+	}
+
+	copy(v.keys[pos+1:numChildren+1], v.keys[pos:numChildren])
+	copy(v.children[pos+1:numChildren+1], v.children[pos:numChildren])
+	v.keys[pos] = kc.ch
+	v.children[pos] = child
+}
+
+// remove removes the child for the key character.
+func (v compactView) remove(kc keyChar) {
+	if kc.invalid {
+		v.hdr.zeroChild = 0
+
+		return
+	}
+
+	numChildren := int(v.hdr.childrenLen)
+	v.hdr.childrenLen--
+
+	if v.keys == nil {
+		v.children[kc.ch] = 0
+
+		return
+	}
+
+	pos := 0
+	for v.keys[pos] != kc.ch {
+		pos++
+	}
+
+	copy(v.keys[pos:], v.keys[pos+1:numChildren])
+	copy(v.children[pos:], v.children[pos+1:numChildren])
+	v.keys[numChildren-1] = 0
+	v.children[numChildren-1] = 0
+}
+
+// first returns the child with the smallest key character, ignoring the zero byte child.
+func (v compactView) first() cref {
+	if v.keys != nil {
+		return v.children[0]
+	}
+
+	for _, child := range v.children {
+		if child != 0 {
+			return child
+		}
+	}
+
+	return 0
+}
+
+// last returns the child with the greatest key character,
+// it is the zero byte child if the Node has no other child.
+func (v compactView) last() cref {
+	if v.hdr.childrenLen == 0 {
+		return v.hdr.zeroChild
+	}
+
+	if v.keys != nil {
+		return v.children[v.hdr.childrenLen-1]
+	}
+
+	for i := node256Max - 1; i >= 0; i-- {
+		if v.children[i] != 0 {
+			return v.children[i]
+		}
+	}
+
+	return 0
+}
+
+// each calls fn for the children in ascending order, the zero byte child comes first.
+// In reverse order, the zero byte child comes last.
+// It returns false as soon as fn returns false.
+func (v compactView) each(reverse bool, fn func(kc keyChar, child cref) bool) bool {
+	if !reverse && v.hdr.zeroChild != 0 && !fn(keyCharInvalid, v.hdr.zeroChild) {
+		return false
+	}
+
+	numSlots := ternary(v.keys == nil, node256Max, int(v.hdr.childrenLen))
+	for i := 0; i < numSlots; i++ {
+		idx := ternary(reverse, numSlots-1-i, i)
+
+		child := v.children[idx]
+		if child == 0 {
+			continue
+		}
+
+		ch := byte(idx)
+		if v.keys != nil {
+			ch = v.keys[idx]
+		}
+
+		if !fn(keyChar{ch: ch}, child) {
+			return false
+		}
+	}
+
+	if reverse && v.hdr.zeroChild != 0 {
+		return fn(keyCharInvalid, v.hdr.zeroChild)
+	}
+
+	return true
+}
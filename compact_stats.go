@@ -0,0 +1,51 @@
+package art
+
+import "unsafe"
+
+// Stats returns the statistics of the compact tree collected by walking all its nodes.
+func (ct *compactTree) Stats() TreeStats {
+	var c statsCollector
+	ct.collectStats(&c, ct.root, 0)
+
+	return c.result()
+}
+
+// collectStats walks the subtree located below depth inner nodes.
+func (ct *compactTree) collectStats(c *statsCollector, r cref, depth int) {
+	if r == 0 {
+		return
+	}
+
+	if r.isLeaf() {
+		leaf := ct.leaves.at(r.index())
+		size := int(unsafe.Sizeof(compactLeaf{})+unsafe.Sizeof(Value(nil))) + int(leaf.keyCap)
+		c.addLeaf(size, int(leaf.keyLen), depth)
+
+		return
+	}
+
+	v := ct.view(r)
+	c.addNode(r.kind(), compactNodeSize(r.kind()), int(v.hdr.childrenLen), int(v.hdr.prefixLen))
+
+	v.each(false, func(_ keyChar, child cref) bool {
+		ct.collectStats(c, child, depth+1)
+
+		return true
+	})
+}
+
+// compactNodeSize returns the size of the compact inner Node structure of the given kind.
+func compactNodeSize(kind Kind) int {
+	switch kind { //nolint:exhaustive
+	case Node4Kind:
+		return int(unsafe.Sizeof(compactNode4{}))
+	case Node16Kind:
+		return int(unsafe.Sizeof(compactNode16{}))
+	case Node48Kind:
+		return int(unsafe.Sizeof(compactNode48{}))
+	case Node256Kind:
+		return int(unsafe.Sizeof(compactNode256{}))
+	}
+
+	return 0
+}
@@ -0,0 +1,146 @@
+package art
+
+const (
+	compactPageBits = 8                    // compactPageBits is the number of index bits addressing a slot in a page.
+	compactPageSize = 1 << compactPageBits // compactPageSize is the number of objects in a page.
+	compactPageMask = compactPageSize - 1  // compactPageMask extracts the slot from an object index.
+
+	compactKeyPageSize = 64 << 10 // compactKeyPageSize is the size of a key page in bytes.
+)
+
+// compactPool stores objects in fixed size pages, the objects are addressed by index.
+// Pages don't move once allocated, so pointers to the objects stay valid.
+// Pages of pointer-free objects are not scanned by the garbage collector.
+type compactPool[T any] struct {
+	pages []*[compactPageSize]T
+	size  uint32   // size is the number of used slots
+	free  []uint32 // free contains the released slots
+}
+
+// alloc returns the index of a free slot, the slot keeps the content of the released object.
+func (p *compactPool[T]) alloc() uint32 {
+	if last := len(p.free) - 1; last >= 0 {
+		idx := p.free[last]
+		p.free = p.free[:last]
+
+		return idx
+	}
+
+	if int(p.size>>compactPageBits) == len(p.pages) {
+		p.pages = append(p.pages, new([compactPageSize]T))
+	}
+
+	idx := p.size
+	p.size++
+
+	return idx
+}
+
+// release puts the slot to the free list.
+func (p *compactPool[T]) release(idx uint32) {
+	p.free = append(p.free, idx)
+}
+
+// at returns the object stored at the index.
+func (p *compactPool[T]) at(idx uint32) *T {
+	return &p.pages[idx>>compactPageBits][idx&compactPageMask]
+}
+
+// clone returns a deep copy of the pool.
+func (p *compactPool[T]) clone() compactPool[T] {
+	clone := compactPool[T]{
+		pages: make([]*[compactPageSize]T, len(p.pages)),
+		size:  p.size,
+		free:  append([]uint32(nil), p.free...),
+	}
+
+	for i, page := range p.pages {
+		pageCopy := *page
+		clone.pages[i] = &pageCopy
+	}
+
+	return clone
+}
+
+// compactValues stores the values of the leaves at the index of their Leaf.
+// It is the only part of the compact tree scanned by the garbage collector.
+type compactValues struct {
+	pages []*[compactPageSize]Value
+}
+
+// get returns the value of the Leaf at the index, nil if its page is not allocated.
+func (cv *compactValues) get(idx uint32) Value {
+	if int(idx>>compactPageBits) >= len(cv.pages) {
+		return nil
+	}
+
+	return cv.pages[idx>>compactPageBits][idx&compactPageMask]
+}
+
+// set stores the value of the Leaf at the index.
+// The pages are allocated by the first non-nil value, a tree holding nil values doesn't allocate them.
+func (cv *compactValues) set(idx uint32, value Value) {
+	if int(idx>>compactPageBits) >= len(cv.pages) {
+		if value == nil {
+			return
+		}
+
+		for int(idx>>compactPageBits) >= len(cv.pages) {
+			cv.pages = append(cv.pages, new([compactPageSize]Value))
+		}
+	}
+
+	cv.pages[idx>>compactPageBits][idx&compactPageMask] = value
+}
+
+// clone returns a deep copy of the values.
+func (cv *compactValues) clone() compactValues {
+	clone := compactValues{pages: make([]*[compactPageSize]Value, len(cv.pages))}
+
+	for i, page := range cv.pages {
+		pageCopy := *page
+		clone.pages[i] = &pageCopy
+	}
+
+	return clone
+}
+
+// compactKeys stores the keys of the leaves in shared byte pages.
+// The pages only grow, the bytes of the released leaves are not reclaimed.
+type compactKeys struct {
+	pages [][]byte
+}
+
+// alloc reserves n bytes and returns their page and offset.
+func (ck *compactKeys) alloc(n int) (uint32, uint32) {
+	last := len(ck.pages) - 1
+	if last < 0 || cap(ck.pages[last])-len(ck.pages[last]) < n {
+		ck.pages = append(ck.pages, make([]byte, 0, ternary(n > compactKeyPageSize, n, compactKeyPageSize)))
+		last++
+	}
+
+	off := len(ck.pages[last])
+	ck.pages[last] = ck.pages[last][:off+n]
+
+	return uint32(last), uint32(off) //nolint:gosec
+}
+
+// bytes returns the n bytes stored at the page and offset.
+func (ck *compactKeys) bytes(page, off, n uint32) Key {
+	if n == 0 {
+		return Key{}
+	}
+
+	return ck.pages[page][off : off+n : off+n]
+}
+
+// clone returns a deep copy of the keys.
+func (ck *compactKeys) clone() compactKeys {
+	clone := compactKeys{pages: make([][]byte, len(ck.pages))}
+
+	for i, page := range ck.pages {
+		clone.pages[i] = append(make([]byte, 0, cap(page)), page...)
+	}
+
+	return clone
+}
@@ -0,0 +1,423 @@
+package art
+
+import (
+	"bytes"
+)
+
+// compactNodeKV is a Node reported by the compact tree traversal.
+type compactNodeKV struct {
+	kind  Kind
+	key   Key
+	value Value
+}
+
+// assert that compactNodeKV implements public NodeKV interface.
+var _ NodeKV = (*compactNodeKV)(nil)
+
+func (n *compactNodeKV) Kind() Kind   { return n.kind }  // Kind returns the Node kind.
+func (n *compactNodeKV) Key() Key     { return n.key }   // Key returns the Leaf key, nil for inner nodes.
+func (n *compactNodeKV) Value() Value { return n.value } // Value returns the Leaf value, nil for inner nodes.
+
+// nodeKV returns the public representation of the referenced Node.
+func (ct *compactTree) nodeKV(r cref) NodeKV {
+	if r.isLeaf() {
+		return &compactNodeKV{kind: LeafKind, key: ct.leafKey(r), value: ct.values.get(r.index())}
+	}
+
+	return &compactNodeKV{kind: r.kind()}
+}
+
+// walk visits the nodes of the subtree in pre-order, the children in key order.
+// It returns false as soon as fn returns false.
+func (ct *compactTree) walk(r cref, reverse bool, fn func(r cref) bool) bool {
+	if r == 0 {
+		return true
+	}
+
+	if !fn(r) {
+		return false
+	}
+
+	if r.isLeaf() {
+		return true
+	}
+
+	return ct.view(r).each(reverse, func(_ keyChar, child cref) bool {
+		return ct.walk(child, reverse, fn)
+	})
+}
+
+// ForEach iterates over all keys in the tree and calls the callback function.
+func (ct *compactTree) ForEach(callback Callback, opts ...int) {
+	options := traverseOptions(opts...)
+	callback = traverseFilter(options, callback)
+
+	ct.walk(ct.root, options.hasReverse(), func(r cref) bool {
+		return callback(ct.nodeKV(r))
+	})
+}
+
+// ForEachPrefix iterates over all keys with the given prefix.
+func (ct *compactTree) ForEachPrefix(keyPrefix Key, callback Callback, opts ...int) {
+	if keyPrefix == nil {
+		return
+	}
+
+	r, depth := ct.root, 0
+
+	// descend to the subtree holding the keys with the prefix
+	for r != 0 && !r.isLeaf() && depth < len(keyPrefix) {
+		v := ct.view(r)
+		if prefixLen := int(v.hdr.prefixLen); prefixLen > 0 {
+			if ct.prefixMismatch(r, keyPrefix, depth) < minInt(prefixLen, len(keyPrefix)-depth) {
+				return
+			}
+
+			depth += prefixLen
+			if depth >= len(keyPrefix) {
+				break
+			}
+		}
+
+		next := v.find(keyPrefix.charAt(depth))
+		if next == nil {
+			return
+		}
+
+		r = *next
+		depth++
+	}
+
+	ct.walk(r, traverseOptions(opts...).hasReverse(), func(r cref) bool {
+		if r.isLeaf() && bytes.HasPrefix(ct.leafKey(r), keyPrefix) {
+			return callback(ct.nodeKV(r))
+		}
+
+		return true
+	})
+}
+
+// ForEachPrefixWithSeparator iterates over all keys with the given prefix,
+// skipping the keys with more than maxDepth separators after the prefix (-1 for unlimited).
+func (ct *compactTree) ForEachPrefixWithSeparator(
+	keyPrefix Key,
+	callback Callback,
+	countSeparator func(Key, Key) int,
+	maxDepth int,
+	reverse bool,
+) {
+	if keyPrefix == nil {
+		keyPrefix = Key{}
+	}
+
+	ct.ForEachPrefix(keyPrefix, func(node NodeKV) bool {
+		if maxDepth >= 0 && countSeparator(keyPrefix, node.Key()) > maxDepth {
+			return true
+		}
+
+		return callback(node)
+	}, ternary(reverse, TraverseReverse, TraverseLeaf))
+}
+
+// List returns the keys and the common prefixes one level below the prefix.
+// The keys rolled up into a common prefix are walked but not listed.
+func (ct *compactTree) List(prefix Key, delimiter byte, opts ...ListOption) ListResult {
+	l := newLister(prefix, delimiter, opts...)
+
+	if prefix == nil {
+		prefix = Key{}
+	}
+
+	ct.ForEachPrefix(prefix, l.addLeaf)
+
+	return l.result
+}
+
+// Children enumerates the path segments following the prefix.
+// The keys grouped under a segment are walked to find the next one.
+func (ct *compactTree) Children(prefix Key, sep byte, cb ChildrenCallback, opts ...ChildrenOption) {
+	options := createChildrenOptions(opts...)
+
+	var (
+		segment Key // segment is the pending non-leaf segment
+		count   int // count is the number of keys grouped under the pending segment
+	)
+
+	flush := func() bool {
+		if segment == nil {
+			return true
+		}
+
+		cont := cb(segment, false, ternary(options.count, count, 0))
+		segment, count = nil, 0
+
+		return cont
+	}
+
+	if prefix == nil {
+		prefix = Key{}
+	}
+
+	stopped := false
+
+	ct.ForEachPrefix(prefix, func(node NodeKV) bool {
+		key := node.Key()
+
+		end := segmentEnd(key, prefix, sep)
+		if end < 0 {
+			stopped = !flush() || !cb(key[len(prefix):], true, 1)
+
+			return !stopped
+		}
+
+		if next := key[len(prefix) : len(prefix)+end]; segment == nil || !bytes.Equal(next, segment) {
+			if !flush() {
+				stopped = true
+
+				return false
+			}
+
+			segment = next
+		}
+
+		count++
+
+		return true
+	})
+
+	if !stopped {
+		flush()
+	}
+}
+
+// FuzzySearch invokes the callback for the keys within maxEdits of the query.
+// The edit distance is computed for every key of the tree.
+func (ct *compactTree) FuzzySearch(query Key, maxEdits int, cb Callback, opts ...FuzzyOption) {
+	if maxEdits < 0 {
+		return
+	}
+
+	lev := newLevenshtein(query, maxEdits, createFuzzyOptions(opts...))
+
+	ct.ForEach(func(node NodeKV) bool {
+		if lev.matches(node.Key()) {
+			return cb(node)
+		}
+
+		return true
+	})
+}
+
+// ForEachMatch invokes the callback for the keys accepted by the automaton.
+// The automaton consumes every key of the tree.
+func (ct *compactTree) ForEachMatch(a Automaton, cb Callback) {
+	ct.ForEach(func(node NodeKV) bool {
+		if state, ok := stepAutomaton(a, a.Start(), node.Key()); ok && a.IsMatch(state) {
+			return cb(node)
+		}
+
+		return true
+	})
+}
+
+// Glob invokes the callback for the keys matching the glob pattern.
+// The keys with the literal prefix of the pattern are walked.
+func (ct *compactTree) Glob(pattern string, cb Callback, opts ...GlobOption) error {
+	g, err := parseGlob(pattern, createGlobOptions(opts...))
+	if err != nil {
+		return err
+	}
+
+	a, err := NewRegexpAutomaton(g.expr)
+	if err != nil {
+		return err
+	}
+
+	ct.ForEachPrefix(append(Key{}, g.prefix...), func(node NodeKV) bool {
+		key := node.Key()
+		if !bytes.HasSuffix(key, g.suffix) {
+			return true
+		}
+
+		if state, ok := stepAutomaton(a, a.Start(), key); ok && a.IsMatch(state) {
+			return cb(node)
+		}
+
+		return true
+	})
+
+	return nil
+}
+
+// Iterator returns a new tree iterator.
+func (ct *compactTree) Iterator(opts ...int) Iterator {
+	options := traverseOptions(opts...)
+
+	it := &compactIterator{
+		version:  ct.version,
+		tree:     ct,
+		nextNode: ct.root,
+		reverse:  options.hasReverse(),
+	}
+
+	if options.hasResilient() {
+		return &resilientIterator{opts: options, it: it, tree: ct}
+	}
+
+	if options&TraverseAll == TraverseAll {
+		return it
+	}
+
+	bit := &bufferedIterator{
+		opts: options,
+		it:   it,
+	}
+
+	// peek the first Node or LeafKind
+	bit.peek()
+
+	return bit
+}
+
+// compactIterator iterates over all nodes of the compact tree in pre-order.
+type compactIterator struct {
+	version  int          // tree version at the time of iterator creation
+	tree     *compactTree // tree to iterate
+	stack    [][]cref     // stack holds the children left to visit of each visited Node
+	nextNode cref         // next Node to iterate
+	reverse  bool         // indicates if the iteration is in reverse order
+}
+
+// assert that compactIterator implements the seekingIterator interface.
+var _ seekingIterator = (*compactIterator)(nil)
+
+// HasNext returns true if there are more nodes to iterate.
+func (it *compactIterator) HasNext() bool {
+	return it.nextNode != 0
+}
+
+// Next returns the next Node and an error if any.
+// It returns ErrNoMoreNodes if there are no more nodes to iterate.
+// It returns ErrConcurrentModification if the tree has been modified concurrently.
+func (it *compactIterator) Next() (NodeKV, error) {
+	if !it.HasNext() {
+		return nil, ErrNoMoreNodes
+	}
+
+	if it.version != it.tree.version {
+		return nil, ErrConcurrentModification
+	}
+
+	current := it.nextNode
+	it.next(current)
+
+	return it.tree.nodeKV(current), nil
+}
+
+// next moves the iterator to the Node following the current one.
+func (it *compactIterator) next(current cref) {
+	if !current.isLeaf() {
+		var children []cref
+
+		it.tree.view(current).each(it.reverse, func(_ keyChar, child cref) bool {
+			children = append(children, child)
+
+			return true
+		})
+
+		it.stack = append(it.stack, children)
+	}
+
+	it.advance()
+}
+
+// advance moves the iterator to the next child left to visit on the stack.
+func (it *compactIterator) advance() {
+	for last := len(it.stack) - 1; last >= 0; last = len(it.stack) - 1 {
+		if children := it.stack[last]; len(children) > 0 {
+			it.nextNode = children[0]
+			it.stack[last] = children[1:]
+
+			return
+		}
+
+		it.stack = it.stack[:last]
+	}
+
+	it.nextNode = 0
+}
+
+// seekAfter moves the iterator to the first Node following the key in the iteration order,
+// the nodes on the path of the key, which precede it, are not visited.
+func (it *compactIterator) seekAfter(key Key) {
+	it.stack, it.nextNode = it.stack[:0], 0
+
+	r, depth := it.tree.root, 0
+	for r != 0 {
+		if r.isLeaf() {
+			if follows(bytes.Compare(it.tree.leafKey(r), key), it.reverse) {
+				it.nextNode = r
+			}
+
+			break
+		}
+
+		// the whole subtree follows or precedes the key unless the key extends its path
+		prefix := it.tree.fullPrefix(r, depth)
+		if !bytes.HasPrefix(key[depth:], prefix) {
+			if follows(bytes.Compare(prefix, key[depth:]), it.reverse) {
+				it.nextNode = r
+			}
+
+			break
+		}
+
+		depth += len(prefix)
+
+		// keep the children following the key character, the child under it is visited next
+		kc := key.charAt(depth)
+
+		var (
+			next     cref
+			children []cref
+		)
+
+		it.tree.view(r).each(it.reverse, func(ckc keyChar, child cref) bool {
+			switch {
+			case ckc == kc:
+				next = child
+			case (ckc.invalid || (!kc.invalid && ckc.ch < kc.ch)) == it.reverse:
+				children = append(children, child)
+			}
+
+			return true
+		})
+
+		it.stack = append(it.stack, children)
+
+		r = next
+		if !kc.invalid {
+			depth++
+		}
+	}
+
+	if it.nextNode == 0 {
+		it.advance()
+	}
+}
+
+// resync adopts the current tree version, it returns false if the tree has not been modified.
+func (it *compactIterator) resync() bool {
+	if it.version == it.tree.version {
+		return false
+	}
+
+	it.version = it.tree.version
+
+	return true
+}
+
+// rewind moves the iterator back to the root of the tree.
+func (it *compactIterator) rewind() {
+	it.stack, it.nextNode = it.stack[:0], it.tree.root
+}
@@ -0,0 +1,570 @@
+package art
+
+import (
+	"bytes"
+)
+
+// compactTree is an adaptive radix tree storing its nodes in pages of pointer-free objects.
+// Nodes are addressed by 32-bit references, the keys are stored in shared byte pages
+// and the values in separate pages indexed by Leaf, so the garbage collector
+// scans only the values instead of the whole tree.
+type compactTree struct {
+	version int  // version is used to detect concurrent modifications
+	size    int  // size is the number of elements in the tree
+	root    cref // root is the root Node of the tree
+
+	leaves   compactPool[compactLeaf]
+	node4s   compactPool[compactNode4]
+	node16s  compactPool[compactNode16]
+	node48s  compactPool[compactNode48]
+	node256s compactPool[compactNode256]
+	keys     compactKeys
+	values   compactValues
+}
+
+// make sure that compactTree implements all methods from the Tree interface.
+var _ Tree = (*compactTree)(nil)
+
+// NewCompact creates a new adaptive radix tree with a pointer-free Node layout.
+// It is meant for large trees, the garbage collector doesn't have to scan the nodes and the keys.
+// A compact tree holds up to 2^29 nodes of each kind.
+// SplitAt moves the keys to the new tree, and Join doesn't support compact trees.
+// The key bytes of the deleted leaves are not reclaimed, a tree with a high churn
+// should be rebuilt from time to time to release them.
+func NewCompact() Tree {
+	return &compactTree{}
+}
+
+// newNode allocates an empty inner Node of the given kind.
+func (ct *compactTree) newNode(kind Kind) cref {
+	var idx uint32
+
+	switch kind { //nolint:exhaustive
+	case Node4Kind:
+		idx = ct.node4s.alloc()
+		*ct.node4s.at(idx) = compactNode4{}
+	case Node16Kind:
+		idx = ct.node16s.alloc()
+		*ct.node16s.at(idx) = compactNode16{}
+	case Node48Kind:
+		idx = ct.node48s.alloc()
+		*ct.node48s.at(idx) = compactNode48{}
+	case Node256Kind:
+		idx = ct.node256s.alloc()
+		*ct.node256s.at(idx) = compactNode256{}
+	}
+
+	return newCref(kind, idx)
+}
+
+// releaseNode puts the inner Node to the free list of its pool.
+func (ct *compactTree) releaseNode(r cref) {
+	switch r.kind() { //nolint:exhaustive
+	case Node4Kind:
+		ct.node4s.release(r.index())
+	case Node16Kind:
+		ct.node16s.release(r.index())
+	case Node48Kind:
+		ct.node48s.release(r.index())
+	case Node256Kind:
+		ct.node256s.release(r.index())
+	}
+}
+
+// newLeaf allocates a Leaf, the key is copied to the key space of a released Leaf if it fits.
+func (ct *compactTree) newLeaf(key Key, value Value) cref {
+	idx := ct.leaves.alloc()
+	leaf := ct.leaves.at(idx)
+
+	if int(leaf.keyCap) < len(key) {
+		leaf.keyPage, leaf.keyOff = ct.keys.alloc(len(key))
+		leaf.keyCap = uint32(len(key)) //nolint:gosec
+	}
+
+	leaf.keyLen = uint32(len(key)) //nolint:gosec
+	copy(ct.keys.bytes(leaf.keyPage, leaf.keyOff, leaf.keyLen), key)
+	ct.values.set(idx, value)
+
+	return newCref(LeafKind, idx)
+}
+
+// releaseLeaf puts the Leaf to the free list and returns its value.
+func (ct *compactTree) releaseLeaf(r cref) Value {
+	oldValue := ct.values.get(r.index())
+	ct.values.set(r.index(), nil)
+
+	ct.leaves.at(r.index()).keyLen = 0
+	ct.leaves.release(r.index())
+
+	return oldValue
+}
+
+// leafKey returns the key of the Leaf.
+func (ct *compactTree) leafKey(r cref) Key {
+	leaf := ct.leaves.at(r.index())
+
+	return ct.keys.bytes(leaf.keyPage, leaf.keyOff, leaf.keyLen)
+}
+
+// view returns the view of the inner Node.
+func (ct *compactTree) view(r cref) compactView {
+	switch r.kind() { //nolint:exhaustive
+	case Node4Kind:
+		n := ct.node4s.at(r.index())
+
+		return compactView{hdr: &n.compactHeader, keys: n.keys[:], children: n.children[:]}
+	case Node16Kind:
+		n := ct.node16s.at(r.index())
+
+		return compactView{hdr: &n.compactHeader, keys: n.keys[:], children: n.children[:]}
+	case Node48Kind:
+		n := ct.node48s.at(r.index())
+
+		return compactView{hdr: &n.compactHeader, keys: n.keys[:], children: n.children[:]}
+	case Node256Kind:
+		n := ct.node256s.at(r.index())
+
+		return compactView{hdr: &n.compactHeader, children: n.children[:]}
+	}
+
+	return compactView{}
+}
+
+// minimum returns the Leaf with the smallest key of the subtree.
+func (ct *compactTree) minimum(r cref) cref {
+	for r != 0 && !r.isLeaf() {
+		v := ct.view(r)
+		if v.hdr.zeroChild != 0 {
+			return v.hdr.zeroChild
+		}
+
+		r = v.first()
+	}
+
+	return r
+}
+
+// maximum returns the Leaf with the greatest key of the subtree.
+func (ct *compactTree) maximum(r cref) cref {
+	for r != 0 && !r.isLeaf() {
+		r = ct.view(r).last()
+	}
+
+	return r
+}
+
+// fullPrefix returns the complete prefix of the inner Node located at the given depth,
+// the bytes which are not stored in the Node are taken from its minimum Leaf key.
+func (ct *compactTree) fullPrefix(r cref, depth int) []byte {
+	h := ct.view(r).hdr
+	if int(h.prefixLen) <= maxPrefixLen {
+		return h.storedPrefix()
+	}
+
+	return ct.leafKey(ct.minimum(r))[depth : depth+int(h.prefixLen)]
+}
+
+// prefixMismatch returns the number of bytes of the Node prefix matching the key at the given depth.
+func (ct *compactTree) prefixMismatch(r cref, key Key, depth int) int {
+	h := ct.view(r).hdr
+	stored := h.storedPrefix()
+
+	idx := findLongestCommonPrefix(stored, key[depth:], 0)
+	if idx < len(stored) || int(h.prefixLen) == len(stored) {
+		return idx
+	}
+
+	minKey := ct.leafKey(ct.minimum(r))
+
+	return idx + findLongestCommonPrefix(minKey[:depth+int(h.prefixLen)], key, depth+idx)
+}
+
+// grow replaces the full Node referenced by the slot with a Node of the next kind.
+func (ct *compactTree) grow(slot *cref) compactView {
+	return ct.convert(slot, (*slot).kind()+1)
+}
+
+// convert replaces the Node referenced by the slot with a Node of the given kind holding the same children.
+func (ct *compactTree) convert(slot *cref, kind Kind) compactView {
+	oldRef := *slot
+	newRef := ct.newNode(kind)
+	oldView, newView := ct.view(oldRef), ct.view(newRef)
+
+	*newView.hdr = *oldView.hdr
+	newView.hdr.childrenLen = 0
+
+	oldView.each(false, func(kc keyChar, child cref) bool {
+		if !kc.invalid {
+			newView.add(kc, child)
+		}
+
+		return true
+	})
+
+	ct.releaseNode(oldRef)
+	*slot = newRef
+
+	return newView
+}
+
+// shrink replaces the under-utilized Node referenced by the slot with a smaller one.
+// A Node4 with a single child is replaced by the child.
+func (ct *compactTree) shrink(slot *cref) {
+	r := *slot
+	v := ct.view(r)
+
+	switch r.kind() { //nolint:exhaustive
+	case Node4Kind:
+		if v.numChildren() < node4Min {
+			ct.collapse(slot, v)
+		}
+	case Node16Kind:
+		if v.hdr.childrenLen < node16Min {
+			ct.convert(slot, Node4Kind)
+		}
+	case Node48Kind:
+		if v.hdr.childrenLen < node48Min {
+			ct.convert(slot, Node16Kind)
+		}
+	case Node256Kind:
+		if v.hdr.childrenLen < node256Min {
+			ct.convert(slot, Node48Kind)
+		}
+	}
+}
+
+// collapse replaces the Node4 referenced by the slot with its only child.
+// The prefix of an inner child is extended with the Node prefix and the child key.
+func (ct *compactTree) collapse(slot *cref, v compactView) {
+	child, kc := v.hdr.zeroChild, keyCharInvalid
+	if child == 0 {
+		child, kc = v.children[0], keyChar{ch: v.keys[0]}
+	}
+
+	if !child.isLeaf() {
+		childHdr := ct.view(child).hdr
+
+		var buf [2*maxPrefixLen + 1]byte
+
+		prefix := append(buf[:0], v.hdr.storedPrefix()...)
+		if int(v.hdr.prefixLen) <= maxPrefixLen {
+			prefix = append(prefix, kc.ch)
+			prefix = append(prefix, childHdr.storedPrefix()...)
+		}
+
+		childHdr.prefixLen += v.hdr.prefixLen + 1
+		copy(childHdr.prefix[:], prefix)
+	}
+
+	ct.releaseNode(*slot)
+	*slot = child
+}
+
+// Insert inserts the given key and value into the tree.
+// If the key already exists, it updates the value and
+// returns the old value with second return value set to true.
+func (ct *compactTree) Insert(key Key, value Value) (Value, bool) {
+	oldValue, updated := ct.insertRecursively(&ct.root, key, value, 0)
+	if !updated {
+		ct.version++
+		ct.size++
+	}
+
+	return oldValue, updated
+}
+
+// insertRecursively inserts the key-value pair into the subtree referenced by the slot.
+func (ct *compactTree) insertRecursively(slot *cref, key Key, value Value, depth int) (Value, bool) {
+	r := *slot
+	if r == 0 {
+		*slot = ct.newLeaf(key, value)
+
+		return nil, false
+	}
+
+	if r.isLeaf() {
+		leafKey := ct.leafKey(r)
+		if bytes.Equal(leafKey, key) {
+			oldValue := ct.values.get(r.index())
+			ct.values.set(r.index(), value)
+
+			return oldValue, true
+		}
+
+		// split the Leaf to a Node4 with the longest common prefix of both keys
+		lcp := findLongestCommonPrefix(leafKey, key, depth)
+
+		nr := ct.newNode(Node4Kind)
+		nv := ct.view(nr)
+		nv.hdr.setPrefix(key[depth:], lcp)
+		nv.add(leafKey.charAt(depth+lcp), r)
+		nv.add(key.charAt(depth+lcp), ct.newLeaf(key, value))
+		*slot = nr
+
+		return nil, false
+	}
+
+	v := ct.view(r)
+	if prefixLen := int(v.hdr.prefixLen); prefixLen > 0 {
+		if mismatchIdx := ct.prefixMismatch(r, key, depth); mismatchIdx < prefixLen {
+			ct.splitPrefix(slot, key, value, depth, mismatchIdx)
+
+			return nil, false
+		}
+
+		depth += prefixLen
+	}
+
+	kc := key.charAt(depth)
+	if next := v.find(kc); next != nil {
+		return ct.insertRecursively(next, key, value, depth+1)
+	}
+
+	if v.isFull() {
+		v = ct.grow(slot)
+	}
+
+	v.add(kc, ct.newLeaf(key, value))
+
+	return nil, false
+}
+
+// splitPrefix branches the key from the prefix of the Node referenced by the slot with a new Node4.
+func (ct *compactTree) splitPrefix(slot *cref, key Key, value Value, depth int, mismatchIdx int) {
+	r := *slot
+	hdr := ct.view(r).hdr
+	prefix := ct.fullPrefix(r, depth)
+
+	nr := ct.newNode(Node4Kind)
+	nv := ct.view(nr)
+	nv.hdr.setPrefix(key[depth:], mismatchIdx)
+	nv.add(keyChar{ch: prefix[mismatchIdx]}, r)
+	nv.add(key.charAt(depth+mismatchIdx), ct.newLeaf(key, value))
+
+	// the Node keeps the rest of its prefix after the mismatching byte
+	hdr.setPrefix(prefix[mismatchIdx+1:], int(hdr.prefixLen)-mismatchIdx-1)
+	*slot = nr
+}
+
+// Delete deletes the given key from the tree.
+func (ct *compactTree) Delete(key Key) (Value, bool) {
+	value, deleted := ct.deleteRecursively(&ct.root, key, 0)
+	if deleted {
+		ct.version++
+		ct.size--
+	}
+
+	return value, deleted
+}
+
+// deleteRecursively removes the key from the subtree referenced by the slot.
+func (ct *compactTree) deleteRecursively(slot *cref, key Key, depth int) (Value, bool) {
+	r := *slot
+	if r == 0 {
+		return nil, false
+	}
+
+	if r.isLeaf() {
+		if !bytes.Equal(ct.leafKey(r), key) {
+			return nil, false
+		}
+
+		*slot = 0
+
+		return ct.releaseLeaf(r), true
+	}
+
+	v := ct.view(r)
+	if v.hdr.prefixLen > 0 {
+		stored := v.hdr.storedPrefix()
+		if findLongestCommonPrefix(stored, key[depth:], 0) != len(stored) {
+			return nil, false
+		}
+
+		depth += int(v.hdr.prefixLen)
+	}
+
+	kc := key.charAt(depth)
+
+	next := v.find(kc)
+	if next == nil {
+		return nil, false
+	}
+
+	if !next.isLeaf() {
+		return ct.deleteRecursively(next, key, depth+1)
+	}
+
+	if !bytes.Equal(ct.leafKey(*next), key) {
+		return nil, false
+	}
+
+	value := ct.releaseLeaf(*next)
+	v.remove(kc)
+	ct.shrink(slot)
+
+	return value, true
+}
+
+// Search searches for the given key in the tree.
+func (ct *compactTree) Search(key Key) (Value, bool) {
+	depth := 0
+
+	for r := ct.root; r != 0; {
+		if r.isLeaf() {
+			if bytes.Equal(ct.leafKey(r), key) {
+				return ct.values.get(r.index()), true
+			}
+
+			return nil, false
+		}
+
+		v := ct.view(r)
+		if v.hdr.prefixLen > 0 {
+			stored := v.hdr.storedPrefix()
+			if findLongestCommonPrefix(stored, key[depth:], 0) != len(stored) {
+				return nil, false
+			}
+
+			depth += int(v.hdr.prefixLen)
+		}
+
+		next := v.find(key.charAt(depth))
+		if next == nil {
+			return nil, false
+		}
+
+		r = *next
+		depth++
+	}
+
+	return nil, false
+}
+
+// LongestPrefix returns the longest key of the tree which is a prefix of the given key.
+func (ct *compactTree) LongestPrefix(key Key) (Key, Value, bool) {
+	var best cref
+
+	depth := 0
+
+	for r := ct.root; r != 0; {
+		if r.isLeaf() {
+			if bytes.HasPrefix(key, ct.leafKey(r)) {
+				best = r
+			}
+
+			break
+		}
+
+		v := ct.view(r)
+		if prefixLen := int(v.hdr.prefixLen); prefixLen > 0 {
+			if depth+prefixLen > len(key) || ct.prefixMismatch(r, key, depth) < prefixLen {
+				break
+			}
+
+			depth += prefixLen
+		}
+
+		if v.hdr.zeroChild != 0 {
+			best = v.hdr.zeroChild
+		}
+
+		if depth >= len(key) {
+			break
+		}
+
+		next := v.find(key.charAt(depth))
+		if next == nil {
+			break
+		}
+
+		r = *next
+		depth++
+	}
+
+	if best == 0 {
+		return nil, nil, false
+	}
+
+	return ct.leafKey(best), ct.values.get(best.index()), true
+}
+
+// Minimum returns the minimum key in the tree.
+func (ct *compactTree) Minimum() (Value, bool) {
+	if ct.root == 0 {
+		return nil, false
+	}
+
+	return ct.values.get(ct.minimum(ct.root).index()), true
+}
+
+// Maximum returns the maximum key in the tree.
+func (ct *compactTree) Maximum() (Value, bool) {
+	if ct.root == 0 {
+		return nil, false
+	}
+
+	return ct.values.get(ct.maximum(ct.root).index()), true
+}
+
+// Size returns the number of elements in the tree.
+func (ct *compactTree) Size() int {
+	return ct.size
+}
+
+// SplitAt moves all keys greater than or equal to the given key into a new compact tree.
+// Unlike the pointer-based tree, the keys are reinserted into the new tree.
+func (ct *compactTree) SplitAt(key Key) (Tree, Tree) {
+	right := &compactTree{}
+
+	ct.ForEach(func(node NodeKV) bool {
+		if bytes.Compare(node.Key(), key) >= 0 {
+			right.Insert(node.Key(), node.Value())
+		}
+
+		return true
+	})
+
+	right.ForEach(func(node NodeKV) bool {
+		ct.Delete(node.Key())
+
+		return true
+	})
+
+	return ct, right
+}
+
+// Clone returns a deep copy of the tree.
+// Values are shared between the original tree and the copy.
+func (ct *compactTree) Clone() Tree {
+	return ct.CloneWith(nil)
+}
+
+// CloneWith returns a deep copy of the tree,
+// each value is copied with the cloneValue function.
+// If cloneValue is nil, values are shared between the original tree and the copy.
+func (ct *compactTree) CloneWith(cloneValue func(Value) Value) Tree {
+	clone := &compactTree{
+		size:     ct.size,
+		root:     ct.root,
+		leaves:   ct.leaves.clone(),
+		node4s:   ct.node4s.clone(),
+		node16s:  ct.node16s.clone(),
+		node48s:  ct.node48s.clone(),
+		node256s: ct.node256s.clone(),
+		keys:     ct.keys.clone(),
+		values:   ct.values.clone(),
+	}
+
+	if cloneValue != nil {
+		clone.walk(clone.root, false, func(r cref) bool {
+			if r.isLeaf() {
+				clone.values.set(r.index(), cloneValue(clone.values.get(r.index())))
+			}
+
+			return true
+		})
+	}
+
+	return clone
+}
@@ -0,0 +1,269 @@
+package art
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// traceForEach returns the kinds and keys of the nodes visited by ForEach.
+func traceForEach(tree Tree, options ...int) []string {
+	var trace []string
+
+	tree.ForEach(func(node NodeKV) bool {
+		trace = append(trace, fmt.Sprintf("%v:%q", node.Kind(), node.Key()))
+
+		return true
+	}, options...)
+
+	return trace
+}
+
+// traceIterator returns the kinds and keys of the nodes returned by the iterator.
+func traceIterator(it Iterator) []string {
+	var trace []string
+
+	for it.HasNext() {
+		node, err := it.Next()
+		if err != nil {
+			break
+		}
+
+		trace = append(trace, fmt.Sprintf("%v:%q", node.Kind(), node.Key()))
+	}
+
+	return trace
+}
+
+// assertSameTrees checks that the compact tree has the same content and structure as the reference tree.
+func assertSameTrees(t *testing.T, expected, actual Tree) {
+	t.Helper()
+
+	assert.Equal(t, expected.Size(), actual.Size())
+
+	for _, opts := range []int{TraverseLeaf, TraverseAll, TraverseNode | TraverseReverse, TraverseAll | TraverseReverse} {
+		assert.Equal(t, traceForEach(expected, opts), traceForEach(actual, opts))
+		assert.Equal(t, traceIterator(expected.Iterator(opts)), traceIterator(actual.Iterator(opts)))
+	}
+
+	expMin, expFound := expected.Minimum()
+	actMin, actFound := actual.Minimum()
+	assert.Equal(t, expFound, actFound)
+	assert.Equal(t, expMin, actMin)
+
+	expMax, expFound := expected.Maximum()
+	actMax, actFound := actual.Maximum()
+	assert.Equal(t, expFound, actFound)
+	assert.Equal(t, expMax, actMax)
+}
+
+func TestCompactTree(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"test/assets/words.txt", "test/assets/uuid.txt", "test/assets/hsk_words.txt"}
+
+	for _, file := range files {
+		file := file
+		t.Run(file, func(t *testing.T) {
+			t.Parallel()
+
+			data := loadTestFile(file)
+			expected, compact := New(), NewCompact()
+
+			for _, d := range data {
+				_, updated := compact.Insert(d, string(d))
+				assert.False(t, updated)
+				expected.Insert(d, string(d))
+			}
+
+			assertSameTrees(t, expected, compact)
+
+			for _, d := range data {
+				val, found := compact.Search(d)
+				require.True(t, found, string(d))
+				require.Equal(t, string(d), val)
+			}
+
+			// delete every other key and insert some of them back to reuse the released nodes
+			for i, d := range data {
+				if i%2 == 0 {
+					expVal, expDeleted := expected.Delete(d)
+					val, deleted := compact.Delete(d)
+					require.Equal(t, expDeleted, deleted, string(d))
+					require.Equal(t, expVal, val)
+				}
+			}
+
+			assertSameTrees(t, expected, compact)
+
+			for i, d := range data {
+				if i%4 == 0 {
+					expected.Insert(d, i)
+					compact.Insert(d, i)
+				}
+			}
+
+			assertSameTrees(t, expected, compact)
+
+			for _, d := range data {
+				expected.Delete(d)
+				compact.Delete(d)
+			}
+
+			assertSameTrees(t, expected, compact)
+		})
+	}
+}
+
+func TestCompactTreeNullAndPrefixKeys(t *testing.T) {
+	t.Parallel()
+
+	keys := []Key{
+		{}, {0}, {0, 0}, {0, 0, 0}, {0, 1}, Key("a"), Key("a\x00"), Key("ab"), Key("abc"),
+		Key("abcdefghijklmnopqrstuvwxyz"), Key("abcdefghijklmnopqrstuvwxyz1"), Key("abcdefghijklmnopqrstuvwxzz"),
+	}
+
+	for i := 0; i < 300; i++ {
+		keys = append(keys, Key{'k', byte(i), byte(i >> 8)})
+	}
+
+	expected, compact := New(), NewCompact()
+	for i, k := range keys {
+		expected.Insert(k, i)
+		compact.Insert(k, i)
+	}
+
+	assertSameTrees(t, expected, compact)
+
+	for i, k := range keys {
+		val, found := compact.Search(k)
+		assert.True(t, found, k)
+		assert.Equal(t, i, val, k)
+	}
+
+	_, found := compact.Search(Key("abcdefghijklmnopqrstuvwxyy"))
+	assert.False(t, found)
+
+	for _, k := range keys[1:] {
+		expected.Delete(k)
+		compact.Delete(k)
+		assertSameTrees(t, expected, compact)
+	}
+}
+
+func TestCompactTreeEmptyKey(t *testing.T) {
+	t.Parallel()
+
+	newCompact := func() Tree {
+		tree := NewCompact()
+		for _, k := range []string{"", "a", "ab", "b"} {
+			tree.Insert(Key(k), k)
+		}
+
+		return tree
+	}
+
+	tree := newCompact()
+
+	val, deleted := tree.Delete(Key(""))
+	assert.True(t, deleted)
+	assert.Equal(t, "", val)
+	assert.Equal(t, 3, tree.Size())
+	require.NoError(t, tree.Validate())
+
+	_, found := tree.Search(Key(""))
+	assert.False(t, found)
+
+	left, right := newCompact().SplitAt(Key(""))
+	assert.Equal(t, 0, left.Size())
+	assert.Equal(t, 4, right.Size())
+	assert.Equal(t, []string{"", "a", "ab", "b"}, collectKeys(right))
+
+	left, right = newCompact().SplitAt(Key("a"))
+	assert.Equal(t, []string{""}, collectKeys(left))
+	assert.Equal(t, []string{"a", "ab", "b"}, collectKeys(right))
+}
+
+func TestCompactTreeForEachPrefix(t *testing.T) {
+	t.Parallel()
+
+	data := loadTestFile("test/assets/words.txt")
+	expected, compact := New(), NewCompact()
+
+	for _, d := range data {
+		expected.Insert(d, d)
+		compact.Insert(d, d)
+	}
+
+	prefixes := []string{"", "a", "ab", "abs", "monk", "monkey", "zz", "ACT", "éa", "x"}
+
+	for _, prefix := range prefixes {
+		for _, opts := range []int{TraverseLeaf, TraverseReverse} {
+			var exp, act []string
+
+			expected.ForEachPrefix(Key(prefix), func(node NodeKV) bool {
+				exp = append(exp, string(node.Key()))
+
+				return true
+			}, opts)
+
+			compact.ForEachPrefix(Key(prefix), func(node NodeKV) bool {
+				act = append(act, string(node.Key()))
+
+				return true
+			}, opts)
+
+			assert.Equal(t, exp, act, prefix)
+		}
+	}
+}
+
+func TestCompactTreeIteratorConcurrentModification(t *testing.T) {
+	t.Parallel()
+
+	tree := NewCompact()
+	tree.Insert(Key("a"), 1)
+	tree.Insert(Key("b"), 2)
+
+	it := tree.Iterator()
+	assert.True(t, it.HasNext())
+
+	tree.Insert(Key("c"), 3)
+
+	_, err := it.Next()
+	assert.ErrorIs(t, err, ErrConcurrentModification)
+
+	_, err = NewCompact().Iterator().Next()
+	assert.ErrorIs(t, err, ErrNoMoreNodes)
+}
+
+func TestCompactTreeSplitAtAndClone(t *testing.T) {
+	t.Parallel()
+
+	tree := NewCompact()
+	data := loadTestFile("test/assets/words.txt")
+
+	for _, d := range data {
+		tree.Insert(d, string(d))
+	}
+
+	clone := tree.CloneWith(func(v Value) Value { return v.(string) + "!" })
+	assert.Equal(t, tree.Size(), clone.Size())
+
+	left, right := tree.SplitAt(Key("monkey"))
+	assert.Same(t, tree, left)
+	assert.Equal(t, len(data), left.Size()+right.Size())
+
+	for _, d := range data {
+		val, found := clone.Search(d)
+		assert.True(t, found)
+		assert.Equal(t, string(d)+"!", val)
+
+		side := ternary(string(d) < "monkey", left, right)
+		val, found = side.Search(d)
+		assert.True(t, found)
+		assert.Equal(t, string(d), val)
+	}
+}
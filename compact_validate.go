@@ -0,0 +1,96 @@
+package art
+
+// Validate walks the compact tree and verifies its structural invariants.
+func (ct *compactTree) Validate() error {
+	var v validator
+	if err := ct.validateRecursively(&v, ct.root, nil, false); err != nil {
+		return err
+	}
+
+	return v.checkSize(ct.size)
+}
+
+// validateRecursively verifies the subtree reached by the path.
+func (ct *compactTree) validateRecursively(v *validator, r cref, path Key, zeroChild bool) error {
+	if r == 0 {
+		return nil
+	}
+
+	kind := r.kind()
+	if kind < LeafKind || kind > Node256Kind {
+		return invalid(path, kind, "unknown Node kind")
+	}
+
+	if r.isLeaf() {
+		return v.checkLeaf(path, ct.leafKey(r), zeroChild)
+	}
+
+	if zeroChild {
+		return invalid(path, kind, "zero byte child is not a Leaf")
+	}
+
+	// the children violations are reported at the path extended with the Node prefix
+	nodePath, err := ct.validatePrefix(r, path)
+	if err != nil {
+		return err
+	}
+
+	view := ct.view(r)
+	childrenLen := int(view.hdr.childrenLen)
+
+	if view.keys != nil {
+		if err := checkSortedKeys(nodePath, kind, view.keys, childrenLen, func(i int) (bool, bool) {
+			return i < childrenLen, view.children[i] != 0
+		}); err != nil {
+			return err
+		}
+	} else {
+		numChildren := 0
+		for _, child := range view.children {
+			if child != 0 {
+				numChildren++
+			}
+		}
+
+		if numChildren != childrenLen {
+			return invalid(nodePath, kind, "children count %d doesn't match %d children", childrenLen, numChildren)
+		}
+	}
+
+	if err := checkBounds(nodePath, kind, childrenLen, view.hdr.zeroChild != 0); err != nil {
+		return err
+	}
+
+	view.each(false, func(kc keyChar, child cref) bool {
+		childPath := nodePath
+		if !kc.invalid {
+			childPath = append(nodePath[:len(nodePath):len(nodePath)], kc.ch)
+		}
+
+		err = ct.validateRecursively(v, child, childPath, kc.invalid)
+
+		return err == nil
+	})
+
+	return err
+}
+
+// validatePrefix verifies the stored prefix of the inner Node and returns the path extended with the full prefix.
+func (ct *compactTree) validatePrefix(r cref, path Key) (Key, error) {
+	hdr := ct.view(r).hdr
+	prefix := hdr.storedPrefix()
+
+	if int(hdr.prefixLen) > maxPrefixLen {
+		minLeaf := ct.minimum(r)
+		if minLeaf == 0 || !minLeaf.isLeaf() || len(ct.leafKey(minLeaf)) < len(path)+int(hdr.prefixLen) {
+			return nil, invalid(path, r.kind(), "prefix of length %d can't be restored from the minimum Leaf", hdr.prefixLen)
+		}
+
+		prefix = ct.leafKey(minLeaf)[len(path) : len(path)+int(hdr.prefixLen)]
+		if string(prefix[:maxPrefixLen]) != string(hdr.storedPrefix()) {
+			return nil, invalid(path, r.kind(), "stored prefix %q doesn't match the minimum Leaf", hdr.storedPrefix())
+		}
+	}
+
+	return append(path[:len(path):len(path)], prefix...), nil
+}
@@ -15,6 +15,11 @@ const (
 	node256Max = 256           // maximum number of children for Node256.
 )
 
+const (
+	// kindCount is the number of Node kinds, see Kind.
+	kindCount = int(Node256Kind) + 1
+)
+
 const (
 	// maxPrefixLen is maximum prefix length for internal nodes.
 	maxPrefixLen = 10
@@ -2,9 +2,9 @@ package main
 
 import (
 	"errors"
-	"fmt"
 
 	art "github.com/alexisvisco/go-adaptive-radix-tree/v2"
+	"github.com/alexisvisco/go-adaptive-radix-tree/v2/keys"
 )
 
 // GTree is a generic tree that supports any type for keys and values.
@@ -73,11 +73,13 @@ func (gt *GTree[K, V]) ForEach(callback func(node art.NodeKV) bool, options ...i
 	gt.tree.ForEach(callback, options...)
 }
 
-// Helper function to convert an integer key to a byte slice.
+// Helper function to convert a key to a byte slice preserving the order of the keys.
 func convertKeyToBytes[K comparable](key K) ([]byte, error) {
 	switch v := any(key).(type) {
 	case int:
-		return []byte(fmt.Sprintf("%d", v)), nil // Simple conversion
+		return keys.AppendInt64(nil, int64(v)), nil
+	case string:
+		return keys.AppendString(nil, v), nil
 	default:
 		return nil, errors.New("unsupported key type")
 	}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	art "github.com/alexisvisco/go-adaptive-radix-tree/v2"
+	"github.com/alexisvisco/go-adaptive-radix-tree/v2/keys"
 )
 
 func main() {
@@ -14,6 +15,7 @@ func main() {
 	tree.Insert(1, "one")
 	tree.Insert(2, "two")
 	tree.Insert(3, "three")
+	tree.Insert(10, "ten")
 
 	// Search for a value.
 	if value, found := tree.Search(2); found {
@@ -30,11 +32,12 @@ func main() {
 	}
 
 	// Check the size of the tree.
-	fmt.Printf("Tree Size: %d\n", tree.Size()) // Output: Tree Size: 2
+	fmt.Printf("Tree Size: %d\n", tree.Size()) // Output: Tree Size: 3
 
 	// Traverse the tree using ForEach.
 	tree.ForEach(func(node art.NodeKV) bool {
-		fmt.Printf("Node Key: %s, Node Value: %s\n", string(node.Key()), node.Value().(string))
+		key, _, _ := keys.DecodeInt64(node.Key())
+		fmt.Printf("Node Key: %d, Node Value: %s\n", key, node.Value().(string))
 		return true // Continue iteration
 	}, art.TraverseLeaf)
 }
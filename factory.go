@@ -4,19 +4,24 @@ import (
 	"unsafe"
 )
 
-// nodeFactory is an interface for creating various types of ART nodes,
+// NodeFactory is an interface for creating various types of ART nodes,
 // including nodes with different capacities and Leaf nodes.
-type nodeFactory interface {
-	newNode4() *NodeRef
-	newNode16() *NodeRef
-	newNode48() *NodeRef
-	newNode256() *NodeRef
+// A custom NodeFactory can be set per tree with the WithAllocator option.
+type NodeFactory interface {
+	NewNode4() NodeRef
+	NewNode16() NodeRef
+	NewNode48() NodeRef
+	NewNode256() NodeRef
 
-	newLeaf(key Key, value interface{}) *NodeRef
+	NewLeaf(key Key, value interface{}) NodeRef
+
+	// Release is called when the tree no longer uses the referenced Node, so it can be recycled.
+	// The children of the released Node are still used by the tree.
+	Release(nr NodeRef)
 }
 
-// make sure that objFactory implements all methods of nodeFactory interface.
-var _ nodeFactory = &objFactory{}
+// make sure that objFactory implements all methods of NodeFactory interface.
+var _ NodeFactory = &objFactory{}
 
 //nolint:gochecknoglobals
 var (
@@ -24,65 +29,71 @@ var (
 )
 
 // newTree creates a new tree.
-func newTree() *tree {
+func newTree(opts ...TreeOption) *tree {
 	return &tree{
 		version: 0,
-		root:    nil,
+		root:    NodeRef{},
 		size:    0,
+		opts:    createTreeOptions(opts...),
 	}
 }
 
-// objFactory implements nodeFactory interface.
+// objFactory implements NodeFactory interface.
 type objFactory struct{}
 
 // newObjFactory creates a new objFactory.
-func newObjFactory() nodeFactory {
+func newObjFactory() NodeFactory {
 	return &objFactory{}
 }
 
-// Simple obj factory implementation.
-func (f *objFactory) newNode4() *NodeRef {
-	return &NodeRef{
-		kind: Node4Kind,
-		ref:  unsafe.Pointer(new(Node4)), //#nosec:G103
-	}
+// NewNode4 creates a new Node4 as a NodeRef.
+func (f *objFactory) NewNode4() NodeRef {
+	return newNodeRef(Node4Kind, unsafe.Pointer(new(Node4))) //#nosec:G103
 }
 
-// newNode16 creates a new Node16 as a NodeRef.
-func (f *objFactory) newNode16() *NodeRef {
-	return &NodeRef{
-		kind: Node16Kind,
-		ref:  unsafe.Pointer(new(Node16)), //#nosec:G103
-	}
+// NewNode16 creates a new Node16 as a NodeRef.
+func (f *objFactory) NewNode16() NodeRef {
+	return newNodeRef(Node16Kind, unsafe.Pointer(new(Node16))) //#nosec:G103
 }
 
-// newNode48 creates a new Node48 as a NodeRef.
-func (f *objFactory) newNode48() *NodeRef {
-	return &NodeRef{
-		kind: Node48Kind,
-		ref:  unsafe.Pointer(new(Node48)), //#nosec:G103
-	}
+// NewNode48 creates a new Node48 as a NodeRef.
+func (f *objFactory) NewNode48() NodeRef {
+	return newNodeRef(Node48Kind, unsafe.Pointer(new(Node48))) //#nosec:G103
+}
+
+// NewNode256 creates a new Node256 as a NodeRef.
+func (f *objFactory) NewNode256() NodeRef {
+	return newNodeRef(Node256Kind, unsafe.Pointer(new(Node256))) //#nosec:G103
 }
 
-// newNode256 creates a new Node256 as a NodeRef.
-func (f *objFactory) newNode256() *NodeRef {
-	return &NodeRef{
-		kind: Node256Kind,
-		ref:  unsafe.Pointer(new(Node256)), //#nosec:G103
+// NewLeaf creates a new Leaf Node as a NodeRef.
+// It clones the key to avoid any source key mutation,
+// short keys are stored in the same allocation as the Leaf.
+func (f *objFactory) NewLeaf(key Key, value interface{}) NodeRef {
+	var leaf *Leaf
+
+	if len(key) <= inlineKeyLen {
+		inline := &inlineLeaf{}
+		inline.key = inline.buf[:len(key):len(key)]
+		leaf = &inline.Leaf
+	} else {
+		leaf = &Leaf{key: make(Key, len(key))}
 	}
+
+	copy(leaf.key, key)
+	leaf.value = value
+
+	return newNodeRef(LeafKind, unsafe.Pointer(leaf)) //#nosec:G103
 }
 
-// newLeaf creates a new Leaf Node as a NodeRef.
-// It clones the key to avoid any source key mutation.
-func (f *objFactory) newLeaf(key Key, value interface{}) *NodeRef {
-	keyClone := make(Key, len(key))
-	copy(keyClone, key)
-
-	return &NodeRef{
-		kind: LeafKind,
-		ref: unsafe.Pointer(&Leaf{ //#nosec:G103
-			key:   keyClone,
-			value: value,
-		}),
+// leafSize returns the size of the Leaf including its key, short keys are stored in the same allocation.
+func (f *objFactory) leafSize(leaf *Leaf) int {
+	if len(leaf.key) <= inlineKeyLen {
+		return int(unsafe.Sizeof(inlineLeaf{}))
 	}
+
+	return int(unsafe.Sizeof(Leaf{})) + cap(leaf.key)
 }
+
+// Release does nothing, released nodes are reclaimed by the garbage collector.
+func (f *objFactory) Release(NodeRef) {}
@@ -0,0 +1,147 @@
+package art
+
+import (
+	"unsafe"
+)
+
+const (
+	// defaultArenaChunkSize is the default maximum number of objects allocated at once per Node kind.
+	defaultArenaChunkSize = 1024
+
+	// minArenaChunkSize is the size of the first chunk, next chunks double up to the maximum size.
+	minArenaChunkSize = 8
+)
+
+// slab carves objects out of chunks and keeps the released objects for reuse.
+type slab[T any] struct {
+	chunk     []T  // unused objects of the current chunk
+	free      []*T // released objects
+	chunkSize int  // size of the last allocated chunk
+	maxSize   int  // maximum size of a chunk
+}
+
+// alloc returns a released object if any, otherwise the next object of the current chunk.
+func (s *slab[T]) alloc() *T {
+	if last := len(s.free) - 1; last >= 0 {
+		obj := s.free[last]
+		s.free[last] = nil
+		s.free = s.free[:last]
+
+		return obj
+	}
+
+	if len(s.chunk) == 0 {
+		s.chunkSize = minInt(ternary(s.chunkSize == 0, minArenaChunkSize, 2*s.chunkSize), s.maxSize)
+		s.chunk = make([]T, s.chunkSize)
+	}
+
+	obj := &s.chunk[0]
+	s.chunk = s.chunk[1:]
+
+	return obj
+}
+
+// release zeroes the object and puts it to the free list.
+func (s *slab[T]) release(obj *T) {
+	var zero T
+	*obj = zero
+
+	s.free = append(s.free, obj)
+}
+
+// arenaFactory implements NodeFactory interface,
+// nodes are carved out of large chunks and recycled when released.
+type arenaFactory struct {
+	leaves   slab[Leaf]
+	node4s   slab[Node4]
+	node16s  slab[Node16]
+	node48s  slab[Node48]
+	node256s slab[Node256]
+}
+
+// make sure that arenaFactory implements all methods of NodeFactory interface.
+var _ NodeFactory = &arenaFactory{}
+
+// NewArenaAllocator creates a NodeFactory allocating nodes in chunks of up to chunkSize objects
+// per Node kind, the nodes released by the tree are kept in free lists and reused.
+// Large trees have far fewer heap objects to be tracked by the garbage collector,
+// a chunk is reclaimed only when none of its objects is referenced.
+// If chunkSize is not positive, a default chunk size is used.
+//
+// The allocator is not safe for concurrent use, it can be shared by trees used from the same goroutine.
+// Nodes and keys returned by the tree must not be retained once they are deleted
+// from the tree, because their memory is reused.
+func NewArenaAllocator(chunkSize int) NodeFactory {
+	if chunkSize <= 0 {
+		chunkSize = defaultArenaChunkSize
+	}
+
+	return &arenaFactory{
+		leaves:   slab[Leaf]{maxSize: chunkSize},
+		node4s:   slab[Node4]{maxSize: chunkSize},
+		node16s:  slab[Node16]{maxSize: chunkSize},
+		node48s:  slab[Node48]{maxSize: chunkSize},
+		node256s: slab[Node256]{maxSize: chunkSize},
+	}
+}
+
+// NewNode4 creates a new Node4 as a NodeRef.
+func (f *arenaFactory) NewNode4() NodeRef {
+	return newNodeRef(Node4Kind, unsafe.Pointer(f.node4s.alloc())) //#nosec:G103
+}
+
+// NewNode16 creates a new Node16 as a NodeRef.
+func (f *arenaFactory) NewNode16() NodeRef {
+	return newNodeRef(Node16Kind, unsafe.Pointer(f.node16s.alloc())) //#nosec:G103
+}
+
+// NewNode48 creates a new Node48 as a NodeRef.
+func (f *arenaFactory) NewNode48() NodeRef {
+	return newNodeRef(Node48Kind, unsafe.Pointer(f.node48s.alloc())) //#nosec:G103
+}
+
+// NewNode256 creates a new Node256 as a NodeRef.
+func (f *arenaFactory) NewNode256() NodeRef {
+	return newNodeRef(Node256Kind, unsafe.Pointer(f.node256s.alloc())) //#nosec:G103
+}
+
+// NewLeaf creates a new Leaf Node as a NodeRef.
+// The key is copied into the key buffer of a released Leaf if it is large enough.
+func (f *arenaFactory) NewLeaf(key Key, value interface{}) NodeRef {
+	leaf := f.leaves.alloc()
+
+	if leaf.key == nil || cap(leaf.key) < len(key) {
+		leaf.key = make(Key, len(key))
+	} else {
+		leaf.key = leaf.key[:len(key)]
+	}
+
+	copy(leaf.key, key)
+	leaf.value = value
+
+	return newNodeRef(LeafKind, unsafe.Pointer(leaf)) //#nosec:G103
+}
+
+// Release puts the referenced Node to the free list of its kind.
+func (f *arenaFactory) Release(nr NodeRef) {
+	if nr.isNil() {
+		return
+	}
+
+	switch nr.kind() {
+	case Node4Kind:
+		f.node4s.release(nr.node4())
+	case Node16Kind:
+		f.node16s.release(nr.node16())
+	case Node48Kind:
+		f.node48s.release(nr.node48())
+	case Node256Kind:
+		f.node256s.release(nr.node256())
+	case LeafKind:
+		// keep the key buffer for the next Leaf
+		leaf := nr.Leaf()
+		key := leaf.key[:0]
+		f.leaves.release(leaf)
+		leaf.key = key
+	}
+}
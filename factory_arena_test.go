@@ -0,0 +1,115 @@
+package art
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArenaAllocator(t *testing.T) {
+	t.Parallel()
+
+	for _, file := range []string{"test/assets/words.txt", "test/assets/uuid.txt", "test/assets/hsk_words.txt"} {
+		file := file
+		t.Run(file, func(t *testing.T) {
+			t.Parallel()
+
+			data := loadTestFile(file)
+			expected := New()
+			tree := New(WithAllocator(NewArenaAllocator(0)))
+
+			for _, d := range data {
+				expected.Insert(d, d)
+				tree.Insert(d, d)
+			}
+
+			assert.True(t, Equal(expected, tree, nil))
+
+			// released nodes are reused by the next insertions
+			for i, d := range data {
+				if i%2 == 0 {
+					_, deleted := expected.Delete(d)
+					require.True(t, deleted)
+
+					_, deleted = tree.Delete(d)
+					require.True(t, deleted)
+				}
+			}
+
+			assert.True(t, Equal(expected, tree, nil))
+
+			for i, d := range data {
+				if i%4 == 0 {
+					expected.Insert(d, d)
+					tree.Insert(d, d)
+				}
+			}
+
+			assert.True(t, Equal(expected, tree, nil))
+
+			for _, d := range data {
+				expected.Delete(d)
+				tree.Delete(d)
+			}
+
+			assert.Equal(t, 0, tree.Size())
+			assert.True(t, Equal(expected, tree, nil))
+		})
+	}
+}
+
+// TestArenaAllocatorReusesNodes is not parallel, the allocations of other tests would be counted.
+func TestArenaAllocatorReusesNodes(t *testing.T) { //nolint:paralleltest
+	keys := make([]Key, 0, 1000)
+	for i := 0; i < cap(keys); i++ {
+		keys = append(keys, Key(fmt.Sprintf("key%d", i)))
+	}
+
+	tree := New(WithAllocator(NewArenaAllocator(64)))
+	fill := func() {
+		for _, k := range keys {
+			tree.Insert(k, nil)
+		}
+
+		for _, k := range keys {
+			tree.Delete(k)
+		}
+	}
+
+	fill()
+	assert.Zero(t, testing.AllocsPerRun(10, fill))
+}
+
+func TestArenaAllocatorSplitJoinClone(t *testing.T) {
+	t.Parallel()
+
+	allocator := NewArenaAllocator(16)
+	tree := New(WithAllocator(allocator))
+	data := loadTestFile("test/assets/words.txt")
+
+	for _, d := range data {
+		tree.Insert(d, d)
+	}
+
+	clone := tree.Clone()
+	left, right := tree.SplitAt(Key("monkey"))
+
+	joined, err := Join(left, right)
+	require.NoError(t, err)
+	assert.True(t, Equal(clone, joined, nil))
+
+	for _, d := range data {
+		_, deleted := joined.Delete(d)
+		assert.True(t, deleted)
+	}
+
+	assert.Equal(t, len(data), clone.Size())
+
+	for _, d := range data {
+		val, found := clone.Search(d)
+		assert.True(t, found)
+		assert.Equal(t, d, val)
+	}
+}
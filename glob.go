@@ -0,0 +1,187 @@
+package art
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// globOptions contains options for NewGlobAutomaton and Glob.
+type globOptions struct {
+	sep byte // sep is the path separator not matched by '*' and '?'
+}
+
+func createGlobOptions(opts ...GlobOption) globOptions {
+	defOpts := globOptions{
+		sep: '/',
+	}
+
+	for _, opt := range opts {
+		opt(&defOpts)
+	}
+
+	return defOpts
+}
+
+// globPattern is a glob pattern translated to a regular expression.
+type globPattern struct {
+	expr   string // expr is the equivalent regular expression
+	prefix Key    // prefix is the literal text all matching keys start with
+	suffix Key    // suffix is the literal text all matching keys end with
+}
+
+// NewGlobAutomaton compiles the glob pattern into an Automaton accepting the matching keys.
+// The pattern syntax extends the one of path.Match:
+//   - '*' matches any sequence of characters except the separator
+//   - '**' matches any sequence of characters, '**' followed by the separator matches zero or more path segments
+//   - '?' matches any single character except the separator
+//   - '[' [ '^' ] { c | lo '-' hi } ']' matches a character class
+//   - '\\' c matches the character c
+//
+// The separator is '/' unless set by WithGlobSeparator.
+// ErrBadPattern is returned if the pattern is malformed.
+func NewGlobAutomaton(pattern string, opts ...GlobOption) (Automaton, error) {
+	g, err := parseGlob(pattern, createGlobOptions(opts...))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRegexpAutomaton(g.expr)
+}
+
+// parseGlob translates the glob pattern to an equivalent regular expression
+// and extracts its literal prefix and suffix.
+func parseGlob(pattern string, opts globOptions) (globPattern, error) {
+	var (
+		sb      strings.Builder
+		g       globPattern
+		literal []byte // literal is the text following the last wildcard
+		meta    bool   // meta indicates a wildcard has been seen
+	)
+
+	sep := regexp.QuoteMeta(string(rune(opts.sep)))
+	notSep := fmt.Sprintf(`[^\x{%x}]`, opts.sep)
+
+	// wildcard ends the literal text
+	wildcard := func(expr string) {
+		if !meta {
+			g.prefix = literal
+		}
+
+		meta, literal = true, nil
+
+		sb.WriteString(expr)
+	}
+
+	sb.WriteString("(?s)")
+
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+
+		switch r {
+		case '*':
+			switch {
+			case !strings.HasPrefix(pattern[i:], "*"):
+				wildcard(notSep + "*")
+			case strings.HasPrefix(pattern[i+1:], string(rune(opts.sep))):
+				wildcard("(?:.*" + sep + ")?")
+				i += 2
+			default:
+				wildcard(".*")
+				i++
+			}
+		case '?':
+			wildcard(notSep)
+		case '[':
+			var class strings.Builder
+
+			n, err := globClass(&class, pattern[i:])
+			if err != nil {
+				return globPattern{}, err
+			}
+
+			i += n
+
+			wildcard(class.String())
+		case '\\':
+			if i >= len(pattern) {
+				return globPattern{}, ErrBadPattern
+			}
+
+			_, size = utf8.DecodeRuneInString(pattern[i:])
+			i += size
+
+			fallthrough
+		default:
+			literal = append(literal, pattern[i-size:i]...)
+			sb.WriteString(regexp.QuoteMeta(pattern[i-size : i]))
+		}
+	}
+
+	if meta {
+		g.suffix = literal
+	} else {
+		g.prefix, g.suffix = literal, literal
+	}
+
+	g.expr = sb.String()
+
+	return g, nil
+}
+
+// globClass translates the character class following '[' and returns the number of bytes consumed.
+func globClass(sb *strings.Builder, class string) (int, error) {
+	i := 0
+	sb.WriteByte('[')
+
+	if i < len(class) && class[i] == '^' {
+		sb.WriteByte('^')
+		i++
+	}
+
+	// next returns the next class character, unescaped
+	next := func() (rune, bool) {
+		if i >= len(class) || class[i] == '-' || class[i] == ']' {
+			return 0, false
+		}
+
+		if class[i] == '\\' {
+			i++
+			if i >= len(class) {
+				return 0, false
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(class[i:])
+		i += size
+
+		return r, true
+	}
+
+	for ranges := 0; ; ranges++ {
+		if i < len(class) && class[i] == ']' && ranges > 0 {
+			sb.WriteByte(']')
+
+			return i + 1, nil
+		}
+
+		lo, ok := next()
+		if !ok {
+			return 0, ErrBadPattern
+		}
+
+		hi := lo
+
+		if i < len(class) && class[i] == '-' {
+			i++
+
+			if hi, ok = next(); !ok || hi < lo {
+				return 0, ErrBadPattern
+			}
+		}
+
+		fmt.Fprintf(sb, `\x{%x}-\x{%x}`, lo, hi)
+	}
+}
@@ -0,0 +1,271 @@
+package art
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGlob(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		prefix  string
+		suffix  string
+	}{
+		{"user/*/settings", "user/", "/settings"},
+		{"*.go", "", ".go"},
+		{"src/**", "src/", ""},
+		{"literal", "literal", "literal"},
+		{"a\\*b*c", "a*b", "c"},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		g, err := parseGlob(tt.pattern, createGlobOptions())
+		require.NoError(t, err, tt.pattern)
+		assert.Equal(t, tt.prefix, string(g.prefix), tt.pattern)
+		assert.Equal(t, tt.suffix, string(g.suffix), tt.pattern)
+	}
+}
+
+func TestGlobAutomaton(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{
+		"", "a", "abc", "a/b", "a/b/c", "user/1/settings", "user/1/2/settings", "user//settings",
+		"x]", "-", "日本", "a*b", "a?b", "file.go", "file.txt",
+	}
+
+	patterns := []string{
+		"*", "a*", "a/*", "*/*", "user/*/settings", "?", "??", "a?b", "[a-c]*", "[^a]*", "[^a/]", "[\\]x]*",
+		"[\\-]", "\\*", "a\\*b", "*.go", "日?", "[日月]本",
+	}
+
+	for _, pattern := range patterns {
+		a, err := NewGlobAutomaton(pattern)
+		require.NoError(t, err, pattern)
+
+		for _, key := range keys {
+			expected, err := path.Match(pattern, key)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, automatonMatch(a, key), "pattern %q key %q", pattern, key)
+		}
+	}
+
+	for _, pattern := range []string{"[", "[a", "a\\", "[z-a]", "[]", "[]a]", "[a-]", "[-]"} {
+		_, err := NewGlobAutomaton(pattern)
+		assert.ErrorIs(t, err, ErrBadPattern, pattern)
+	}
+}
+
+func TestGlobAutomatonDoubleStar(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern  string
+		opts     []GlobOption
+		matches  []string
+		excludes []string
+	}{
+		{"a/**/b", nil, []string{"a/b", "a/x/b", "a/x/y/b"}, []string{"ab", "a/xb", "a/x/b/c"}},
+		{"**/*.go", nil, []string{"main.go", "cmd/main.go", "a/b/c.go"}, []string{"main.txt", "a/b.go/c"}},
+		{"src/**", nil, []string{"src/", "src/a", "src/a/b"}, []string{"src", "lib/a"}},
+		{"a**b", nil, []string{"ab", "a/b", "axx/yyb"}, []string{"a/c"}},
+		{"a.*.c", []GlobOption{WithGlobSeparator('.')}, []string{"a.b.c", "a..c"}, []string{"a.b.b.c", "a/b.c"}},
+		{"a.**.c", []GlobOption{WithGlobSeparator('.')}, []string{"a.c", "a.b.c", "a.b.b.c"}, []string{"a.bc"}},
+	}
+
+	for _, tt := range tests {
+		a, err := NewGlobAutomaton(tt.pattern, tt.opts...)
+		require.NoError(t, err, tt.pattern)
+
+		for _, key := range tt.matches {
+			assert.True(t, automatonMatch(a, key), "pattern %q key %q", tt.pattern, key)
+		}
+
+		for _, key := range tt.excludes {
+			assert.False(t, automatonMatch(a, key), "pattern %q key %q", tt.pattern, key)
+		}
+	}
+}
+
+func TestTreeGlob(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{
+		"cmd/art/main.go", "docs/readme.md", "main.go", "src/a.go", "src/a_test.go", "src/b/c.go", "src/b/d.txt",
+	}
+
+	tests := []struct {
+		name     string
+		pattern  string
+		expected []string
+	}{
+		{"LiteralPrefix", "src/*.go", []string{"src/a.go", "src/a_test.go"}},
+		{"DoubleStar", "src/**/*.go", []string{"src/a.go", "src/a_test.go", "src/b/c.go"}},
+		{"LeadingWildcard", "**/*.go", []string{"cmd/art/main.go", "main.go", "src/a.go", "src/a_test.go", "src/b/c.go"}},
+		{"Question", "src/?.go", []string{"src/a.go"}},
+		{"Class", "[cd]*/**", []string{"cmd/art/main.go", "docs/readme.md"}},
+		{"Literal", "main.go", []string{"main.go"}},
+		{"LiteralMissing", "src/b", nil},
+		{"NoMatch", "lib/**", nil},
+	}
+
+	for _, e := range engines {
+		e := e
+		tree := e.newTree()
+
+		for _, k := range keys {
+			tree.Insert(Key(k), k)
+		}
+
+		for _, tt := range tests {
+			tt := tt
+			t.Run(e.name+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				var matched []string
+
+				err := tree.Glob(tt.pattern, func(node NodeKV) bool {
+					matched = append(matched, string(node.Key()))
+
+					return true
+				})
+
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, matched)
+			})
+		}
+
+		t.Run(e.name+"/BadPattern", func(t *testing.T) {
+			t.Parallel()
+
+			assert.ErrorIs(t, tree.Glob("src/[a", func(NodeKV) bool { return true }), ErrBadPattern)
+		})
+	}
+}
+
+func TestTreeGlobSuffixPruning(t *testing.T) {
+	t.Parallel()
+
+	tr := newTree()
+	for i := 0; i < 100; i++ {
+		for j := 0; j < 10; j++ {
+			tr.Insert(Key(fmt.Sprintf("dir%d/file%d.go", i, j)), nil)
+		}
+	}
+
+	tr.Insert(Key("dir42/notes.txt"), nil)
+	tr.Insert(Key("dir43/notes.txt"), nil)
+	tr.Delete(Key("dir43/notes.txt")) // the suffix bits are left stale
+
+	g, err := parseGlob("**/*.txt", createGlobOptions())
+	require.NoError(t, err)
+	require.Equal(t, Key(".txt"), g.suffix)
+
+	steps := func(suffix Key) (int, []string) {
+		regexpAutomaton, err := NewRegexpAutomaton(g.expr)
+		require.NoError(t, err)
+
+		a := &countingAutomaton{Automaton: regexpAutomaton}
+
+		var matched []string
+
+		tr.matchRecursively(a, tr.root, nil, a.Start(), suffix, func(node NodeKV) bool {
+			matched = append(matched, string(node.Key()))
+
+			return true
+		})
+
+		return a.steps, matched
+	}
+
+	scanned, all := steps(nil)
+	pruned, matched := steps(g.suffix)
+
+	assert.Equal(t, []string{"dir42/notes.txt"}, all)
+	assert.Equal(t, all, matched)
+	assert.Less(t, pruned*20, scanned, "the subtrees without a key ending with 't' are skipped")
+}
+
+func TestTreeGlobRandom(t *testing.T) {
+	t.Parallel()
+
+	patterns := []string{"*.t", "**/*.tx", "**t", "*a", "a*", "?", "**/?.t", "a/**", "[ab]*/*x", "**/b.*"}
+
+	options := map[string][]TreeOption{
+		"Default":      nil,
+		"MaxPrefixLen": {WithMaxPrefixLen(1)},
+		"LeafSuffixes": {WithLeafSuffixes()},
+	}
+
+	for name, opts := range options {
+		name, opts := name, opts
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rnd := rand.New(rand.NewSource(1)) //nolint:gosec
+
+			const alphabet = "ab./tx"
+
+			for round := 0; round < 50; round++ {
+				tree := New(opts...)
+
+				keys := make([]Key, 1+rnd.Intn(200))
+				for i := range keys {
+					keys[i] = make(Key, rnd.Intn(10))
+					for j := range keys[i] {
+						keys[i][j] = alphabet[rnd.Intn(len(alphabet))]
+					}
+
+					tree.Insert(keys[i], nil)
+				}
+
+				for _, k := range keys[:rnd.Intn(len(keys))] {
+					tree.Delete(k)
+				}
+
+				if rnd.Intn(2) == 0 {
+					left, right := tree.SplitAt(keys[rnd.Intn(len(keys))])
+
+					joined, err := Join(left, right)
+					require.NoError(t, err)
+
+					tree = joined
+				}
+
+				require.NoError(t, tree.Validate())
+
+				for _, pattern := range patterns {
+					a, err := NewGlobAutomaton(pattern)
+					require.NoError(t, err)
+
+					var want, got []string
+
+					tree.ForEach(func(node NodeKV) bool {
+						if state, ok := stepAutomaton(a, a.Start(), node.Key()); ok && a.IsMatch(state) {
+							want = append(want, string(node.Key()))
+						}
+
+						return true
+					})
+
+					require.NoError(t, tree.Glob(pattern, func(node NodeKV) bool {
+						got = append(got, string(node.Key()))
+
+						return true
+					}))
+
+					require.Equal(t, want, got, "round %d, pattern %q", round, pattern)
+				}
+			}
+		})
+	}
+}
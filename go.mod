@@ -2,7 +2,10 @@ module github.com/alexisvisco/go-adaptive-radix-tree/v2
 
 go 1.18
 
-require github.com/stretchr/testify v1.9.0
+require (
+	github.com/stretchr/testify v1.9.0
+	golang.org/x/text v0.22.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
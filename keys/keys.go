@@ -0,0 +1,218 @@
+// Package keys implements order-preserving encodings of the common key types,
+// the byte order of the encoded keys matches the logical order of the values,
+// so that the prefix and range scans of the tree follow the natural order.
+//
+// The Append functions append the encoded value to a buffer and the Decode functions decode
+// a value from the start of a buffer and return the remaining bytes:
+//
+//	key := keys.AppendString(nil, "user")
+//	key = keys.AppendInt64(key, -42)
+//	tree.Insert(key, value)
+//
+//	name, rest, err := keys.DecodeString(key)
+//	id, rest, err := keys.DecodeInt64(rest)
+//
+// The encodings are self-delimiting, a value can be followed by other encoded values without changing the order.
+// Tuple combines values of different types into a single key.
+package keys
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrInvalidKey is returned when the bytes can't be decoded as the expected value.
+var ErrInvalidKey = errors.New("invalid encoded key")
+
+const (
+	signBit = 1 << 63
+
+	escapeByte     = 0x00 // escapeByte starts the escape sequences of the strings
+	escapedZero    = 0xff // escapedZero follows the escapeByte to encode a zero byte
+	terminatorByte = 0x01 // terminatorByte follows the escapeByte to end a string
+
+	timeLen = 12 // timeLen is the length of an encoded time, 8 bytes of seconds and 4 bytes of nanoseconds
+)
+
+// AppendUint64 appends the big endian encoding of the value.
+func AppendUint64(dst []byte, v uint64) []byte {
+	var buf [8]byte
+
+	binary.BigEndian.PutUint64(buf[:], v)
+
+	return append(dst, buf[:]...)
+}
+
+// DecodeUint64 decodes a value encoded by AppendUint64.
+func DecodeUint64(b []byte) (uint64, []byte, error) {
+	if len(b) < 8 {
+		return 0, b, ErrInvalidKey
+	}
+
+	return binary.BigEndian.Uint64(b), b[8:], nil
+}
+
+// AppendInt64 appends the encoding of the value, the big endian two's complement with the sign bit flipped
+// so that the negative values sort before the positive ones.
+func AppendInt64(dst []byte, v int64) []byte {
+	return AppendUint64(dst, uint64(v)^signBit)
+}
+
+// DecodeInt64 decodes a value encoded by AppendInt64.
+func DecodeInt64(b []byte) (int64, []byte, error) {
+	u, rest, err := DecodeUint64(b)
+
+	return int64(u ^ signBit), rest, err
+}
+
+// AppendFloat64 appends the encoding of the value, the IEEE 754 bits with the sign bit flipped for positive values
+// and all bits flipped for negative values. The negative zero sorts before the positive zero
+// and the NaN values sort after the positive infinity, or before the negative infinity if their sign bit is set.
+func AppendFloat64(dst []byte, v float64) []byte {
+	return AppendUint64(dst, floatBits(v))
+}
+
+// DecodeFloat64 decodes a value encoded by AppendFloat64.
+func DecodeFloat64(b []byte) (float64, []byte, error) {
+	u, rest, err := DecodeUint64(b)
+	if err != nil {
+		return 0, rest, err
+	}
+
+	return floatFromBits(u), rest, nil
+}
+
+// floatBits returns the order-preserving bits of the float.
+func floatBits(v float64) uint64 {
+	u := math.Float64bits(v)
+	if u&signBit != 0 {
+		return ^u
+	}
+
+	return u ^ signBit
+}
+
+// floatFromBits returns the float of the order-preserving bits.
+func floatFromBits(u uint64) float64 {
+	if u&signBit != 0 {
+		return math.Float64frombits(u ^ signBit)
+	}
+
+	return math.Float64frombits(^u)
+}
+
+// AppendBool appends the encoding of the value, false sorts before true.
+func AppendBool(dst []byte, v bool) []byte {
+	if v {
+		return append(dst, 1)
+	}
+
+	return append(dst, 0)
+}
+
+// DecodeBool decodes a value encoded by AppendBool.
+func DecodeBool(b []byte) (bool, []byte, error) {
+	if len(b) < 1 || b[0] > 1 {
+		return false, b, ErrInvalidKey
+	}
+
+	return b[0] == 1, b[1:], nil
+}
+
+// AppendTime appends the encoding of the time instant, the Unix seconds encoded by AppendInt64
+// followed by the big endian nanoseconds. The location and the monotonic clock reading are not encoded.
+func AppendTime(dst []byte, t time.Time) []byte {
+	dst = AppendInt64(dst, t.Unix())
+
+	var buf [4]byte
+
+	binary.BigEndian.PutUint32(buf[:], uint32(t.Nanosecond()))
+
+	return append(dst, buf[:]...)
+}
+
+// DecodeTime decodes a time encoded by AppendTime, the time is returned in UTC.
+func DecodeTime(b []byte) (time.Time, []byte, error) {
+	if len(b) < timeLen {
+		return time.Time{}, b, ErrInvalidKey
+	}
+
+	sec, rest, _ := DecodeInt64(b)
+
+	nsec := binary.BigEndian.Uint32(rest)
+	if nsec >= uint32(time.Second) {
+		return time.Time{}, b, ErrInvalidKey
+	}
+
+	return time.Unix(sec, int64(nsec)).UTC(), rest[4:], nil
+}
+
+// AppendString appends the encoding of the string, its bytes with the zero bytes escaped as 0x00 0xFF,
+// followed by the 0x00 0x01 terminator. The terminator keeps a string before its extensions
+// whatever the encoded values following it.
+func AppendString(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		if s[i] == escapeByte {
+			dst = append(dst, escapeByte, escapedZero)
+		} else {
+			dst = append(dst, s[i])
+		}
+	}
+
+	return append(dst, escapeByte, terminatorByte)
+}
+
+// AppendBytes appends the encoding of the bytes, see AppendString.
+func AppendBytes(dst []byte, b []byte) []byte {
+	for _, c := range b {
+		if c == escapeByte {
+			dst = append(dst, escapeByte, escapedZero)
+		} else {
+			dst = append(dst, c)
+		}
+	}
+
+	return append(dst, escapeByte, terminatorByte)
+}
+
+// DecodeString decodes a string encoded by AppendString.
+func DecodeString(b []byte) (string, []byte, error) {
+	v, rest, err := DecodeBytes(b)
+
+	return string(v), rest, err
+}
+
+// DecodeBytes decodes the bytes encoded by AppendBytes, the decoded bytes don't share the memory of b.
+func DecodeBytes(b []byte) ([]byte, []byte, error) {
+	var v []byte
+
+	for i := 0; i < len(b); i++ {
+		if b[i] != escapeByte {
+			v = append(v, b[i])
+
+			continue
+		}
+
+		if i+1 == len(b) {
+			break
+		}
+
+		switch b[i+1] {
+		case terminatorByte:
+			if v == nil {
+				v = []byte{}
+			}
+
+			return v, b[i+2:], nil
+		case escapedZero:
+			v = append(v, escapeByte)
+			i++
+		default:
+			return nil, b, ErrInvalidKey
+		}
+	}
+
+	return nil, b, ErrInvalidKey
+}
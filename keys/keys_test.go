@@ -0,0 +1,186 @@
+package keys
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertOrdered checks that the encodings of the ascending values are strictly ascending.
+func assertOrdered[T any](t *testing.T, values []T, encode func([]byte, T) []byte) {
+	t.Helper()
+
+	for i := 1; i < len(values); i++ {
+		prev, cur := encode(nil, values[i-1]), encode(nil, values[i])
+		assert.Equal(t, -1, bytes.Compare(prev, cur), "%v < %v", values[i-1], values[i])
+	}
+}
+
+// assertRoundTrip checks that the values are decoded back, followed by the trailing bytes.
+func assertRoundTrip[T any](t *testing.T, values []T, encode func([]byte, T) []byte,
+	decode func([]byte) (T, []byte, error),
+) {
+	t.Helper()
+
+	trailer := []byte{0x00, 0xff, 0x42}
+
+	for _, v := range values {
+		got, rest, err := decode(append(encode(nil, v), trailer...))
+		require.NoError(t, err)
+		assert.Equal(t, v, got)
+		assert.Equal(t, trailer, rest)
+	}
+}
+
+func TestUint64(t *testing.T) {
+	t.Parallel()
+
+	values := []uint64{0, 1, 2, 255, 256, 1 << 32, math.MaxInt64, math.MaxUint64}
+	assertOrdered(t, values, AppendUint64)
+	assertRoundTrip(t, values, AppendUint64, DecodeUint64)
+}
+
+func TestInt64(t *testing.T) {
+	t.Parallel()
+
+	values := []int64{math.MinInt64, -1 << 32, -256, -2, -1, 0, 1, 2, 10, 255, 1 << 40, math.MaxInt64}
+	assertOrdered(t, values, AppendInt64)
+	assertRoundTrip(t, values, AppendInt64, DecodeInt64)
+}
+
+func TestFloat64(t *testing.T) {
+	t.Parallel()
+
+	values := []float64{
+		math.Inf(-1), -math.MaxFloat64, -1e10, -1.5, -1, -math.SmallestNonzeroFloat64, math.Copysign(0, -1),
+		0, math.SmallestNonzeroFloat64, 0.5, 1, 2, 1e300, math.MaxFloat64, math.Inf(1),
+	}
+	assertOrdered(t, values, AppendFloat64)
+	assertRoundTrip(t, values, AppendFloat64, DecodeFloat64)
+
+	got, _, err := DecodeFloat64(AppendFloat64(nil, math.NaN()))
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(got))
+}
+
+func TestBool(t *testing.T) {
+	t.Parallel()
+
+	values := []bool{false, true}
+	assertOrdered(t, values, AppendBool)
+	assertRoundTrip(t, values, AppendBool, DecodeBool)
+
+	_, _, err := DecodeBool([]byte{2})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestTime(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2024, time.February, 29, 12, 30, 0, 0, time.UTC)
+	values := []time.Time{
+		time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(1677, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Unix(-1, 999999999).UTC(),
+		time.Unix(0, 0).UTC(),
+		base,
+		base.Add(time.Nanosecond),
+		base.Add(time.Second - time.Nanosecond),
+		base.Add(time.Second),
+		time.Date(3000, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	assertOrdered(t, values, AppendTime)
+	assertRoundTrip(t, values, AppendTime, DecodeTime)
+
+	// the location is not encoded
+	paris := time.FixedZone("CET", 3600)
+	got, _, err := DecodeTime(AppendTime(nil, base.In(paris)))
+	require.NoError(t, err)
+	assert.True(t, got.Equal(base))
+
+	_, _, err = DecodeTime(AppendUint64(AppendInt64(nil, 0), math.MaxUint64))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	values := []string{"", "\x00", "\x00\x00", "\x00\x01", "\x01", "a", "a\x00", "a\x00b", "a\x01", "ab", "b", "\xff"}
+	assertOrdered(t, values, AppendString)
+	assertRoundTrip(t, values, AppendString, DecodeString)
+
+	byteValues := make([][]byte, len(values))
+	for i, v := range values {
+		byteValues[i] = []byte(v)
+	}
+
+	assertOrdered(t, byteValues, AppendBytes)
+	assertRoundTrip(t, byteValues, AppendBytes, DecodeBytes)
+
+	assert.Equal(t, []byte{'a', 0x00, 0xff, 'b', 0x00, 0x01}, AppendString(nil, "a\x00b"))
+}
+
+func TestStringFollowedByValues(t *testing.T) {
+	t.Parallel()
+
+	// a string sorts before its extensions whatever the values following it
+	a := AppendUint64(AppendString(nil, "a"), math.MaxUint64)
+	b := AppendUint64(AppendString(nil, "a\x00"), 0)
+	c := AppendUint64(AppendString(nil, "ab"), 0)
+
+	assert.Equal(t, -1, bytes.Compare(a, b))
+	assert.Equal(t, -1, bytes.Compare(b, c))
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := DecodeUint64([]byte{1, 2, 3})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, _, err = DecodeInt64(nil)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, _, err = DecodeFloat64([]byte{1})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, _, err = DecodeTime(make([]byte, 11))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	for _, b := range [][]byte{nil, []byte("abc"), {'a', 0x00}, {'a', 0x00, 0x02}} {
+		_, rest, err := DecodeString(b)
+		assert.ErrorIs(t, err, ErrInvalidKey, "%q", b)
+		assert.Equal(t, b, rest)
+	}
+}
+
+func TestRandomInt64Order(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(42)) //nolint:gosec
+
+	values := make([]int64, 1000)
+	for i := range values {
+		values[i] = rnd.Int63() - rnd.Int63()
+	}
+
+	encoded := make([][]byte, len(values))
+	for i, v := range values {
+		encoded[i] = AppendInt64(nil, v)
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	sort.Slice(encoded, func(i, j int) bool { return bytes.Compare(encoded[i], encoded[j]) < 0 })
+
+	for i, b := range encoded {
+		v, _, err := DecodeInt64(b)
+		require.NoError(t, err)
+		assert.Equal(t, values[i], v)
+	}
+}
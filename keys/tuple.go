@@ -0,0 +1,280 @@
+package keys
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+
+	art "github.com/alexisvisco/go-adaptive-radix-tree/v2"
+)
+
+// The type codes prefixing the tuple elements, the elements of different types sort by their type code.
+// The codes follow the FoundationDB tuple layer, the time code is specific to this package.
+const (
+	nilCode    = 0x00
+	bytesCode  = 0x01
+	stringCode = 0x02
+	nestedCode = 0x05
+	intZero    = 0x14 // intZero encodes the zero integer, the other integers are shifted by their byte length
+	floatCode  = 0x21
+	falseCode  = 0x26
+	trueCode   = 0x27
+	timeCode   = 0x30
+)
+
+// Tuple is a sequence of values encoded as a single key, the tuples sort element by element
+// and a tuple sorts before its extensions. The supported element types are:
+//   - nil
+//   - bool
+//   - int, int8, int16, int32, int64, uint, uint8, uint16, uint32 and uint64,
+//     encoded on as few bytes as possible, the integers of all sizes and signs sort together
+//   - float32 and float64, encoded as float64
+//   - string and []byte, the strings sort after the byte slices
+//   - time.Time
+//   - Tuple, nested as a single element
+//
+// The types sort in the order nil, []byte, string, Tuple, integers, floats, bool and time.Time.
+type Tuple []interface{}
+
+// Pack returns the encoded key of the tuple.
+// An error is returned if an element has an unsupported type.
+func (t Tuple) Pack() (art.Key, error) {
+	return t.AppendTo(nil)
+}
+
+// AppendTo appends the encoding of the tuple to dst.
+func (t Tuple) AppendTo(dst []byte) ([]byte, error) {
+	return t.appendTo(dst, false)
+}
+
+// appendTo appends the elements, the nil elements of a nested tuple are escaped from its terminator.
+func (t Tuple) appendTo(dst []byte, nested bool) ([]byte, error) {
+	var err error
+
+	for i, elem := range t {
+		if dst, err = appendElement(dst, elem, nested); err != nil {
+			return nil, fmt.Errorf("tuple element %d: %w", i, err)
+		}
+	}
+
+	return dst, nil
+}
+
+// appendElement appends the type code and the encoding of the element.
+//
+//nolint:cyclop
+func appendElement(dst []byte, elem interface{}, nested bool) ([]byte, error) {
+	switch v := elem.(type) {
+	case nil:
+		if nested {
+			return append(dst, nilCode, escapedZero), nil
+		}
+
+		return append(dst, nilCode), nil
+	case bool:
+		if v {
+			return append(dst, trueCode), nil
+		}
+
+		return append(dst, falseCode), nil
+	case int:
+		return appendInt(dst, int64(v)), nil
+	case int8:
+		return appendInt(dst, int64(v)), nil
+	case int16:
+		return appendInt(dst, int64(v)), nil
+	case int32:
+		return appendInt(dst, int64(v)), nil
+	case int64:
+		return appendInt(dst, v), nil
+	case uint:
+		return appendUint(dst, uint64(v)), nil
+	case uint8:
+		return appendUint(dst, uint64(v)), nil
+	case uint16:
+		return appendUint(dst, uint64(v)), nil
+	case uint32:
+		return appendUint(dst, uint64(v)), nil
+	case uint64:
+		return appendUint(dst, v), nil
+	case float32:
+		return AppendFloat64(append(dst, floatCode), float64(v)), nil
+	case float64:
+		return AppendFloat64(append(dst, floatCode), v), nil
+	case string:
+		return AppendString(append(dst, stringCode), v), nil
+	case []byte:
+		return AppendBytes(append(dst, bytesCode), v), nil
+	case time.Time:
+		return AppendTime(append(dst, timeCode), v), nil
+	case Tuple:
+		dst, err := v.appendTo(append(dst, nestedCode), true)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(dst, nilCode), nil
+	}
+
+	return nil, fmt.Errorf("%w: unsupported type %T", ErrInvalidKey, elem)
+}
+
+// appendInt appends the integer with its type code.
+// A negative integer is encoded as the one's complement of its absolute value,
+// on as many bytes as the absolute value, which are subtracted from the zero code.
+func appendInt(dst []byte, v int64) []byte {
+	if v >= 0 {
+		return appendUint(dst, uint64(v))
+	}
+
+	abs := uint64(-v) // the absolute value of math.MinInt64 wraps around to 1 << 63
+	n := byteLen(abs)
+
+	return appendBigEndian(append(dst, byte(intZero-n)), ^abs, n)
+}
+
+// appendUint appends the unsigned integer with its type code,
+// the number of bytes of the integer is added to the zero code.
+func appendUint(dst []byte, v uint64) []byte {
+	n := byteLen(v)
+
+	return appendBigEndian(append(dst, byte(intZero+n)), v, n)
+}
+
+// byteLen returns the number of bytes needed by the integer.
+func byteLen(v uint64) int {
+	return (bits.Len64(v) + 7) / 8
+}
+
+// appendBigEndian appends the n low bytes of the integer in the big endian order.
+func appendBigEndian(dst []byte, v uint64, n int) []byte {
+	for i := n - 1; i >= 0; i-- {
+		dst = append(dst, byte(v>>(8*i)))
+	}
+
+	return dst
+}
+
+// Unpack decodes a tuple encoded by Tuple.Pack.
+// The integers are decoded as int64, or uint64 if they are greater than math.MaxInt64,
+// the floats as float64 and the times in UTC.
+func Unpack(b []byte) (Tuple, error) {
+	t, rest, err := unpack(b, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, ErrInvalidKey
+	}
+
+	return t, nil
+}
+
+// unpack decodes the elements up to the end of the bytes, or up to the terminator of a nested tuple.
+func unpack(b []byte, nested bool) (Tuple, []byte, error) {
+	t := Tuple{}
+
+	for len(b) > 0 {
+		if nested && b[0] == nilCode {
+			if len(b) == 1 || b[1] != escapedZero {
+				return t, b[1:], nil
+			}
+
+			t = append(t, nil)
+			b = b[2:]
+
+			continue
+		}
+
+		elem, rest, err := decodeElement(b)
+		if err != nil {
+			return nil, b, fmt.Errorf("tuple element %d: %w", len(t), err)
+		}
+
+		t = append(t, elem)
+		b = rest
+	}
+
+	if nested {
+		return nil, b, ErrInvalidKey
+	}
+
+	return t, b, nil
+}
+
+// decodeElement decodes the element starting with its type code.
+//
+//nolint:cyclop
+func decodeElement(b []byte) (interface{}, []byte, error) {
+	code, b := b[0], b[1:]
+
+	switch {
+	case code == nilCode:
+		return nil, b, nil
+	case code == falseCode:
+		return false, b, nil
+	case code == trueCode:
+		return true, b, nil
+	case code == bytesCode:
+		return decoded(DecodeBytes(b))
+	case code == stringCode:
+		return decoded(DecodeString(b))
+	case code == floatCode:
+		return decoded(DecodeFloat64(b))
+	case code == timeCode:
+		return decoded(DecodeTime(b))
+	case code == nestedCode:
+		return decoded(unpack(b, true))
+	case code >= intZero-8 && code <= intZero+8:
+		return decodeInt(b, int(code)-intZero)
+	}
+
+	return nil, b, fmt.Errorf("%w: unknown type code %#x", ErrInvalidKey, code)
+}
+
+// decodeInt decodes the integer of the given signed byte length.
+func decodeInt(b []byte, n int) (interface{}, []byte, error) {
+	size := n
+	if n < 0 {
+		size = -n
+	}
+
+	if len(b) < size {
+		return nil, b, ErrInvalidKey
+	}
+
+	var v uint64
+	for _, c := range b[:size] {
+		v = v<<8 | uint64(c)
+	}
+
+	if n >= 0 {
+		if v > math.MaxInt64 {
+			return v, b[size:], nil
+		}
+
+		return int64(v), b[size:], nil
+	}
+
+	abs := ^v
+	if size < 8 {
+		abs &= 1<<(8*size) - 1
+	}
+
+	if abs > 1<<63 {
+		return nil, b, ErrInvalidKey
+	}
+
+	return -int64(abs), b[size:], nil
+}
+
+// decoded returns the result of a decoder as an element, the element is nil on error.
+func decoded[T any](v T, rest []byte, err error) (interface{}, []byte, error) {
+	if err != nil {
+		return nil, rest, err
+	}
+
+	return v, rest, nil
+}
@@ -0,0 +1,163 @@
+package keys
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	art "github.com/alexisvisco/go-adaptive-radix-tree/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustPack(t *testing.T, tuple Tuple) art.Key {
+	t.Helper()
+
+	key, err := tuple.Pack()
+	require.NoError(t, err)
+
+	return key
+}
+
+func TestTupleOrder(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	// ascending tuples
+	tuples := []Tuple{
+		{},
+		{nil},
+		{nil, nil},
+		{[]byte("a")},
+		{""},
+		{"a"},
+		{"a", nil},
+		{"a", Tuple{}},
+		{"a", Tuple{nil}},
+		{"a", Tuple{nil, 1}},
+		{"a", Tuple{1}},
+		{"a", math.MinInt64},
+		{"a", -1 << 40},
+		{"a", -256},
+		{"a", -255},
+		{"a", -1},
+		{"a", 0},
+		{"a", uint8(1)},
+		{"a", 255},
+		{"a", int16(256)},
+		{"a", math.MaxInt64},
+		{"a", uint64(math.MaxUint64)},
+		{"a", -1.5},
+		{"a", float32(0)},
+		{"a", 1.5},
+		{"a", false},
+		{"a", true},
+		{"a", day},
+		{"a", day.Add(time.Nanosecond)},
+		{"a\x00"},
+		{"ab"},
+		{"b", 1, "x"},
+		{"b", 2},
+		{"b", 10},
+		{"b", 10, "a"},
+	}
+
+	for i := 1; i < len(tuples); i++ {
+		prev, cur := mustPack(t, tuples[i-1]), mustPack(t, tuples[i])
+		assert.Equal(t, -1, bytes.Compare(prev, cur), "%v < %v", tuples[i-1], tuples[i])
+	}
+}
+
+func TestTupleRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, time.March, 1, 12, 0, 0, 42, time.UTC)
+
+	tests := []struct {
+		name  string
+		tuple Tuple
+		want  Tuple
+	}{
+		{"empty", Tuple{}, Tuple{}},
+		{"nil", Tuple{nil}, Tuple{nil}},
+		{"strings", Tuple{"", "a\x00b", []byte{0, 1}}, Tuple{"", "a\x00b", []byte{0, 1}}},
+		{"bools", Tuple{true, false}, Tuple{true, false}},
+		{"signed", Tuple{0, -1, int8(-128), int32(1 << 20), math.MinInt64, math.MaxInt64},
+			Tuple{int64(0), int64(-1), int64(-128), int64(1 << 20), int64(math.MinInt64), int64(math.MaxInt64)}},
+		{"unsigned", Tuple{uint(7), uint64(math.MaxUint64)}, Tuple{int64(7), uint64(math.MaxUint64)}},
+		{"floats", Tuple{float32(0.5), -2.25, math.Inf(1)}, Tuple{0.5, -2.25, math.Inf(1)}},
+		{"time", Tuple{day}, Tuple{day}},
+		{"nested", Tuple{"a", Tuple{nil, "b", Tuple{1, nil}}, nil},
+			Tuple{"a", Tuple{nil, "b", Tuple{int64(1), nil}}, nil}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Unpack(mustPack(t, tt.tuple))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTupleAppendTo(t *testing.T) {
+	t.Parallel()
+
+	prefix := mustPack(t, Tuple{"users"})
+
+	key, err := Tuple{42}.AppendTo(prefix)
+	require.NoError(t, err)
+	assert.Equal(t, mustPack(t, Tuple{"users", 42}), art.Key(key))
+}
+
+func TestTupleErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := Tuple{"a", struct{}{}}.Pack()
+	require.ErrorIs(t, err, ErrInvalidKey)
+	assert.Contains(t, err.Error(), "tuple element 1")
+
+	_, err = Tuple{Tuple{complex(1, 1)}}.Pack()
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	for _, b := range [][]byte{
+		{0xff},                                // unknown type code
+		{intZero + 2, 1},                      // truncated integer
+		{intZero - 8, 0, 0, 0, 0, 0, 0, 0, 0}, // out of range negative integer
+		{stringCode, 'a'},                     // unterminated string
+		{nestedCode, nilCode, escapedZero},    // unterminated nested tuple
+		{floatCode, 0},                        // truncated float
+	} {
+		_, err := Unpack(b)
+		assert.ErrorIs(t, err, ErrInvalidKey, "%x", b)
+	}
+}
+
+func TestTupleRangeScan(t *testing.T) {
+	t.Parallel()
+
+	tree := art.New()
+
+	for _, id := range []int{10, 2, -5, 1000, 33} {
+		tree.Insert(mustPack(t, Tuple{"order", id}), id)
+		tree.Insert(mustPack(t, Tuple{"user", id}), id)
+	}
+
+	var ids []interface{}
+
+	tree.ForEachPrefix(mustPack(t, Tuple{"order"}), func(node art.NodeKV) bool {
+		tuple, err := Unpack(node.Key())
+		require.NoError(t, err)
+
+		ids = append(ids, tuple[1])
+
+		return true
+	})
+
+	assert.Equal(t, []interface{}{int64(-5), int64(2), int64(10), int64(33), int64(1000)}, ids)
+}
@@ -1,5 +1,7 @@
 package art
 
+import "unsafe"
+
 // prefix used in the Node to store the key prefix.
 // it is used to improve Leaf key comparison performance.
 type prefix [maxPrefixLen]byte
@@ -7,17 +9,58 @@ type prefix [maxPrefixLen]byte
 // Node is the base struct for all Node types.
 // it contains the common fields for all nodeX types.
 type Node struct {
-	prefix      prefix // prefix of the Node
+	prefix      prefix // prefix of the Node, the first maxPrefixLen bytes
 	prefixLen   uint16 // length of the prefix
 	childrenLen uint16 // number of children in the Node4, Node16, Node48, Node256
+	storedLen   uint16 // number of prefix bytes stored in the Node
+	prefixExt   *byte  // heap-allocated stored prefix if it doesn't fit into the prefix array
+	suffixes    uint64 // suffixes has the suffixBit of the last byte of every key in the subtree set, at least
 }
 
-// replaceRef is used to replace Node in-place by updating the reference.
-func replaceRef(oldNode **NodeRef, newNode *NodeRef) {
-	*oldNode = newNode
+// suffixBit returns the bit standing for the last byte of the key in the Node suffixes,
+// the bytes sharing their 6 low bits share the bit. The empty key has none.
+// The suffixes are not cleared on deletion, they are a superset of the bits of the keys,
+// so the Glob patterns ending with a literal skip the subtrees without a key ending with its last byte.
+func suffixBit(key []byte) uint64 {
+	if len(key) == 0 {
+		return 0
+	}
+
+	return 1 << (key[len(key)-1] & 63)
+}
+
+// storedPrefix returns the prefix bytes stored in the Node.
+// It may be shorter than prefixLen if the prefix is truncated.
+func (n *Node) storedPrefix() []byte {
+	if n.prefixExt != nil {
+		return unsafe.Slice(n.prefixExt, n.storedLen) //#nosec:G103
+	}
+
+	return n.prefix[:n.storedLen]
 }
 
-// replaceNode is used to replace Node in-place by updating the Node.
-func replaceNode(oldNode *NodeRef, newNode *NodeRef) {
-	*oldNode = *newNode
+// hasFullPrefix returns true if the whole prefix is stored in the Node,
+// so the prefix can be verified without loading a Leaf.
+func (n *Node) hasFullPrefix() bool {
+	return n.storedLen == n.prefixLen
+}
+
+// setStoredPrefix stores the first storedLen bytes of the prefix.
+// The bytes which don't fit into the prefix array are stored in a new heap-allocated buffer,
+// the buffer is never modified afterwards, so it can be shared between Node copies.
+func (n *Node) setStoredPrefix(src []byte, storedLen int) {
+	n.storedLen = uint16(storedLen) //#nosec:G115
+	copy(n.prefix[:], src[:minInt(storedLen, maxPrefixLen)])
+
+	n.prefixExt = nil
+	if storedLen > maxPrefixLen {
+		ext := make([]byte, storedLen)
+		copy(ext, src)
+		n.prefixExt = &ext[0]
+	}
+}
+
+// replaceRef is used to replace Node in-place by updating the reference.
+func replaceRef(oldNode *NodeRef, newNode NodeRef) {
+	*oldNode = newNode
 }
@@ -29,7 +29,7 @@ func (p *present16) shiftLeft(idx int) {
 // Node16 represents a Node with 16 children.
 type Node16 struct {
 	Node
-	children [node16Max + 1]*NodeRef // +1 is for the zero byte child
+	children [node16Max + 1]NodeRef // +1 is for the zero byte child
 	keys     [node16Max]byte
 	present  present16
 }
@@ -50,11 +50,11 @@ func (n *Node16) index(kc keyChar) int {
 		return node16Max
 	}
 
-	return findIndex(n.keys[:n.childrenLen], kc.ch)
+	return findIndex16(&n.keys, int(n.childrenLen), kc.ch)
 }
 
 // childAt returns the child at the given index.
-func (n *Node16) childAt(idx int) **NodeRef {
+func (n *Node16) childAt(idx int) *NodeRef {
 	if idx < 0 || idx >= len(n.children) {
 		return &nodeNotFound
 	}
@@ -62,7 +62,7 @@ func (n *Node16) childAt(idx int) **NodeRef {
 	return &n.children[idx]
 }
 
-func (n *Node16) allChildren() []*NodeRef {
+func (n *Node16) allChildren() []NodeRef {
 	return n.children[:]
 }
 
@@ -72,8 +72,8 @@ func (n *Node16) hasCapacityForChild() bool {
 }
 
 // grow converts the Node to a Node48.
-func (n *Node16) grow() *NodeRef {
-	an48 := factory.newNode48()
+func (n *Node16) grow(opts *treeOptions) NodeRef {
+	an48 := opts.factory.NewNode48()
 	n48 := an48.node48()
 
 	copyNode(&n48.Node, &n.Node)
@@ -98,8 +98,8 @@ func (n *Node16) isReadyToShrink() bool {
 }
 
 // shrink converts the Node16 into the Node4.
-func (n *Node16) shrink() *NodeRef {
-	an4 := factory.newNode4()
+func (n *Node16) shrink(opts *treeOptions) NodeRef {
+	an4 := opts.factory.NewNode4()
 	n4 := an4.node4()
 
 	copyNode(&n4.Node, &n.Node)
@@ -113,7 +113,7 @@ func (n *Node16) shrink() *NodeRef {
 		}
 
 		n4.children[i] = n.children[i]
-		if n4.children[i] != nil {
+		if !n4.children[i].isNil() {
 			n4.childrenLen++
 		}
 	}
@@ -126,7 +126,7 @@ func (n *Node16) hasChild(idx int) bool {
 }
 
 // addChild adds a new child to the Node.
-func (n *Node16) addChild(kc keyChar, child *NodeRef) {
+func (n *Node16) addChild(kc keyChar, child NodeRef) {
 	pos := n.findInsertPos(kc)
 	n.makeRoom(pos)
 	n.insertChildAt(pos, kc.ch, child)
@@ -163,7 +163,7 @@ func (n *Node16) makeRoom(pos int) {
 }
 
 // insertChildAt inserts a new child at the given position.
-func (n *Node16) insertChildAt(pos int, ch byte, child *NodeRef) {
+func (n *Node16) insertChildAt(pos int, ch byte, child NodeRef) {
 	if pos < 0 || pos > node16Max {
 		return
 	}
@@ -182,7 +182,7 @@ func (n *Node16) insertChildAt(pos int, ch byte, child *NodeRef) {
 func (n *Node16) deleteChild(kc keyChar) int {
 	if kc.invalid {
 		// clear the zero byte child reference
-		n.children[node16Max] = nil
+		n.children[node16Max] = NodeRef{}
 	} else if idx := n.index(kc); idx >= 0 {
 		n.deleteChildAt(idx)
 		n.clearLastElement()
@@ -215,5 +215,5 @@ func (n *Node16) clearLastElement() {
 	lastIdx := int(n.childrenLen)
 	n.keys[lastIdx] = 0
 	n.present.clearAt(lastIdx)
-	n.children[lastIdx] = nil
+	n.children[lastIdx] = NodeRef{}
 }
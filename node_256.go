@@ -3,7 +3,7 @@ package art
 // NodeKV with 256 children.
 type Node256 struct {
 	Node
-	children [node256Max + 1]*NodeRef // +1 is for the zero byte child
+	children [node256Max + 1]NodeRef // +1 is for the zero byte child
 }
 
 // minimum returns the minimum Leaf Node.
@@ -26,7 +26,7 @@ func (n *Node256) index(kc keyChar) int {
 }
 
 // childAt returns the child at the given index.
-func (n *Node256) childAt(idx int) **NodeRef {
+func (n *Node256) childAt(idx int) *NodeRef {
 	if idx < 0 || idx >= len(n.children) {
 		return &nodeNotFound
 	}
@@ -34,12 +34,12 @@ func (n *Node256) childAt(idx int) **NodeRef {
 	return &n.children[idx]
 }
 
-func (n *Node256) allChildren() []*NodeRef {
+func (n *Node256) allChildren() []NodeRef {
 	return n.children[:]
 }
 
 // addChild adds a new child to the Node.
-func (n *Node256) addChild(kc keyChar, child *NodeRef) {
+func (n *Node256) addChild(kc keyChar, child NodeRef) {
 	if kc.invalid {
 		// handle zero byte in the key
 		n.children[node256Max] = child
@@ -57,8 +57,8 @@ func (n *Node256) hasCapacityForChild() bool {
 
 // grow for Node256 always returns nil,
 // because Node256 has the maximum capacity.
-func (n *Node256) grow() *NodeRef {
-	return nil
+func (n *Node256) grow(_ *treeOptions) NodeRef {
+	return NodeRef{}
 }
 
 // isReadyToShrink returns true if the Node can be shrunk.
@@ -67,15 +67,15 @@ func (n *Node256) isReadyToShrink() bool {
 }
 
 // shrink shrinks the Node to a smaller type.
-func (n *Node256) shrink() *NodeRef {
-	an48 := factory.newNode48()
+func (n *Node256) shrink(opts *treeOptions) NodeRef {
+	an48 := opts.factory.NewNode48()
 	n48 := an48.node48()
 
 	copyNode(&n48.Node, &n.Node)
-	n48.children[node48Min] = n.children[node256Max] // copy zero byte child
+	n48.children[node48Max] = n.children[node256Max] // copy zero byte child
 
 	for numChildren, i := 0, 0; i < node256Max; i++ {
-		if n.children[i] == nil {
+		if n.children[i].isNil() {
 			continue // skip if the child is nil
 		}
 		// copy elements from n256 to n48 to the last position
@@ -91,10 +91,10 @@ func (n *Node256) shrink() *NodeRef {
 func (n *Node256) deleteChild(kc keyChar) int {
 	if kc.invalid {
 		// clear the zero byte child reference
-		n.children[node256Max] = nil
-	} else if idx := n.index(kc); n.children[idx] != nil {
+		n.children[node256Max] = NodeRef{}
+	} else if idx := n.index(kc); !n.children[idx].isNil() {
 		// clear the child at the given index
-		n.children[idx] = nil
+		n.children[idx] = NodeRef{}
 		n.childrenLen--
 	}
 
@@ -3,9 +3,9 @@ package art
 // Node4 represents a Node with 4 children.
 type Node4 struct {
 	Node
-	children [node4Max + 1]*NodeRef // pointers to the child nodes, +1 is for the zero byte child
-	keys     [node4Max]byte         // keys for the children
-	present  [node4Max]byte         // present bits for the keys
+	children [node4Max + 1]NodeRef // pointers to the child nodes, +1 is for the zero byte child
+	keys     [node4Max]byte        // keys for the children
+	present  [node4Max]byte        // present bits for the keys
 }
 
 // minimum returns the minimum Leaf Node.
@@ -24,11 +24,11 @@ func (n *Node4) index(kc keyChar) int {
 		return node4Max
 	}
 
-	return findIndex(n.keys[:n.childrenLen], kc.ch)
+	return findIndex4(&n.keys, int(n.childrenLen), kc.ch)
 }
 
 // childAt returns the child at the given index.
-func (n *Node4) childAt(idx int) **NodeRef {
+func (n *Node4) childAt(idx int) *NodeRef {
 	if idx < 0 || idx >= len(n.children) {
 		return &nodeNotFound
 	}
@@ -36,7 +36,7 @@ func (n *Node4) childAt(idx int) **NodeRef {
 	return &n.children[idx]
 }
 
-func (n *Node4) allChildren() []*NodeRef {
+func (n *Node4) allChildren() []NodeRef {
 	return n.children[:]
 }
 
@@ -46,8 +46,8 @@ func (n *Node4) hasCapacityForChild() bool {
 }
 
 // grow converts the Node4 into the Node16.
-func (n *Node4) grow() *NodeRef {
-	an16 := factory.newNode16()
+func (n *Node4) grow(opts *treeOptions) NodeRef {
+	an16 := opts.factory.NewNode16()
 	n16 := an16.node16()
 
 	copyNode(&n16.Node, &n.Node)
@@ -73,7 +73,7 @@ func (n *Node4) isReadyToShrink() bool {
 	// For all higher nodes(16/48/256) we simply copy zero Node to a smaller Node
 	// see deleteChild() and shrink() methods for implementation details
 	numChildren := n.childrenLen
-	if n.children[node4Max] != nil {
+	if !n.children[node4Max].isNil() {
 		numChildren++
 	}
 
@@ -81,52 +81,50 @@ func (n *Node4) isReadyToShrink() bool {
 }
 
 // shrink converts the Node4 into the Leaf Node or a Node with fewer children.
-func (n *Node4) shrink() *NodeRef {
+func (n *Node4) shrink(opts *treeOptions) NodeRef {
 	// Select the non-nil child Node
-	var nonNilChild *NodeRef
-	if n.children[0] != nil {
+	var nonNilChild NodeRef
+
+	kc := keyChar{ch: n.keys[0]}
+	if !n.children[0].isNil() {
 		nonNilChild = n.children[0]
 	} else {
-		nonNilChild = n.children[node4Max]
+		nonNilChild, kc = n.children[node4Max], keyCharInvalid
 	}
 
-	// if the only child is a LeafKind Node, return it
+	// if the only child is a LeafKind Node, return it,
+	// a Leaf storing the key suffix takes the path of the Node
 	if nonNilChild.isLeaf() {
-		return nonNilChild
+		return extendLeaf(nonNilChild, n.storedPrefix(), kc, opts)
 	}
 
 	// update the prefix of the child Node
-	n.adjustPrefix(nonNilChild.node())
+	n.adjustPrefix(nonNilChild.node(), opts.maxPrefixLen)
 
 	return nonNilChild
 }
 
 // adjustPrefix handles prefix adjustments for a non-LeafKind child.
-func (n *Node4) adjustPrefix(childNode *Node) {
-	nodePrefLen := int(n.prefixLen)
-
+// The child prefix becomes the current Node prefix, the key of the child and the child prefix.
+// Only the bytes known from the stored prefixes are kept, at most maxStored bytes.
+func (n *Node4) adjustPrefix(childNode *Node, maxStored int) {
 	// at this point, the Node has only one child
 	// copy the key part of the current Node as prefix
-	if nodePrefLen < maxPrefixLen {
-		n.prefix[nodePrefLen] = n.keys[0]
-		nodePrefLen++
-	}
+	var buf [2*maxPrefixLen + 1]byte
+
+	newPrefix := append(buf[:0], n.storedPrefix()...)
 
-	// copy the part of child prefix that fits into the current Node
-	if nodePrefLen < maxPrefixLen {
-		childPrefLen := minInt(int(childNode.prefixLen), maxPrefixLen-nodePrefLen)
-		copy(n.prefix[nodePrefLen:], childNode.prefix[:childPrefLen])
-		nodePrefLen += childPrefLen
+	if n.hasFullPrefix() {
+		newPrefix = append(newPrefix, n.keys[0])
+		newPrefix = append(newPrefix, childNode.storedPrefix()...)
 	}
 
-	// copy the part of the current Node prefix that fits into the child Node
-	prefixLen := minInt(nodePrefLen, maxPrefixLen)
-	copy(childNode.prefix[:], n.prefix[:prefixLen])
 	childNode.prefixLen += n.prefixLen + 1
+	childNode.setStoredPrefix(newPrefix, minInt(len(newPrefix), minInt(int(childNode.prefixLen), maxStored)))
 }
 
 // addChild adds a new child to the Node.
-func (n *Node4) addChild(kc keyChar, child *NodeRef) {
+func (n *Node4) addChild(kc keyChar, child NodeRef) {
 	pos := n.findInsertPos(kc)
 	n.makeRoom(pos)
 	n.insertChildAt(pos, kc.ch, child)
@@ -162,7 +160,7 @@ func (n *Node4) makeRoom(pos int) {
 }
 
 // insertChildAt inserts the child at the given position.
-func (n *Node4) insertChildAt(pos int, ch byte, child *NodeRef) {
+func (n *Node4) insertChildAt(pos int, ch byte, child NodeRef) {
 	if pos == node4Max {
 		n.children[pos] = child
 	} else {
@@ -177,7 +175,7 @@ func (n *Node4) insertChildAt(pos int, ch byte, child *NodeRef) {
 func (n *Node4) deleteChild(kc keyChar) int {
 	if kc.invalid {
 		// clear the zero byte child reference
-		n.children[node4Max] = nil
+		n.children[node4Max] = NodeRef{}
 	} else if idx := n.index(kc); idx >= 0 {
 		n.deleteChildAt(idx)
 		n.clearLastElement()
@@ -190,7 +188,7 @@ func (n *Node4) deleteChild(kc keyChar) int {
 	// For all higher nodes(16/48/256) we simply copy null Node to a smaller Node
 	// see deleteChild() and shrink() methods for implementation details
 	numChildren := int(n.childrenLen)
-	if n.children[node4Max] != nil {
+	if !n.children[node4Max].isNil() {
 		numChildren++
 	}
 
@@ -214,5 +212,5 @@ func (n *Node4) clearLastElement() {
 	lastIdx := int(n.childrenLen)
 	n.keys[lastIdx] = 0
 	n.present[lastIdx] = 0
-	n.children[lastIdx] = nil
+	n.children[lastIdx] = NodeRef{}
 }
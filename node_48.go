@@ -24,14 +24,14 @@ func (p *present48) clearAt(ch int) {
 
 type Node48 struct {
 	Node
-	children [node48Max + 1]*NodeRef // +1 is for the zero byte child
+	children [node48Max + 1]NodeRef // +1 is for the zero byte child
 	keys     [node256Max]byte
 	present  present48 // need 256 bits for keys
 }
 
 // minimum returns the minimum Leaf Node.
 func (n *Node48) minimum() *Leaf {
-	if n.children[node48Max] != nil {
+	if !n.children[node48Max].isNil() {
 		return n.children[node48Max].minimum()
 	}
 
@@ -40,7 +40,7 @@ func (n *Node48) minimum() *Leaf {
 		idx++
 	}
 
-	if n.children[n.keys[idx]] != nil {
+	if !n.children[n.keys[idx]].isNil() {
 		return n.children[n.keys[idx]].minimum()
 	}
 
@@ -65,7 +65,7 @@ func (n *Node48) index(kc keyChar) int {
 
 	if n.hasChild(int(kc.ch)) {
 		idx := int(n.keys[kc.ch])
-		if idx < node48Max && n.children[idx] != nil {
+		if idx < node48Max && !n.children[idx].isNil() {
 			return idx
 		}
 	}
@@ -74,7 +74,7 @@ func (n *Node48) index(kc keyChar) int {
 }
 
 // childAt returns the child at the given index.
-func (n *Node48) childAt(idx int) **NodeRef {
+func (n *Node48) childAt(idx int) *NodeRef {
 	if idx < 0 || idx >= len(n.children) {
 		return &nodeNotFound
 	}
@@ -82,7 +82,7 @@ func (n *Node48) childAt(idx int) **NodeRef {
 	return &n.children[idx]
 }
 
-func (n *Node48) allChildren() []*NodeRef {
+func (n *Node48) allChildren() []NodeRef {
 	return n.children[:]
 }
 
@@ -92,8 +92,8 @@ func (n *Node48) hasCapacityForChild() bool {
 }
 
 // grow converts the Node to a Node256.
-func (n *Node48) grow() *NodeRef {
-	an256 := factory.newNode256()
+func (n *Node48) grow(opts *treeOptions) NodeRef {
+	an256 := opts.factory.NewNode256()
 	n256 := an256.node256()
 
 	copyNode(&n256.Node, &n.Node)
@@ -114,8 +114,8 @@ func (n *Node48) isReadyToShrink() bool {
 }
 
 // shrink converts the Node to a Node16.
-func (n *Node48) shrink() *NodeRef {
-	an16 := factory.newNode16()
+func (n *Node48) shrink(opts *treeOptions) NodeRef {
+	an16 := opts.factory.NewNode16()
 	n16 := an16.node16()
 
 	copyNode(&n16.Node, &n.Node)
@@ -128,7 +128,7 @@ func (n *Node48) shrink() *NodeRef {
 		}
 
 		child := n.children[idx]
-		if child == nil {
+		if child.isNil() {
 			continue // skip if the child is nil
 		}
 
@@ -146,7 +146,7 @@ func (n *Node48) hasChild(idx int) bool {
 }
 
 // addChild adds a new child to the Node.
-func (n *Node48) addChild(kc keyChar, child *NodeRef) {
+func (n *Node48) addChild(kc keyChar, child NodeRef) {
 	pos := n.findInsertPos(kc)
 	n.insertChildAt(pos, kc.ch, child)
 }
@@ -158,7 +158,7 @@ func (n *Node48) findInsertPos(kc keyChar) int {
 	}
 
 	var i int
-	for i < node48Max && n.children[i] != nil {
+	for i < node48Max && !n.children[i].isNil() {
 		i++
 	}
 
@@ -166,7 +166,7 @@ func (n *Node48) findInsertPos(kc keyChar) int {
 }
 
 // insertChildAt inserts a child at the given position.
-func (n *Node48) insertChildAt(pos int, ch byte, child *NodeRef) {
+func (n *Node48) insertChildAt(pos int, ch byte, child NodeRef) {
 	if pos == node48Max {
 		// insert the child at the zero byte child reference
 		n.children[node48Max] = child
@@ -183,12 +183,12 @@ func (n *Node48) insertChildAt(pos int, ch byte, child *NodeRef) {
 func (n *Node48) deleteChild(kc keyChar) int {
 	if kc.invalid {
 		// clear the zero byte child reference
-		n.children[node48Max] = nil
-	} else if idx := n.index(kc); idx >= 0 && n.children[idx] != nil {
+		n.children[node48Max] = NodeRef{}
+	} else if idx := n.index(kc); idx >= 0 && !n.children[idx].isNil() {
 		// clear the child at the given index
 		n.keys[kc.ch] = 0
 		n.present.clearAt(int(kc.ch))
-		n.children[idx] = nil
+		n.children[idx] = NodeRef{}
 		n.childrenLen--
 	}
 
@@ -8,6 +8,17 @@ type Leaf struct {
 	value interface{}
 }
 
+// inlineKeyLen is the maximum length of the keys stored in the same allocation as their Leaf.
+// The inlineLeaf size is 64 bytes, the same size class as a Leaf with a separate key.
+const inlineKeyLen = 24
+
+// inlineLeaf is a Leaf with a short key stored in the same allocation,
+// the Leaf key references the buf array.
+type inlineLeaf struct {
+	Leaf
+	buf [inlineKeyLen]byte
+}
+
 // Match returns true if the Leaf Node's key matches the given key.
 func (l *Leaf) Match(key Key) bool {
 	return len(l.key) == len(key) && bytes.Equal(l.key, key)
@@ -8,17 +8,43 @@ import (
 // that indicates that the index is not found.
 const indexNotFound = -1
 
-// nodeNotFound is a special Node pointer
+// nodeNotFound is a special Node reference
 // that indicates that the Node is not found
 // for different internal tree operations.
-var nodeNotFound *NodeRef //nolint:gochecknoglobals
+var nodeNotFound NodeRef //nolint:gochecknoglobals
+
+const (
+	kindBits = 3               // number of low pointer bits used to store the Node kind
+	kindMask = 1<<kindBits - 1 // mask to extract the Node kind from the pointer
+)
 
 // NodeRef stores all available tree nodes LeafKind and nodeX types
-// as a ref to *unsafe* pointer.
-// The kind field is used to determine the type of the Node.
+// as a tagged *unsafe* pointer, the Node kind is stored in its low bits.
+// All nodes are at least 8-byte aligned, so the tagged pointer still points into the Node
+// and it is held directly in the children arrays without any extra allocation.
+// The zero NodeRef is the nil reference.
 type NodeRef struct {
-	ref  unsafe.Pointer
-	kind Kind
+	ptr unsafe.Pointer
+}
+
+// newNodeRef creates a reference to the Node of the given kind.
+func newNodeRef(kind Kind, ptr unsafe.Pointer) NodeRef {
+	return NodeRef{ptr: unsafe.Add(ptr, int(kind))} //#nosec:G103
+}
+
+// kind returns the kind of the referenced Node.
+func (nr NodeRef) kind() Kind {
+	return Kind(uintptr(nr.ptr) & kindMask)
+}
+
+// pointer returns the untagged pointer to the Node.
+func (nr NodeRef) pointer() unsafe.Pointer {
+	return unsafe.Add(nr.ptr, -int(nr.kind())) //#nosec:G103
+}
+
+// isNil returns true if the NodeRef doesn't reference any Node.
+func (nr NodeRef) isNil() bool {
+	return nr.ptr == nil
 }
 
 type nodeLeafer interface {
@@ -28,20 +54,20 @@ type nodeLeafer interface {
 
 type nodeSizeManager interface {
 	hasCapacityForChild() bool
-	grow() *NodeRef
+	grow(opts *treeOptions) NodeRef
 
 	isReadyToShrink() bool
-	shrink() *NodeRef
+	shrink(opts *treeOptions) NodeRef
 }
 
 type nodeOperations interface {
-	addChild(kc keyChar, child *NodeRef)
+	addChild(kc keyChar, child NodeRef)
 	deleteChild(kc keyChar) int
 }
 
 type nodeChildren interface {
-	childAt(idx int) **NodeRef
-	allChildren() []*NodeRef
+	childAt(idx int) *NodeRef
+	allChildren() []NodeRef
 }
 
 type nodeKeyIndexer interface {
@@ -62,12 +88,12 @@ type noder interface {
 
 // toNode converts the NodeRef to specific Node type.
 // the idea is to avoid type assertion in the code in multiple places.
-func toNode(nr *NodeRef) noder {
-	if nr == nil {
+func toNode(nr NodeRef) noder {
+	if nr.isNil() {
 		return noopNoder
 	}
 
-	switch nr.kind { //nolint:exhaustive
+	switch nr.kind() { //nolint:exhaustive
 	case Node4Kind:
 		return nr.node4()
 	case Node16Kind:
@@ -84,17 +110,17 @@ func toNode(nr *NodeRef) noder {
 // noop is a no-op noder implementation.
 type noop struct{}
 
-func (*noop) minimum() *Leaf             { return nil }
-func (*noop) maximum() *Leaf             { return nil }
-func (*noop) index(keyChar) int          { return indexNotFound }
-func (*noop) childAt(int) **NodeRef      { return &nodeNotFound }
-func (*noop) allChildren() []*NodeRef    { return nil }
-func (*noop) hasCapacityForChild() bool  { return true }
-func (*noop) grow() *NodeRef             { return nil }
-func (*noop) isReadyToShrink() bool      { return false }
-func (*noop) shrink() *NodeRef           { return nil }
-func (*noop) addChild(keyChar, *NodeRef) {}
-func (*noop) deleteChild(keyChar) int    { return 0 }
+func (*noop) minimum() *Leaf              { return nil }
+func (*noop) maximum() *Leaf              { return nil }
+func (*noop) index(keyChar) int           { return indexNotFound }
+func (*noop) childAt(int) *NodeRef        { return &nodeNotFound }
+func (*noop) allChildren() []NodeRef      { return nil }
+func (*noop) hasCapacityForChild() bool   { return true }
+func (*noop) grow(*treeOptions) NodeRef   { return NodeRef{} }
+func (*noop) isReadyToShrink() bool       { return false }
+func (*noop) shrink(*treeOptions) NodeRef { return NodeRef{} }
+func (*noop) addChild(keyChar, NodeRef)   {}
+func (*noop) deleteChild(keyChar) int     { return 0 }
 
 // noopNoder is the default Noder implementation.
 var noopNoder noder = &noop{} //nolint:gochecknoglobals
@@ -106,16 +132,16 @@ var _ noder = (*Node48)(nil)
 var _ noder = (*Node256)(nil)
 
 // assert that NodeRef implements public NodeKV interface.
-var _ NodeKV = (*NodeRef)(nil)
+var _ NodeKV = NodeRef{}
 
 // Kind returns the Node kind.
-func (nr *NodeRef) Kind() Kind {
-	return nr.kind
+func (nr NodeRef) Kind() Kind {
+	return nr.kind()
 }
 
 // Key returns the Node key for LeafKind nodes.
 // for nodeX types, it returns nil.
-func (nr *NodeRef) Key() Key {
+func (nr NodeRef) Key() Key {
 	if nr.isLeaf() {
 		return nr.Leaf().key
 	}
@@ -125,7 +151,7 @@ func (nr *NodeRef) Key() Key {
 
 // Value returns the Node value for LeafKind nodes.
 // for nodeX types, it returns nil.
-func (nr *NodeRef) Value() Value {
+func (nr NodeRef) Value() Value {
 	if nr.isLeaf() {
 		return nr.Leaf().value
 	}
@@ -134,24 +160,23 @@ func (nr *NodeRef) Value() Value {
 }
 
 // isLeaf returns true if the Node is a Leaf Node.
-func (nr *NodeRef) isLeaf() bool {
-	return nr.kind == LeafKind
+func (nr NodeRef) isLeaf() bool {
+	return nr.kind() == LeafKind
 }
 
 // setPrefix sets the Node prefix with the new prefix and prefix length.
-func (nr *NodeRef) setPrefix(newPrefix []byte, prefixLen int) {
+// At most maxStored bytes of the prefix are stored in the Node.
+func (nr NodeRef) setPrefix(newPrefix []byte, prefixLen int, maxStored int) {
 	n := nr.node()
 
 	n.prefixLen = uint16(prefixLen) //#nosec:G115
-	for i := 0; i < minInt(prefixLen, maxPrefixLen); i++ {
-		n.prefix[i] = newPrefix[i]
-	}
+	n.setStoredPrefix(newPrefix, minInt(prefixLen, maxStored))
 }
 
 // minimum returns itself if the Node is a Leaf Node.
 // otherwise it returns the minimum Leaf Node under the current Node.
-func (nr *NodeRef) minimum() *Leaf {
-	if nr.kind == LeafKind {
+func (nr NodeRef) minimum() *Leaf {
+	if nr.isLeaf() {
 		return nr.Leaf()
 	}
 
@@ -160,8 +185,8 @@ func (nr *NodeRef) minimum() *Leaf {
 
 // maximum returns itself if the Node is a Leaf Node.
 // otherwise it returns the maximum Leaf Node under the current Node.
-func (nr *NodeRef) maximum() *Leaf {
-	if nr.kind == LeafKind {
+func (nr NodeRef) maximum() *Leaf {
+	if nr.isLeaf() {
 		return nr.Leaf()
 	}
 
@@ -169,71 +194,181 @@ func (nr *NodeRef) maximum() *Leaf {
 }
 
 // findChildByKey returns the child Node reference for the given key.
-func (nr *NodeRef) findChildByKey(key Key, keyOffset int) **NodeRef {
+func (nr NodeRef) findChildByKey(key Key, keyOffset int) *NodeRef {
 	n := toNode(nr)
 	idx := n.index(key.charAt(keyOffset))
 
 	return n.childAt(idx)
 }
 
+// fullPrefix returns the complete compressed path of the Node starting at the given depth.
+// For nodeX types it is the whole Node prefix, the bytes which are not stored
+// are restored from the minimum Leaf. For LeafKind nodes it is the rest of the key.
+func (nr NodeRef) fullPrefix(depth int) []byte {
+	if nr.isLeaf() {
+		return nr.Leaf().key[depth:]
+	}
+
+	n := nr.node()
+	if n.hasFullPrefix() {
+		return n.storedPrefix()
+	}
+
+	return nr.minimum().key[depth : depth+int(n.prefixLen)]
+}
+
+// childRef is a child Node reference along with the key character it is stored under.
+type childRef struct {
+	kc  keyChar
+	ref NodeRef
+}
+
+// childRefs returns all children of the Node in ascending key order.
+// The zero byte child, if any, goes first.
+func (nr NodeRef) childRefs() []childRef {
+	if nr.isNil() || nr.isLeaf() {
+		return nil
+	}
+
+	refs := make([]childRef, 0, nr.node().childrenLen+1)
+	appendRef := func(kc keyChar, child NodeRef) {
+		if !child.isNil() {
+			refs = append(refs, childRef{kc: kc, ref: child})
+		}
+	}
+
+	switch nr.kind() { //nolint:exhaustive
+	case Node4Kind:
+		n := nr.node4()
+		appendRef(keyCharInvalid, n.children[node4Max])
+		for i := 0; i < int(n.childrenLen); i++ {
+			appendRef(keyChar{ch: n.keys[i]}, n.children[i])
+		}
+	case Node16Kind:
+		n := nr.node16()
+		appendRef(keyCharInvalid, n.children[node16Max])
+		for i := 0; i < int(n.childrenLen); i++ {
+			appendRef(keyChar{ch: n.keys[i]}, n.children[i])
+		}
+	case Node48Kind:
+		n := nr.node48()
+		appendRef(keyCharInvalid, n.children[node48Max])
+		for ch := 0; ch < node256Max; ch++ {
+			if n.hasChild(ch) {
+				appendRef(keyChar{ch: byte(ch)}, n.children[n.keys[ch]])
+			}
+		}
+	case Node256Kind:
+		n := nr.node256()
+		appendRef(keyCharInvalid, n.children[node256Max])
+		for ch := 0; ch < node256Max; ch++ {
+			appendRef(keyChar{ch: byte(ch)}, n.children[ch])
+		}
+	}
+
+	return refs
+}
+
 // nodeX/LeafKind casts the NodeRef to the specific nodeX/LeafKind type.
-func (nr *NodeRef) node() *Node       { return (*Node)(nr.ref) }    // Node casts NodeRef to Node.
-func (nr *NodeRef) node4() *Node4     { return (*Node4)(nr.ref) }   // Node4 casts NodeRef to Node4.
-func (nr *NodeRef) node16() *Node16   { return (*Node16)(nr.ref) }  // Node16 casts NodeRef to Node16.
-func (nr *NodeRef) node48() *Node48   { return (*Node48)(nr.ref) }  // Node48 casts NodeRef to Node48.
-func (nr *NodeRef) node256() *Node256 { return (*Node256)(nr.ref) } // Node256 casts NodeRef to Node256.
-func (nr *NodeRef) Leaf() *Leaf       { return (*Leaf)(nr.ref) }    // Leaf casts NodeRef to Leaf.
+func (nr NodeRef) node() *Node       { return (*Node)(nr.pointer()) }    // Node casts NodeRef to Node.
+func (nr NodeRef) node4() *Node4     { return (*Node4)(nr.pointer()) }   // Node4 casts NodeRef to Node4.
+func (nr NodeRef) node16() *Node16   { return (*Node16)(nr.pointer()) }  // Node16 casts NodeRef to Node16.
+func (nr NodeRef) node48() *Node48   { return (*Node48)(nr.pointer()) }  // Node48 casts NodeRef to Node48.
+func (nr NodeRef) node256() *Node256 { return (*Node256)(nr.pointer()) } // Node256 casts NodeRef to Node256.
+func (nr NodeRef) Leaf() *Leaf       { return (*Leaf)(nr.ptr) }          // Leaf casts NodeRef to Leaf.
 
 // addChild adds a new child Node to the current Node.
-// If the Node is full, it grows to the next Node type.
-func (nr *NodeRef) addChild(kc keyChar, child *NodeRef) {
-	n := toNode(nr)
+// If the Node is full, it grows to the next Node type
+// and the NodeRef is updated in place to reference the new Node.
+func (nr *NodeRef) addChild(kc keyChar, child NodeRef, opts *treeOptions) {
+	opts.untrack(*nr)
+
+	nr.node().suffixes |= child.suffixes(kc, opts.leafSuffixes)
+
+	n := toNode(*nr)
 
 	if n.hasCapacityForChild() {
 		n.addChild(kc, child)
 	} else {
-		bigNode := n.grow()         // grow to the next Node type
-		bigNode.addChild(kc, child) // recursively add the child to the new Node
-		replaceNode(nr, bigNode)    // replace the current Node with the new Node
+		bigNode := n.grow(opts)             // grow to the next Node type
+		toNode(bigNode).addChild(kc, child) // add the child to the new Node
+		nr.replaceAndRelease(bigNode, opts)
 	}
+
+	opts.track(*nr)
+}
+
+// suffixes returns the suffix bits of the keys of the subtree stored under the key character, see suffixBit.
+// The last byte of a key whose Leaf stores an empty suffix under the zero byte child is a byte of the path,
+// all the bits are set since it is not known here.
+func (nr NodeRef) suffixes(kc keyChar, leafSuffixes bool) uint64 {
+	if !nr.isLeaf() {
+		return nr.node().suffixes
+	}
+
+	switch key := nr.Leaf().key; {
+	case len(key) > 0 || !leafSuffixes:
+		return suffixBit(key)
+	case !kc.invalid:
+		return suffixBit([]byte{kc.ch})
+	}
+
+	return ^uint64(0)
 }
 
 // deleteChild deletes the child Node from the current Node.
-// If the Node can shrink after, it shrinks to the previous Node type.
-func (nr *NodeRef) deleteChild(kc keyChar) bool {
+// If the Node can shrink after, it shrinks to the previous Node type
+// and the NodeRef is updated in place to reference the new Node.
+func (nr *NodeRef) deleteChild(kc keyChar, opts *treeOptions) bool {
+	opts.untrack(*nr)
+
 	shrank := false
-	n := toNode(nr)
+	n := toNode(*nr)
 	n.deleteChild(kc)
 
 	if n.isReadyToShrink() {
+		// a Node4 is replaced by its only child, which takes its prefix
+		if nr.kind() == Node4Kind {
+			n4 := nr.node4()
+			opts.untrack(ternary(n4.children[0].isNil(), n4.children[node4Max], n4.children[0]))
+		}
+
 		shrank = true
-		smallNode := n.shrink()    // shrink to the previous Node type
-		replaceNode(nr, smallNode) // replace the current Node with the shrank Node
+		smallNode := n.shrink(opts) // shrink to the previous Node type
+		nr.replaceAndRelease(smallNode, opts)
 	}
 
+	opts.track(*nr)
+
 	return shrank
 }
 
+// replaceAndRelease replaces the current Node with the new Node and releases the replaced Node.
+func (nr *NodeRef) replaceAndRelease(newNR NodeRef, opts *treeOptions) {
+	oldNR := *nr
+	replaceRef(nr, newNR)
+
+	opts.factory.Release(oldNR)
+}
+
 // match finds the first mismatched index between
 // the Node's prefix and the specified key prefix.
 // This approach efficiently identifies the mismatch by
 // leveraging the Node's existing prefix data.
-func (nr *NodeRef) match(key Key, keyOffset int) int /* 1st mismatch index*/ {
+func (nr NodeRef) match(key Key, keyOffset int) int /* 1st mismatch index*/ {
 	// calc the remaining key length from offset
 	keyRemaining := len(key) - keyOffset
 	if keyRemaining < 0 {
 		return 0
 	}
 
-	n := nr.node()
-
-	// the maximum length we can check against the Node's prefix
-	maxPrefixLen := minInt(int(n.prefixLen), maxPrefixLen)
-	limit := minInt(maxPrefixLen, keyRemaining)
+	// the maximum length we can check against the Node's stored prefix
+	storedPrefix := nr.node().storedPrefix()
+	limit := minInt(len(storedPrefix), keyRemaining)
 
 	// compare the key against the Node's prefix
 	for i := 0; i < limit; i++ {
-		if n.prefix[i] != key[keyOffset+i] {
+		if storedPrefix[i] != key[keyOffset+i] {
 			return i
 		}
 	}
@@ -243,10 +378,13 @@ func (nr *NodeRef) match(key Key, keyOffset int) int /* 1st mismatch index*/ {
 
 // matchDeep returns the first index where the key mismatches,
 // starting with the Node's prefix(see match) and continuing with the minimum Leaf's key.
+// The Leaf is loaded only if the stored prefix matches and it is truncated.
 // It returns the mismatch index or matches up to the key's end.
-func (nr *NodeRef) matchDeep(key Key, keyOffset int) int /* mismatch index*/ {
+func (nr NodeRef) matchDeep(key Key, keyOffset int) int /* mismatch index*/ {
 	mismatchIdx := nr.match(key, keyOffset)
-	if mismatchIdx < maxPrefixLen {
+
+	n := nr.node()
+	if mismatchIdx < int(n.storedLen) || n.hasFullPrefix() {
 		return mismatchIdx
 	}
 
@@ -2,10 +2,14 @@ package art
 
 import (
 	"testing"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// testOpts are the default tree options used to grow and shrink nodes in tests.
+var testOpts = createTreeOptions() //nolint:gochecknoglobals
+
 // Test basic properties and behavior of each Node kind.
 func TestNodeKindProperties(t *testing.T) {
 	t.Parallel()
@@ -13,13 +17,13 @@ func TestNodeKindProperties(t *testing.T) {
 	// Define a Table of NodeKV Types to Test
 	nodeTests := []struct {
 		name string
-		node *NodeRef
+		node NodeRef
 		kind Kind
 	}{
-		{"Node4Kind Test", factory.newNode4(), Node4Kind},
-		{"Node16Kind Test", factory.newNode16(), Node16Kind},
-		{"Node48Kind Test", factory.newNode48(), Node48Kind},
-		{"Node256Kind Test", factory.newNode256(), Node256Kind},
+		{"Node4Kind Test", factory.NewNode4(), Node4Kind},
+		{"Node16Kind Test", factory.NewNode16(), Node16Kind},
+		{"Node48Kind Test", factory.NewNode48(), Node48Kind},
+		{"Node256Kind Test", factory.NewNode256(), Node256Kind},
 	}
 
 	// Run NodeKV Kind Tests
@@ -28,7 +32,7 @@ func TestNodeKindProperties(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			assert.NotNil(t, tt.node)
-			assert.Equal(t, tt.kind, tt.node.kind)
+			assert.Equal(t, tt.kind, tt.node.kind())
 		})
 	}
 
@@ -36,10 +40,10 @@ func TestNodeKindProperties(t *testing.T) {
 	t.Run("LeafKind NodeKV Test", func(t *testing.T) {
 		t.Parallel()
 
-		leaf := factory.newLeaf(Key("key"), "value")
+		leaf := factory.NewLeaf(Key("key"), "value")
 		assert.NotNil(t, leaf)
-		assert.Equal(t, LeafKind, leaf.kind)
-		assert.Equal(t, "LeafKind", leaf.kind.String())
+		assert.Equal(t, LeafKind, leaf.kind())
+		assert.Equal(t, "LeafKind", leaf.kind().String())
 		assert.Equal(t, Key("key"), leaf.Key())
 
 		val, ok := leaf.Value().(string)
@@ -51,7 +55,7 @@ func TestNodeKindProperties(t *testing.T) {
 func TestUnknownNode(t *testing.T) {
 	t.Parallel()
 
-	unknownNode := &NodeRef{kind: Kind(0xFF)}
+	unknownNode := newNodeRef(Kind(kindMask), unsafe.Pointer(&Node{})) //#nosec:G103
 	assert.Nil(t, unknownNode.maximum())
 	assert.Nil(t, unknownNode.minimum())
 }
@@ -59,22 +63,22 @@ func TestUnknownNode(t *testing.T) {
 func TestLeafFunctionality(t *testing.T) {
 	t.Parallel()
 
-	leaf := factory.newLeaf([]byte("key"), "value")
+	leaf := factory.NewLeaf([]byte("key"), "value")
 	assert.NotNil(t, leaf)
-	assert.Equal(t, LeafKind, leaf.kind)
+	assert.Equal(t, LeafKind, leaf.kind())
 
 	assert.False(t, leaf.Leaf().Match(Key("unknown-key")))
 
 	// Ensure we cannot shrink/grow LeafKind Node
-	assert.Nil(t, toNode(leaf).shrink())
-	assert.Nil(t, toNode(leaf).grow())
+	assert.True(t, toNode(leaf).shrink(&testOpts).isNil())
+	assert.True(t, toNode(leaf).grow(&testOpts).isNil())
 }
 
 // Test matching behavior of LeafKind nodes.
 func TestLeafMatchBehavior(t *testing.T) {
 	t.Parallel()
 
-	leaf := factory.newLeaf(Key("key"), "value")
+	leaf := factory.NewLeaf(Key("key"), "value")
 
 	assert.False(t, leaf.Leaf().Match(Key("unknown-key")))
 	assert.False(t, leaf.Leaf().Match(nil))
@@ -89,28 +93,57 @@ func TestLeafMatchBehavior(t *testing.T) {
 func TestNodePrefixSetting(t *testing.T) {
 	t.Parallel()
 
-	n4 := factory.newNode4()
+	n4 := factory.NewNode4()
 	nn := n4.node()
 
 	key := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 
-	n4.setPrefix(key, 2)
+	n4.setPrefix(key, 2, maxPrefixLen)
 	assert.Equal(t, 2, int(nn.prefixLen))
 	assert.Equal(t, byte(1), nn.prefix[0])
 	assert.Equal(t, byte(2), nn.prefix[1])
 
-	n4.setPrefix(key, maxPrefixLen)
+	n4.setPrefix(key, maxPrefixLen, maxPrefixLen)
 	assert.Equal(t, maxPrefixLen, int(nn.prefixLen))
 	assert.Equal(t, []byte{1, 2, 3, 4}, nn.prefix[:4])
 }
 
+// Check the setting of prefixes longer than the prefix array.
+func TestNodeLongPrefixSetting(t *testing.T) {
+	t.Parallel()
+
+	n4 := factory.NewNode4()
+	nn := n4.node()
+
+	key := []byte("abcdefghijklmnopqrstuvwxyz")
+
+	n4.setPrefix(key, 20, maxPrefixLen)
+	assert.Equal(t, 20, int(nn.prefixLen))
+	assert.Equal(t, key[:maxPrefixLen], nn.storedPrefix())
+	assert.False(t, nn.hasFullPrefix())
+
+	n4.setPrefix(key, 20, 64)
+	assert.Equal(t, 20, int(nn.prefixLen))
+	assert.Equal(t, key[:20], nn.storedPrefix())
+	assert.Equal(t, key[:maxPrefixLen], nn.prefix[:])
+	assert.True(t, nn.hasFullPrefix())
+
+	assert.Equal(t, 20, n4.match(key, 0))
+	assert.Equal(t, 19, n4.match(key[:19], 0))
+	assert.Equal(t, 15, n4.match(append(key[:15:15], '!'), 0))
+
+	n4.setPrefix(key, 3, 64)
+	assert.Equal(t, key[:3], nn.storedPrefix())
+	assert.Nil(t, nn.prefixExt)
+}
+
 // Test the matching of nodes with keys.
 func TestNodeMatchKeyBehavior(t *testing.T) {
 	t.Parallel()
 
 	key := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
-	n16 := factory.newNode16()
-	n16.setPrefix([]byte{1, 2, 3, 4, 5, 66, 77, 88, 99}, 5)
+	n16 := factory.NewNode16()
+	n16.setPrefix([]byte{1, 2, 3, 4, 5, 66, 77, 88, 99}, 5, maxPrefixLen)
 
 	assert.Equal(t, 5, n16.match(key, 0))
 	assert.Equal(t, 0, n16.match(key, 1))
@@ -152,13 +185,13 @@ func TestNodeAddChildAndFindChild(t *testing.T) {
 
 	nodeKinds := []struct {
 		name        string
-		node        *NodeRef
+		node        NodeRef
 		maxChildren int
 	}{
-		{"Node4Kind", factory.newNode4(), node4Max},
-		{"Node16Kind", factory.newNode16(), node16Max},
-		{"Node48Kind", factory.newNode48(), node48Max},
-		{"Node256Kind", factory.newNode256(), node256Max},
+		{"Node4Kind", factory.NewNode4(), node4Max},
+		{"Node16Kind", factory.NewNode16(), node16Max},
+		{"Node48Kind", factory.NewNode48(), node48Max},
+		{"Node256Kind", factory.NewNode256(), node256Max},
 	}
 
 	for _, n := range nodeKinds {
@@ -167,13 +200,13 @@ func TestNodeAddChildAndFindChild(t *testing.T) {
 			t.Parallel()
 
 			for i := 0; i < n.maxChildren; i++ {
-				leaf := factory.newLeaf(Key{byte(i)}, i)
-				n.node.addChild(keyChar{ch: byte(i)}, leaf)
+				leaf := factory.NewLeaf(Key{byte(i)}, i)
+				n.node.addChild(keyChar{ch: byte(i)}, leaf, &testOpts)
 			}
 
 			for i := 0; i < n.maxChildren; i++ {
 				leaf := n.node.findChildByKey(Key{byte(i)}, 0)
-				assert.NotNil(t, *leaf, "child should not be nil for key %d", i)
+				assert.False(t, leaf.isNil(), "child should not be nil for key %d", i)
 				val, ok := (*leaf).Leaf().value.(int)
 				assert.True(t, ok, "value should be of type int")
 				assert.Equal(t, i, val, "value should be %d", i)
@@ -186,17 +219,17 @@ func TestNodeAddChildAndFindChild(t *testing.T) {
 func TestNodeIndex(t *testing.T) {
 	t.Parallel()
 
-	nodes := []*NodeRef{
-		factory.newNode4(),
-		factory.newNode16(),
-		factory.newNode48(),
-		factory.newNode256(),
+	nodes := []NodeRef{
+		factory.NewNode4(),
+		factory.NewNode16(),
+		factory.NewNode48(),
+		factory.NewNode256(),
 	}
 
 	for _, n := range nodes {
 		maxChildren := 0
 
-		switch n.kind {
+		switch n.kind() {
 		case Node4Kind:
 			maxChildren = node4Max
 		case Node16Kind:
@@ -210,8 +243,8 @@ func TestNodeIndex(t *testing.T) {
 		}
 
 		for i := 0; i < maxChildren; i++ {
-			leaf := factory.newLeaf(Key{byte(i)}, i)
-			n.addChild(keyChar{ch: byte(i)}, leaf)
+			leaf := factory.NewLeaf(Key{byte(i)}, i)
+			n.addChild(keyChar{ch: byte(i)}, leaf, &testOpts)
 		}
 
 		for i := 0; i < maxChildren; i++ {
@@ -225,24 +258,24 @@ func TestNodesMinimumMaximum(t *testing.T) {
 	t.Parallel()
 
 	nodes := []struct {
-		node  *NodeRef
+		node  NodeRef
 		count int
 	}{
-		{factory.newNode4(), 3},
-		{factory.newNode16(), 15},
-		{factory.newNode48(), 47},
-		{factory.newNode256(), 255},
+		{factory.NewNode4(), 3},
+		{factory.NewNode16(), 15},
+		{factory.NewNode48(), 47},
+		{factory.NewNode256(), 255},
 	}
 
 	for _, n := range nodes {
 		n := n
-		t.Run(n.node.kind.String(), func(t *testing.T) {
+		t.Run(n.node.kind().String(), func(t *testing.T) {
 			t.Parallel()
 
 			for j := 1; j <= n.count; j++ {
 				kc := keyChar{ch: byte(j)}
-				leaf := factory.newLeaf([]byte{byte(j)}, byte(j))
-				n.node.addChild(kc, leaf)
+				leaf := factory.NewLeaf([]byte{byte(j)}, byte(j))
+				n.node.addChild(kc, leaf, &testOpts)
 			}
 
 			minLeaf := n.node.minimum()
@@ -258,10 +291,10 @@ func TestNodesMinimumMaximum(t *testing.T) {
 func TestNode4AddChildAndFindChild(t *testing.T) {
 	t.Parallel()
 
-	parent := factory.newNode4()
-	child := factory.newNode4()
+	parent := factory.NewNode4()
+	child := factory.NewNode4()
 	k := Key{1}
-	parent.addChild(keyChar{ch: k[0]}, child)
+	parent.addChild(keyChar{ch: k[0]}, child, &testOpts)
 
 	assert.Equal(t, 1, int(parent.node().childrenLen))
 	assert.Equal(t, child, *parent.findChildByKey(k, 0))
@@ -271,12 +304,12 @@ func TestNode4AddChildAndFindChild(t *testing.T) {
 func TestNode4AddChildTwicePreserveSorted(t *testing.T) {
 	t.Parallel()
 
-	parent := factory.newNode4()
-	child1 := factory.newNode4()
-	child2 := factory.newNode4()
+	parent := factory.NewNode4()
+	child1 := factory.NewNode4()
+	child2 := factory.NewNode4()
 
-	parent.addChild(keyChar{ch: 2}, child1)
-	parent.addChild(keyChar{ch: 1}, child2)
+	parent.addChild(keyChar{ch: 2}, child1, &testOpts)
+	parent.addChild(keyChar{ch: 1}, child2, &testOpts)
 
 	assert.Equal(t, 2, int(parent.node().childrenLen))
 	assert.Equal(t, byte(1), parent.node4().keys[0])
@@ -287,9 +320,9 @@ func TestNode4AddChildTwicePreserveSorted(t *testing.T) {
 func TestNode4AddChild4PreserveSorted(t *testing.T) {
 	t.Parallel()
 
-	parent := factory.newNode4()
+	parent := factory.NewNode4()
 	for i := 4; i > 0; i-- {
-		parent.addChild(keyChar{ch: byte(i)}, factory.newNode4())
+		parent.addChild(keyChar{ch: byte(i)}, factory.NewNode4(), &testOpts)
 	}
 
 	assert.Equal(t, 4, int(parent.node().childrenLen))
@@ -300,9 +333,9 @@ func TestNode4AddChild4PreserveSorted(t *testing.T) {
 func TestNode16AddChild16PreserveSorted(t *testing.T) {
 	t.Parallel()
 
-	parent := factory.newNode16()
+	parent := factory.NewNode16()
 	for i := 16; i > 0; i-- {
-		parent.addChild(keyChar{ch: byte(i)}, factory.newNode16())
+		parent.addChild(keyChar{ch: byte(i)}, factory.NewNode16(), &testOpts)
 	}
 
 	assert.Equal(t, 16, int(parent.node().childrenLen))
@@ -318,12 +351,12 @@ func TestNodeGrow(t *testing.T) {
 
 	nodeKinds := []struct {
 		name     string
-		node     *NodeRef
+		node     NodeRef
 		expected Kind
 	}{
-		{"Node4Kind", factory.newNode4(), Node16Kind},
-		{"Node16Kind", factory.newNode16(), Node48Kind},
-		{"Node48Kind", factory.newNode48(), Node256Kind},
+		{"Node4Kind", factory.NewNode4(), Node16Kind},
+		{"Node16Kind", factory.NewNode16(), Node48Kind},
+		{"Node48Kind", factory.NewNode48(), Node256Kind},
 	}
 
 	for _, tt := range nodeKinds {
@@ -331,8 +364,8 @@ func TestNodeGrow(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			newNode := toNode(tt.node).grow()
-			assert.Equal(t, tt.expected, newNode.kind)
+			newNode := toNode(tt.node).grow(&testOpts)
+			assert.Equal(t, tt.expected, newNode.kind())
 		})
 	}
 }
@@ -343,14 +376,14 @@ func TestNodeShrink(t *testing.T) {
 
 	nodeKinds := []struct {
 		name        string
-		node        *NodeRef
+		node        NodeRef
 		expected    Kind
 		minChildren int
 	}{
-		{"Node256Kind", factory.newNode256(), Node48Kind, node256Min},
-		{"Node48Kind", factory.newNode48(), Node16Kind, node48Min},
-		{"Node16Kind", factory.newNode16(), Node4Kind, node16Min},
-		{"Node4Kind", factory.newNode4(), LeafKind, node4Min},
+		{"Node256Kind", factory.NewNode256(), Node48Kind, node256Min},
+		{"Node48Kind", factory.NewNode48(), Node16Kind, node48Min},
+		{"Node16Kind", factory.NewNode16(), Node4Kind, node16Min},
+		{"Node4Kind", factory.NewNode4(), LeafKind, node4Min},
 	}
 
 	for _, tt := range nodeKinds {
@@ -359,15 +392,15 @@ func TestNodeShrink(t *testing.T) {
 			t.Parallel()
 
 			for j := 0; j < tt.minChildren; j++ {
-				if tt.node.kind != Node4Kind {
-					tt.node.addChild(keyChar{ch: byte(j)}, factory.newNode4())
+				if tt.node.kind() != Node4Kind {
+					tt.node.addChild(keyChar{ch: byte(j)}, factory.NewNode4(), &testOpts)
 				} else {
-					tt.node.addChild(keyChar{ch: byte(j)}, factory.newLeaf(Key{byte(j)}, "value"))
+					tt.node.addChild(keyChar{ch: byte(j)}, factory.NewLeaf(Key{byte(j)}, "value"), &testOpts)
 				}
 			}
 
-			newNode := toNode(tt.node).shrink()
-			assert.Equal(t, tt.expected, newNode.kind)
+			newNode := toNode(tt.node).shrink(&testOpts)
+			assert.Equal(t, tt.expected, newNode.kind())
 		})
 	}
 }
@@ -0,0 +1,69 @@
+package art
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// SWAR (SIMD within a register) helpers used to look up the keys
+// of Node4 and Node16 eight bytes at a time.
+// Every helper returns a mask with the high bit set in each matching byte lane.
+const (
+	swarLo = 0x0101010101010101 // the lowest bit of every byte lane
+	swarHi = 0x8080808080808080 // the highest bit of every byte lane
+)
+
+// swarRepeat broadcasts the byte to all lanes of a word.
+func swarRepeat(ch byte) uint64 {
+	return swarLo * uint64(ch)
+}
+
+// swarEqual returns the lanes of w equal to ch.
+func swarEqual(w uint64, ch byte) uint64 {
+	x := w ^ swarRepeat(ch)
+
+	// a lane is zero if neither its low 7 bits nor its high bit are set,
+	// adding 0x7f to the low 7 bits can't carry into the next lane.
+	return ^(((x &^ swarHi) + ^uint64(swarHi)) | x) & swarHi
+}
+
+// swarValid returns the lanes of the first n bytes of a word.
+func swarValid(n int) uint64 {
+	if n >= 8 {
+		return swarHi
+	}
+
+	return swarHi & (1<<(uint(n)*8) - 1)
+}
+
+// swarFirst returns the index of the first lane set in the mask.
+func swarFirst(m uint64) int {
+	return bits.TrailingZeros64(m) >> 3
+}
+
+// findIndex4 returns the index of ch among the first n keys of a Node4.
+func findIndex4(keys *[node4Max]byte, n int, ch byte) int {
+	w := uint64(binary.LittleEndian.Uint32(keys[:]))
+	if m := swarEqual(w, ch) & swarValid(n); m != 0 {
+		return swarFirst(m)
+	}
+
+	return indexNotFound
+}
+
+// findIndex16 returns the index of ch among the first n keys of a Node16.
+func findIndex16(keys *[node16Max]byte, n int, ch byte) int {
+	lo := binary.LittleEndian.Uint64(keys[:8])
+	if m := swarEqual(lo, ch) & swarValid(n); m != 0 {
+		return swarFirst(m)
+	}
+
+	if n > 8 {
+		hi := binary.LittleEndian.Uint64(keys[8:])
+		if m := swarEqual(hi, ch) & swarValid(n-8); m != 0 {
+			return 8 + swarFirst(m)
+		}
+	}
+
+	return indexNotFound
+}
@@ -0,0 +1,105 @@
+package art
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// linearIndex is the byte-by-byte reference for findIndex4/findIndex16.
+func linearIndex(keys []byte, ch byte) int {
+	for i, key := range keys {
+		if key == ch {
+			return i
+		}
+	}
+
+	return indexNotFound
+}
+
+// randomSortedKeys fills keys with n distinct sorted bytes, the rest is zeroed.
+func randomSortedKeys(rnd *rand.Rand, keys []byte, n int) {
+	perm := rnd.Perm(256)[:n]
+	sort.Ints(perm)
+
+	for i := range keys {
+		keys[i] = 0
+		if i < n {
+			keys[i] = byte(perm[i])
+		}
+	}
+}
+
+func TestSWARFindIndex(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(1)) //nolint:gosec
+
+	for iter := 0; iter < 200; iter++ {
+		var keys4 [node4Max]byte
+
+		var keys16 [node16Max]byte
+
+		n4 := iter % (node4Max + 1)
+		n16 := iter % (node16Max + 1)
+
+		randomSortedKeys(rnd, keys4[:], n4)
+		randomSortedKeys(rnd, keys16[:], n16)
+
+		for c := 0; c < 256; c++ {
+			ch := byte(c)
+			assert.Equal(t, linearIndex(keys4[:n4], ch), findIndex4(&keys4, n4, ch), "node4 index %v %d", keys4, ch)
+			assert.Equal(t, linearIndex(keys16[:n16], ch), findIndex16(&keys16, n16, ch), "node16 index %v %d", keys16, ch)
+		}
+	}
+}
+
+func TestSWARMasks(t *testing.T) {
+	t.Parallel()
+
+	w := uint64(0x00_ff_80_7f_01_00_80_ff)
+
+	assert.Equal(t, uint64(0x80_00_00_00_00_80_00_00), swarEqual(w, 0x00))
+	assert.Equal(t, uint64(0x00_80_00_00_00_00_00_80), swarEqual(w, 0xff))
+	assert.Equal(t, uint64(0), swarValid(0))
+	assert.Equal(t, uint64(0x80_80_80), swarValid(3))
+	assert.Equal(t, uint64(swarHi), swarValid(16))
+}
+
+// Per-node micro-benchmarks comparing the SWAR search against the linear scan.
+func benchmarkNodeSearch(b *testing.B, search func(ch byte) int) {
+	b.Helper()
+
+	for i := 0; i < b.N; i++ {
+		for c := 0; c < 256; c += 7 {
+			_ = search(byte(c))
+		}
+	}
+}
+
+func BenchmarkNode4Index(b *testing.B) {
+	keys := [node4Max]byte{'a', 'f', 'k', 'z'}
+
+	b.Run("Linear", func(b *testing.B) {
+		benchmarkNodeSearch(b, func(ch byte) int { return linearIndex(keys[:], ch) })
+	})
+	b.Run("SWAR", func(b *testing.B) {
+		benchmarkNodeSearch(b, func(ch byte) int { return findIndex4(&keys, node4Max, ch) })
+	})
+}
+
+func BenchmarkNode16Index(b *testing.B) {
+	var keys [node16Max]byte
+	for i := range keys {
+		keys[i] = byte(i * 16)
+	}
+
+	b.Run("Linear", func(b *testing.B) {
+		benchmarkNodeSearch(b, func(ch byte) int { return linearIndex(keys[:], ch) })
+	})
+	b.Run("SWAR", func(b *testing.B) {
+		benchmarkNodeSearch(b, func(ch byte) int { return findIndex16(&keys, node16Max, ch) })
+	})
+}
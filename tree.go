@@ -1,5 +1,10 @@
 package art
 
+import (
+	"bytes"
+	"math"
+)
+
 // treeOpResult represents the result of the tree operation.
 type treeOpResult int
 
@@ -45,11 +50,40 @@ func (k Key) isValid(idx int) bool {
 	return idx >= 0 && idx < len(k)
 }
 
+// treeOptions contains options for the tree, see TreeOption.
+type treeOptions struct {
+	maxPrefixLen int             // maximum number of prefix bytes stored in inner nodes
+	factory      NodeFactory     // factory allocates and recycles the tree nodes
+	transform    KeyTransformer  // transform maps the keys to their sort keys, nil to use the keys as is
+	leafSuffixes bool            // leafSuffixes stores the key suffixes below the Leaf depths in the leaves
+	stats        *statsCollector // stats holds the tracked statistics, nil unless WithStatsTracking is set
+}
+
+// createTreeOptions applies the options to the default tree options.
+func createTreeOptions(opts ...TreeOption) treeOptions {
+	defOpts := treeOptions{
+		maxPrefixLen: maxPrefixLen,
+		factory:      factory,
+	}
+
+	for _, opt := range opts {
+		opt(&defOpts)
+	}
+
+	// the truncated prefixes are restored from the Leaf keys, which don't hold them with suffixes
+	if defOpts.leafSuffixes {
+		defOpts.maxPrefixLen = math.MaxUint16
+	}
+
+	return defOpts
+}
+
 // tree is the main data structure of the ART tree.
 type tree struct {
-	version int      // version is used to detect concurrent modifications
-	size    int      // size is the number of elements in the tree
-	root    *NodeRef // root is the root Node of the tree
+	version int         // version is used to detect concurrent modifications
+	size    int         // size is the number of elements in the tree
+	root    NodeRef     // root is the root Node of the tree
+	opts    treeOptions // opts is the tree configuration
 }
 
 // make sure that tree implements all methods from the Tree interface.
@@ -83,45 +117,91 @@ func (tr *tree) Delete(key Key) (Value, bool) {
 
 // Search searches for the given key in the tree.
 func (tr *tree) Search(key Key) (Value, bool) {
+	if leaf := tr.searchLeaf(key); leaf != nil {
+		return leaf.value, true
+	}
+
+	return nil, false
+}
+
+// searchLeaf returns the Leaf of the given key, nil if the key is not found.
+func (tr *tree) searchLeaf(key Key) *Leaf {
 	keyOffset := 0
 
 	current := tr.root
-	for current != nil {
+	for !current.isNil() {
 		if current.isLeaf() {
 			leaf := current.Leaf()
-			if leaf.Match(key) {
-				return leaf.value, true
+			if leaf.Match(tr.leafPart(key, keyOffset)) {
+				return leaf
 			}
 
-			return nil, false
+			return nil
 		}
 
 		curNode := current.node()
 		if curNode.prefixLen > 0 {
 			prefixLen := current.match(key, keyOffset)
-			if prefixLen != minInt(int(curNode.prefixLen), maxPrefixLen) {
-				return nil, false
+			if prefixLen != int(curNode.storedLen) {
+				return nil
 			}
 
 			keyOffset += int(curNode.prefixLen)
 		}
 
-		next := current.findChildByKey(key, keyOffset)
-		if *next != nil {
-			current = *next
-		} else {
-			current = nil
+		current = *current.findChildByKey(key, keyOffset)
+		keyOffset++
+	}
+
+	return nil
+}
+
+// LongestPrefix returns the longest key of the tree which is a prefix of the given key.
+// The candidates are the zero byte children of the nodes along the search path and the Leaf ending it.
+func (tr *tree) LongestPrefix(key Key) (Key, Value, bool) {
+	var best *Leaf
+
+	depth, bestDepth := 0, 0
+
+	for current := tr.root; !current.isNil(); {
+		if current.isLeaf() {
+			if leaf := current.Leaf(); bytes.HasPrefix(tr.leafPart(key, depth), leaf.key) {
+				best, bestDepth = leaf, depth
+			}
+
+			break
+		}
+
+		prefix := current.fullPrefix(depth)
+		if !bytes.HasPrefix(key[depth:], prefix) {
+			break
 		}
 
-		keyOffset++
+		depth += len(prefix)
+
+		n := toNode(current)
+		if zeroChild := *n.childAt(n.index(keyCharInvalid)); !zeroChild.isNil() {
+			best, bestDepth = zeroChild.Leaf(), depth
+		}
+
+		if depth >= len(key) {
+			break
+		}
+
+		current = *current.findChildByKey(key, depth)
+		depth++
 	}
 
-	return nil, false
+	if best == nil {
+		return nil, nil, false
+	}
+
+	return tr.leafKey(best, key[:bestDepth]), best.value, true
 }
 
 // Minimum returns the minimum key in the tree.
 func (tr *tree) Minimum() (Value, bool) {
-	if tr == nil || tr.root == nil {
+	if tr == nil || tr.root.isNil() {
 		return nil, false
 	}
 
@@ -130,7 +210,7 @@ func (tr *tree) Minimum() (Value, bool) {
 
 // Maximum returns the maximum key in the tree.
 func (tr *tree) Maximum() (Value, bool) {
-	if tr == nil || tr.root == nil {
+	if tr == nil || tr.root.isNil() {
 		return nil, false
 	}
 
@@ -139,7 +219,7 @@ func (tr *tree) Maximum() (Value, bool) {
 
 // Size returns the number of elements in the tree.
 func (tr *tree) Size() int {
-	if tr == nil || tr.root == nil {
+	if tr == nil || tr.root.isNil() {
 		return 0
 	}
 
@@ -149,7 +229,18 @@ func (tr *tree) Size() int {
 // ForEach iterates over all keys in the tree and calls the callback function.
 func (tr *tree) ForEach(callback Callback, opts ...int) {
 	options := traverseOptions(opts...)
-	tr.forEachRecursively(tr.root, traverseFilter(options, callback), options.hasReverse())
+	callback = traverseFilter(options, callback)
+
+	if tr.opts.leafSuffixes {
+		// the keys of the leaves are restored from their path
+		walkPath(tr.root, nil, options.hasReverse(), func(nr NodeRef, path Key) traverseAction {
+			return ternary(callback(tr.nodeKV(nr, path)), traverseContinue, traverseStop)
+		})
+
+		return
+	}
+
+	tr.forEachRecursively(tr.root, callback, options.hasReverse())
 }
 
 // ForEachPrefix iterates over all keys with the given prefix.
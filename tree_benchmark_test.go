@@ -1,6 +1,7 @@
 package art
 
 import (
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -37,6 +38,21 @@ func BenchmarkWordsTreeSearch(b *testing.B) {
 	}
 }
 
+func BenchmarkWordsTreeFuzzySearch(b *testing.B) {
+	tree := New()
+
+	words := loadTestFile("test/assets/words.txt")
+	for _, w := range words {
+		tree.Insert(w, w)
+	}
+
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		tree.FuzzySearch(Key("algoritm"), 2, func(NodeKV) bool { return true })
+	}
+}
+
 func BenchmarkWordsTreeIterator(b *testing.B) {
 	tree := New()
 
@@ -189,3 +205,312 @@ func BenchmarkHSKTreeForEach(b *testing.B) {
 	stats := collectStats(tree.Iterator(TraverseAll))
 	assert.Equal(b, treeStats{4995, 1630, 276, 21, 4}, stats)
 }
+
+// prefixModes lists the prefix storage modes compared by the benchmarks.
+//
+//nolint:gochecknoglobals
+var prefixModes = []struct {
+	name string
+	mode int
+}{
+	{"Optimistic", OptimisticPrefixLen},
+	{"Pessimistic", PessimisticPrefixLen},
+}
+
+func BenchmarkNamespacedUUIDsTreeInsert(b *testing.B) {
+	words := namespacedKeys(loadTestFile("test/assets/uuid.txt"))
+
+	for _, pm := range prefixModes {
+		b.Run(pm.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				tree := New(WithMaxPrefixLen(pm.mode))
+				for _, w := range words {
+					tree.Insert(w, w)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkNamespacedUUIDsTreeSearch(b *testing.B) {
+	words := namespacedKeys(loadTestFile("test/assets/uuid.txt"))
+
+	for _, pm := range prefixModes {
+		tree := New(WithMaxPrefixLen(pm.mode))
+		for _, w := range words {
+			tree.Insert(w, w)
+		}
+
+		b.Run(pm.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, w := range words {
+					tree.Search(w)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUUIDsTreeSearchMaxPrefixLen(b *testing.B) {
+	words := loadTestFile("test/assets/uuid.txt")
+
+	for _, pm := range prefixModes {
+		tree := New(WithMaxPrefixLen(pm.mode))
+		for _, w := range words {
+			tree.Insert(w, w)
+		}
+
+		b.Run(pm.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, w := range words {
+					tree.Search(w)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUUIDsTreeInsertDeleteAllocator(b *testing.B) {
+	words := loadTestFile("test/assets/uuid.txt")
+
+	allocators := []struct {
+		name    string
+		factory NodeFactory
+	}{
+		{"Heap", nil},
+		{"Arena", NewArenaAllocator(0)},
+	}
+
+	for _, a := range allocators {
+		tree := New(WithAllocator(a.factory))
+
+		b.Run(a.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for n := 0; n < b.N; n++ {
+				for _, w := range words {
+					tree.Insert(w, w)
+				}
+
+				for _, w := range words {
+					tree.Delete(w)
+				}
+			}
+		})
+	}
+}
+
+//nolint:gochecknoglobals
+var engines = []struct {
+	name    string
+	newTree func() Tree
+}{
+	{"Pointer", func() Tree { return New() }},
+	{"Compact", NewCompact},
+}
+
+func BenchmarkWordsTreeInsertEngine(b *testing.B) {
+	words := loadTestFile("test/assets/words.txt")
+
+	for _, e := range engines {
+		b.Run(e.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				tree := e.newTree()
+				for _, w := range words {
+					tree.Insert(w, w)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWordsTreeSearchEngine(b *testing.B) {
+	words := loadTestFile("test/assets/words.txt")
+
+	for _, e := range engines {
+		tree := e.newTree()
+		for _, w := range words {
+			tree.Insert(w, w)
+		}
+
+		b.Run(e.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, w := range words {
+					tree.Search(w)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkUUIDsTreeGCEngine measures the duration of a garbage collection with a live tree.
+func BenchmarkUUIDsTreeGCEngine(b *testing.B) {
+	words := loadTestFile("test/assets/uuid.txt")
+
+	for _, e := range engines {
+		tree := e.newTree()
+		for i := 0; i < 10; i++ {
+			for _, w := range words {
+				tree.Insert(append(append(Key{}, w...), byte(i)), i)
+			}
+		}
+
+		b.Run(e.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				runtime.GC()
+			}
+		})
+
+		runtime.KeepAlive(tree)
+	}
+}
+
+// BenchmarkWordsTreeMemory reports the heap bytes retained by the tree per key.
+func BenchmarkWordsTreeMemory(b *testing.B) {
+	words := loadTestFile("test/assets/words.txt")
+
+	var before, after runtime.MemStats
+
+	for n := 0; n < b.N; n++ {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		tree := New()
+		for _, w := range words {
+			tree.Insert(w, w)
+		}
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(tree)
+	}
+
+	b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(len(words)), "bytes/key")
+}
+
+// BenchmarkWordsSetMemory reports the heap bytes retained per key by a Set
+// and by the trees holding the same keys with an empty value.
+func BenchmarkWordsSetMemory(b *testing.B) {
+	words := loadTestFile("test/assets/words.txt")
+
+	containers := []struct {
+		name string
+		add  func() interface{}
+	}{
+		{"PointerTree", func() interface{} {
+			tree := New()
+			for _, w := range words {
+				tree.Insert(w, struct{}{})
+			}
+
+			return tree
+		}},
+		{"CompactTree", func() interface{} {
+			tree := NewCompact()
+			for _, w := range words {
+				tree.Insert(w, struct{}{})
+			}
+
+			return tree
+		}},
+		{"Set", func() interface{} {
+			s := NewSet()
+			for _, w := range words {
+				s.Add(w)
+			}
+
+			return s
+		}},
+	}
+
+	for _, c := range containers {
+		b.Run(c.name, func(b *testing.B) {
+			var before, after runtime.MemStats
+
+			for n := 0; n < b.N; n++ {
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+
+				container := c.add()
+
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				runtime.KeepAlive(container)
+			}
+
+			b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(len(words)), "bytes/key")
+		})
+	}
+}
+
+func BenchmarkURLsLeafSuffixesMemory(b *testing.B) {
+	urls := urlKeys(loadTestFile("test/assets/uuid.txt"))
+
+	modes := []struct {
+		name string
+		opt  TreeOption
+	}{
+		{"Pessimistic", WithMaxPrefixLen(PessimisticPrefixLen)},
+		{"LeafSuffixes", WithLeafSuffixes()},
+	}
+
+	for _, m := range modes {
+		b.Run(m.name, func(b *testing.B) {
+			var before, after runtime.MemStats
+
+			for n := 0; n < b.N; n++ {
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+
+				tree := New(m.opt)
+				for _, u := range urls {
+					tree.Insert(u, struct{}{})
+				}
+
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				runtime.KeepAlive(tree)
+			}
+
+			b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(len(urls)), "bytes/key")
+		})
+	}
+}
+
+// BenchmarkWordsMultiValues compares a MultiTree with a tree holding the []Value of each key as its value.
+func BenchmarkWordsMultiValues(b *testing.B) {
+	words := loadTestFile("test/assets/words.txt")
+
+	const valuesPerKey = 4
+
+	b.Run("MultiTree", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			tree := NewMultiTree()
+			for i := 0; i < valuesPerKey; i++ {
+				for _, w := range words {
+					tree.Insert(w, i)
+				}
+			}
+		}
+	})
+
+	b.Run("SliceValue", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			tree := New()
+			for i := 0; i < valuesPerKey; i++ {
+				for _, w := range words {
+					values, _ := tree.Search(w)
+					if values == nil {
+						tree.Insert(w, []Value{i})
+					} else {
+						tree.Insert(w, append(values.([]Value), i)) //nolint:forcetypeassert
+					}
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package art
+
+import "bytes"
+
+// childrenOptions contains options for Children.
+type childrenOptions struct {
+	count bool // count enables counting the keys grouped under the segments
+}
+
+func createChildrenOptions(opts ...ChildrenOption) childrenOptions {
+	var defOpts childrenOptions
+
+	for _, opt := range opts {
+		opt(&defOpts)
+	}
+
+	return defOpts
+}
+
+// segmentEnd returns the length of the segment starting the key suffix after the prefix,
+// -1 if the suffix holds no separator.
+func segmentEnd(key Key, prefix Key, sep byte) int {
+	if len(key) <= len(prefix) {
+		return -1
+	}
+
+	return bytes.IndexByte(key[len(prefix):], sep)
+}
+
+// Children enumerates the path segments following the prefix.
+// The subtree whose path holds a separator after the prefix is reported as a single segment
+// without being visited, unless the keys are counted.
+func (tr *tree) Children(prefix Key, sep byte, cb ChildrenCallback, opts ...ChildrenOption) {
+	options := createChildrenOptions(opts...)
+
+	root, depth := tr.findPrefixRoot(prefix)
+	walkPath(root, prefix[:depth], false, func(nr NodeRef, path Key) traverseAction {
+		if nr.isLeaf() {
+			key := tr.leafKey(nr.Leaf(), path)
+			if !bytes.HasPrefix(key, prefix) {
+				return traverseContinue
+			}
+
+			if end := segmentEnd(key, prefix, sep); end >= 0 {
+				count := ternary(options.count, 1, 0)
+
+				return ternary(cb(key[len(prefix):len(prefix)+end], false, count), traverseContinue, traverseStop)
+			}
+
+			return ternary(cb(key[len(prefix):], true, 1), traverseContinue, traverseStop)
+		}
+
+		end := segmentEnd(path, prefix, sep)
+		if end < 0 {
+			return traverseContinue
+		}
+
+		count := 0
+		if options.count {
+			count = countLeaves(nr)
+		}
+
+		return ternary(cb(path[len(prefix):len(prefix)+end], false, count), traverseSkip, traverseStop)
+	})
+}
+
+// countLeaves returns the number of leaves in the subtree.
+func countLeaves(nr NodeRef) int {
+	if nr.isNil() {
+		return 0
+	}
+
+	if nr.isLeaf() {
+		return 1
+	}
+
+	count := 0
+	for _, child := range toNode(nr).allChildren() {
+		count += countLeaves(child)
+	}
+
+	return count
+}
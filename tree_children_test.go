@@ -0,0 +1,81 @@
+package art
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type childSegment struct {
+	segment string
+	isLeaf  bool
+	count   int
+}
+
+func TestTreeChildren(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		prefix   string
+		opts     []ChildrenOption
+		expected []childSegment
+	}{
+		{"Root", "", nil, []childSegment{
+			{"photos", false, 0}, {"readme.md", true, 1}, {"src", false, 0},
+		}},
+		{"RootCount", "", []ChildrenOption{WithChildrenCount()}, []childSegment{
+			{"photos", false, 7}, {"readme.md", true, 1}, {"src", false, 1},
+		}},
+		{"Directory", "photos/", []ChildrenOption{WithChildrenCount()}, []childSegment{
+			{"", true, 1}, {"2023", false, 3}, {"2024", false, 1}, {"e.jpg", true, 1}, {"f.jpg", true, 1},
+		}},
+		{"PartialSegment", "photos/2023/j", nil, []childSegment{{"an", false, 0}}},
+		{"NoMatch", "videos/", nil, nil},
+	}
+
+	for _, e := range engines {
+		e := e
+		tree := newListTree(e.newTree)
+
+		for _, tt := range tests {
+			tt := tt
+			t.Run(e.name+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				var actual []childSegment
+
+				tree.Children(Key(tt.prefix), '/', func(segment Key, isLeaf bool, count int) bool {
+					actual = append(actual, childSegment{string(segment), isLeaf, count})
+
+					return true
+				}, tt.opts...)
+
+				assert.Equal(t, tt.expected, actual)
+			})
+		}
+	}
+}
+
+func TestTreeChildrenStop(t *testing.T) {
+	t.Parallel()
+
+	for _, e := range engines {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := newListTree(e.newTree)
+
+			var segments []string
+
+			tree.Children(Key("photos/"), '/', func(segment Key, _ bool, _ int) bool {
+				segments = append(segments, string(segment))
+
+				return len(segments) < 2
+			}, WithChildrenCount())
+
+			assert.Equal(t, []string{"", "2023"}, segments)
+		})
+	}
+}
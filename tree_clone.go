@@ -0,0 +1,140 @@
+package art
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// Clone returns a deep copy of the tree.
+// Values are shared between the original tree and the copy.
+func (tr *tree) Clone() Tree {
+	return tr.CloneWith(nil)
+}
+
+// CloneWith returns a deep copy of the tree,
+// each value is copied with the cloneValue function.
+// If cloneValue is nil, values are shared between the original tree and the copy.
+func (tr *tree) CloneWith(cloneValue func(Value) Value) Tree {
+	if cloneValue == nil {
+		cloneValue = func(v Value) Value { return v }
+	}
+
+	clone := &tree{opts: tr.opts}
+
+	// an arena is not safe for concurrent use, the copy gets its own one
+	if arena, ok := tr.opts.factory.(*arenaFactory); ok {
+		clone.opts.factory = NewArenaAllocator(arena.leaves.maxSize)
+	}
+
+	clone.root = cloneRecursively(tr.root, cloneValue, clone.opts.factory)
+	clone.size = tr.size
+	clone.retrackStats()
+
+	return clone
+}
+
+// cloneRecursively copies the subtree node-by-node preserving Node kinds and prefixes.
+func cloneRecursively(nr NodeRef, cloneValue func(Value) Value, f NodeFactory) NodeRef {
+	if nr.isNil() {
+		return NodeRef{}
+	}
+
+	var clone NodeRef
+
+	switch nr.kind() {
+	case LeafKind:
+		leaf := nr.Leaf()
+
+		return f.NewLeaf(leaf.key, cloneValue(leaf.value))
+	case Node4Kind:
+		clone = f.NewNode4()
+		*clone.node4() = *nr.node4()
+	case Node16Kind:
+		clone = f.NewNode16()
+		*clone.node16() = *nr.node16()
+	case Node48Kind:
+		clone = f.NewNode48()
+		*clone.node48() = *nr.node48()
+	case Node256Kind:
+		clone = f.NewNode256()
+		*clone.node256() = *nr.node256()
+	}
+
+	children := toNode(clone).allChildren()
+	for i, child := range children {
+		children[i] = cloneRecursively(child, cloneValue, f)
+	}
+
+	return clone
+}
+
+// Equal reports whether two trees have the same structure and hold the same key-value pairs.
+// Trees are walked in lock step, values are compared with the eq function.
+// If eq is nil, values are compared with reflect.DeepEqual.
+func Equal(a, b Tree, eq func(a, b Value) bool) bool {
+	if eq == nil {
+		eq = func(a, b Value) bool { return reflect.DeepEqual(a, b) }
+	}
+
+	ta, aok := a.(*tree)
+	tb, bok := b.(*tree)
+
+	if aok && bok && ta.opts.leafSuffixes == tb.opts.leafSuffixes {
+		return ta.size == tb.size && equalRecursively(ta.root, tb.root, eq)
+	}
+
+	return a.Size() == b.Size() && equalIterators(a.Iterator(), b.Iterator(), eq)
+}
+
+// equalRecursively compares two subtrees Node by Node.
+func equalRecursively(a, b NodeRef, eq func(a, b Value) bool) bool {
+	if a.isNil() || b.isNil() {
+		return a.isNil() == b.isNil()
+	}
+
+	if a.kind() != b.kind() {
+		return false
+	}
+
+	if a.isLeaf() {
+		la, lb := a.Leaf(), b.Leaf()
+
+		return bytes.Equal(la.key, lb.key) && eq(la.value, lb.value)
+	}
+
+	na, nb := a.node(), b.node()
+	if na.prefixLen != nb.prefixLen || !bytes.Equal(na.storedPrefix(), nb.storedPrefix()) {
+		return false
+	}
+
+	refsA, refsB := a.childRefs(), b.childRefs()
+	if len(refsA) != len(refsB) {
+		return false
+	}
+
+	for i := range refsA {
+		if refsA[i].kc != refsB[i].kc || !equalRecursively(refsA[i].ref, refsB[i].ref, eq) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalIterators compares the key-value pairs returned by two Leaf iterators.
+func equalIterators(a, b Iterator, eq func(a, b Value) bool) bool {
+	for a.HasNext() && b.HasNext() {
+		na, errA := a.Next()
+		nb, errB := b.Next()
+
+		if errA != nil || errB != nil {
+			return false
+		}
+
+		if !bytes.Equal(na.Key(), nb.Key()) || !eq(na.Value(), nb.Value()) {
+			return false
+		}
+	}
+
+	return !a.HasNext() && !b.HasNext()
+}
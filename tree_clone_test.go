@@ -0,0 +1,122 @@
+package art
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTreeClone(t *testing.T) {
+	t.Parallel()
+
+	tree, data := treeWithData("test/assets/words.txt")
+
+	clone := tree.Clone()
+	assert.True(t, Equal(tree, clone, nil))
+	assert.Equal(t, tree.Size(), clone.Size())
+	assert.Equal(t, collectStats(tree.Iterator(TraverseAll)), collectStats(clone.Iterator(TraverseAll)))
+
+	// modifications of the clone must not affect the original tree
+	for _, d := range data[:1000] {
+		_, deleted := clone.Delete(d)
+		assert.True(t, deleted)
+	}
+
+	clone.Insert(Key("new key"), "new value")
+
+	assert.False(t, Equal(tree, clone, nil))
+	assert.Equal(t, len(data), tree.Size())
+
+	for _, d := range data {
+		val, found := tree.Search(d)
+		assert.True(t, found)
+		assert.Equal(t, d, val)
+	}
+}
+
+func TestTreeCloneWithValues(t *testing.T) {
+	t.Parallel()
+
+	tree := New()
+	tree.Insert(Key("a"), []int{1})
+	tree.Insert(Key("b"), []int{2})
+
+	clone := tree.CloneWith(func(v Value) Value {
+		return append([]int{}, v.([]int)...)
+	})
+	assert.True(t, Equal(tree, clone, nil))
+
+	val, _ := clone.Search(Key("a"))
+	val.([]int)[0] = 100
+
+	orig, _ := tree.Search(Key("a"))
+	assert.Equal(t, []int{1}, orig)
+	assert.False(t, Equal(tree, clone, nil))
+}
+
+func TestTreeCloneArenaAllocator(t *testing.T) {
+	t.Parallel()
+
+	original := newTree(WithAllocator(NewArenaAllocator(64)))
+	data := loadTestFile("test/assets/words.txt")[:20000]
+
+	for _, d := range data {
+		original.Insert(d, d)
+	}
+
+	clone := original.Clone()
+
+	arena := clone.(*tree).opts.factory.(*arenaFactory) //nolint:forcetypeassert
+	assert.NotSame(t, original.opts.factory, arena)
+	assert.Equal(t, 64, arena.leaves.maxSize)
+
+	// both trees allocate and release nodes at the same time
+	var wg sync.WaitGroup
+
+	for _, tr := range []Tree{original, clone} {
+		wg.Add(1)
+
+		go func(tr Tree) {
+			defer wg.Done()
+
+			for _, d := range data[:len(data)/2] {
+				tr.Delete(d)
+			}
+
+			for _, d := range data[:len(data)/2] {
+				tr.Insert(d, d)
+			}
+		}(tr)
+	}
+
+	wg.Wait()
+
+	assert.True(t, Equal(original, clone, nil))
+	assert.NoError(t, clone.Validate())
+}
+
+func TestTreeEqual(t *testing.T) {
+	t.Parallel()
+
+	build := func(keys ...string) Tree {
+		tree := New()
+		for _, k := range keys {
+			tree.Insert(Key(k), k)
+		}
+
+		return tree
+	}
+
+	assert.True(t, Equal(New(), New(), nil))
+	assert.True(t, Equal(build("a", "ab", "abc"), build("abc", "a", "ab"), nil))
+	assert.False(t, Equal(build("a", "ab"), build("a", "ab", "abc"), nil))
+	assert.False(t, Equal(build("a", "ab"), build("a", "ac"), nil))
+	assert.False(t, Equal(build("a"), New(), nil))
+
+	upper := New()
+	upper.Insert(Key("a"), "A")
+
+	assert.False(t, Equal(build("a"), upper, nil))
+	assert.True(t, Equal(build("a"), upper, func(a, b Value) bool { return true }))
+}
@@ -1,25 +1,29 @@
 package art
 
 // deleteRecursively removes a Node associated with the key from the tree.
-func (tr *tree) deleteRecursively(nrp **NodeRef, key Key, keyOffset int) (Value, treeOpResult) {
-	if tr == nil || *nrp == nil || len(key) == 0 {
+func (tr *tree) deleteRecursively(nrp *NodeRef, key Key, keyOffset int) (Value, treeOpResult) {
+	if tr == nil || nrp.isNil() || len(key) == 0 {
 		return nil, treeOpNoChange
 	}
 
 	nr := *nrp
 	if nr.isLeaf() {
-		return tr.handleLeafDeletion(nrp, key)
+		return tr.handleLeafDeletion(nrp, key, keyOffset)
 	}
 
-	return tr.handleInternalNodeDeletion(nr, key, keyOffset)
+	return tr.handleInternalNodeDeletion(nrp, key, keyOffset)
 }
 
 // handleLeafDeletion removes a Leaf Node associated with the key from the tree.
-func (tr *tree) handleLeafDeletion(nrp **NodeRef, key Key) (Value, treeOpResult) {
-	if leaf := (*nrp).Leaf(); leaf.Match(key) {
-		replaceRef(nrp, nil)
+func (tr *tree) handleLeafDeletion(nrp *NodeRef, key Key, keyOffset int) (Value, treeOpResult) {
+	nr := *nrp
+	if leaf := nr.Leaf(); leaf.Match(tr.leafPart(key, keyOffset)) {
+		value := leaf.value
+		replaceRef(nrp, NodeRef{})
+		tr.opts.untrack(nr)
+		tr.opts.factory.Release(nr)
 
-		return leaf.value, treeOpDeleted
+		return value, treeOpDeleted
 	}
 
 	return nil, treeOpNoChange
@@ -30,7 +34,7 @@ func (tr *tree) handleInternalNodeDeletion(nr *NodeRef, key Key, keyOffset int)
 	n := nr.node()
 
 	if n.prefixLen > 0 {
-		if mismatchIdx := nr.match(key, keyOffset); mismatchIdx != minInt(int(n.prefixLen), maxPrefixLen) {
+		if mismatchIdx := nr.match(key, keyOffset); mismatchIdx != int(n.storedLen) {
 			return nil, treeOpNoChange
 		}
 
@@ -38,11 +42,11 @@ func (tr *tree) handleInternalNodeDeletion(nr *NodeRef, key Key, keyOffset int)
 	}
 
 	next := nr.findChildByKey(key, keyOffset)
-	if *next == nil {
+	if next.isNil() {
 		return nil, treeOpNoChange
 	}
 
-	if (*next).isLeaf() {
+	if next.isLeaf() {
 		return tr.handleDeletionInChild(nr, *next, key, keyOffset)
 	}
 
@@ -50,13 +54,16 @@ func (tr *tree) handleInternalNodeDeletion(nr *NodeRef, key Key, keyOffset int)
 }
 
 // handleDeletionInChild removes a Leaf Node from the child Node.
-func (tr *tree) handleDeletionInChild(curNR, nextNR *NodeRef, key Key, keyOffset int) (Value, treeOpResult) {
-	leaf := (*nextNR).Leaf()
-	if !leaf.Match(key) {
+func (tr *tree) handleDeletionInChild(curNR *NodeRef, nextNR NodeRef, key Key, keyOffset int) (Value, treeOpResult) {
+	leaf := nextNR.Leaf()
+	if !leaf.Match(tr.leafPart(key, keyOffset+1)) {
 		return nil, treeOpNoChange
 	}
 
-	curNR.deleteChild(key.charAt(keyOffset))
+	value := leaf.value
+	curNR.deleteChild(key.charAt(keyOffset), &tr.opts)
+	tr.opts.untrack(nextNR)
+	tr.opts.factory.Release(nextNR)
 
-	return leaf.value, treeOpDeleted
+	return value, treeOpDeleted
 }
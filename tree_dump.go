@@ -14,21 +14,21 @@ const (
 	printValueDefault = printValuesAsChar
 )
 
-// refFormatter is a function that formats an artNodeRef.
-type refFormatter func(*dumpNodeRef) string
+// RefFormatter is a function that formats a DumpNodeRef.
+type RefFormatter func(*DumpNodeRef) string
 
 // RefFullFormatter returns the full address of the Node, including the ID and the pointer.
-func RefFullFormatter(a *dumpNodeRef) string {
-	if a.ptr == nil {
+func RefFullFormatter(a *DumpNodeRef) string {
+	if a.ref.isNil() {
 		return "-"
 	}
 
-	return fmt.Sprintf("#%d/%p", a.id, a.ptr)
+	return fmt.Sprintf("#%d/%p", a.id, a.ref.pointer())
 }
 
 // RefShortFormatter returns only the ID of the Node.
-func RefShortFormatter(a *dumpNodeRef) string {
-	if a.ptr == nil {
+func RefShortFormatter(a *DumpNodeRef) string {
+	if a.ref.isNil() {
 		return "-"
 	}
 
@@ -36,15 +36,15 @@ func RefShortFormatter(a *dumpNodeRef) string {
 }
 
 // RefAddrFormatter returns only the pointer address of the Node (legacy).
-func RefAddrFormatter(a *dumpNodeRef) string {
-	if a.ptr == nil {
+func RefAddrFormatter(a *DumpNodeRef) string {
+	if a.ref.isNil() {
 		return "-"
 	}
 
-	return fmt.Sprintf("%p", a.ptr)
+	return fmt.Sprintf("%p", a.ref.pointer())
 }
 
-// dumpNodeRef represents the address of a NodeRef in the tree,
+// DumpNodeRef represents the address of a NodeRef in the tree,
 // composed of a unique, sequential ID and a pointer to the Node.
 // The ID remains consistent for trees built with the same keys
 // while the pointer may change with each build.
@@ -54,14 +54,29 @@ func RefAddrFormatter(a *dumpNodeRef) string {
 // For example: if you inserted the same keys in two different trees (or rerun the same test),
 // you can compare the nodes of the two trees by their IDs.
 // The IDs will be the same for the same keys, but the pointers will be different.
-type dumpNodeRef struct {
+type DumpNodeRef struct {
 	id  int          // unique ID
-	ptr *NodeRef     // pointer to the Node
-	fmt refFormatter // function to format the address
+	ref NodeRef      // reference to the Node
+	fmt RefFormatter // function to format the address
+}
+
+// ID returns the unique, sequential ID of the Node.
+func (a *DumpNodeRef) ID() int {
+	return a.id
+}
+
+// IsNil returns true if the reference doesn't point to any Node.
+func (a *DumpNodeRef) IsNil() bool {
+	return a.ref.isNil()
+}
+
+// Addr returns the address of the Node.
+func (a *DumpNodeRef) Addr() uintptr {
+	return uintptr(a.ref.pointer())
 }
 
 // String returns the string representation of the address.
-func (a dumpNodeRef) String() string {
+func (a DumpNodeRef) String() string {
 	if a.fmt == nil {
 		return RefFullFormatter(&a)
 	}
@@ -71,13 +86,13 @@ func (a dumpNodeRef) String() string {
 
 // NodeRegistry maintains a mapping between NodeRef pointers and their unique IDs.
 type nodeRegistry struct {
-	ptrToID   map[*NodeRef]int // Maps a Node pointer to its unique ID
-	addresses []dumpNodeRef    // List of Node references
-	formatter refFormatter     // Function to format Node references
+	ptrToID   map[NodeRef]int // Maps a Node reference to its unique ID
+	addresses []DumpNodeRef   // List of Node references
+	formatter RefFormatter    // Function to format Node references
 }
 
 // register adds a NodeRef to the registry and returns its reference.
-func (nr *nodeRegistry) register(node *NodeRef) dumpNodeRef {
+func (nr *nodeRegistry) register(node NodeRef) DumpNodeRef {
 	// Check if the Node is already registered.
 	if id, exists := nr.ptrToID[node]; exists {
 		return nr.addresses[id]
@@ -85,9 +100,9 @@ func (nr *nodeRegistry) register(node *NodeRef) dumpNodeRef {
 
 	// Create a new reference for the Node.
 	id := len(nr.addresses)
-	ref := dumpNodeRef{
+	ref := DumpNodeRef{
 		id:  id,
-		ptr: node,
+		ref: node,
 		fmt: nr.formatter,
 	}
 
@@ -106,9 +121,10 @@ type depthStorage struct {
 
 // treeStringer is a helper struct for generating a human-readable representation of the tree.
 type treeStringer struct {
-	storage      []depthStorage // Storage for depth information
-	buf          *bytes.Buffer  // Buffer for building the string representation
-	nodeRegistry *nodeRegistry  // Registry for node references
+	opts         treeStringerOptions // Options of the string representation
+	storage      []depthStorage      // Storage for depth information
+	buf          *bytes.Buffer       // Buffer for building the string representation
+	nodeRegistry *nodeRegistry       // Registry for node references
 }
 
 // String returns the string representation of the tree.
@@ -122,19 +138,19 @@ func (ts *treeStringer) String() string {
 }
 
 // regNode registers a NodeRef and returns its reference.
-func (ts *treeStringer) regNode(node *NodeRef) dumpNodeRef {
+func (ts *treeStringer) regNode(node NodeRef) DumpNodeRef {
 	addr := ts.nodeRegistry.register(node)
 
 	return addr
 }
 
 // regNodes registers a slice of artNodes and returns their references.
-func (ts *treeStringer) regNodes(nodes []*NodeRef) []dumpNodeRef {
+func (ts *treeStringer) regNodes(nodes []NodeRef) []DumpNodeRef {
 	if nodes == nil {
 		return nil
 	}
 
-	addrs := make([]dumpNodeRef, 0, len(nodes))
+	addrs := make([]DumpNodeRef, 0, len(nodes))
 	for _, n := range nodes {
 		addrs = append(addrs, ts.nodeRegistry.register(n))
 	}
@@ -282,16 +298,51 @@ func (ts *treeStringer) appendKey(keys []byte, present []byte, opts ...int) *tre
 }
 
 // children generates a string representation of the children of a NodeRef.
-func (ts *treeStringer) children(children []*NodeRef, _ /*numChildred*/ uint16, keyOffset int, zeroChild *NodeRef) {
+// The more children which are not printed are reported on a separate line.
+func (ts *treeStringer) children(children []NodeRef, more int, keyOffset int, zeroChild NodeRef) {
+	childrenTotal := len(children) + 1
+	if more > 0 {
+		childrenTotal++
+	}
+
 	for i, child := range children {
-		ts.baseNode(child, keyOffset, i, len(children)+1)
+		ts.baseNode(child, keyOffset, i, childrenTotal)
+	}
+
+	if more > 0 {
+		padHeader, _ := ts.generatePads(keyOffset, len(children), childrenTotal)
+		ts.append(padHeader).
+			append(fmt.Sprintf("... %d more\n", more))
 	}
 
-	ts.baseNode(zeroChild, keyOffset, len(children)+1, len(children)+1)
+	ts.baseNode(zeroChild, keyOffset, childrenTotal, childrenTotal)
+}
+
+// limitChildren returns the children to print according to WithMaxChildren
+// and the number of the elided children.
+func (ts *treeStringer) limitChildren(children []NodeRef) ([]NodeRef, int) {
+	if ts.opts.maxChildren <= 0 {
+		return children, 0
+	}
+
+	shown := make([]NodeRef, 0, ts.opts.maxChildren)
+	more := 0
+
+	for _, child := range children {
+		switch {
+		case child.isNil():
+		case len(shown) < ts.opts.maxChildren:
+			shown = append(shown, child)
+		default:
+			more++
+		}
+	}
+
+	return shown, more
 }
 
 // Node generates a string representation of a NodeRef.
-func (ts *treeStringer) node(pad string, prefixLen uint16, prefix []byte, keys []byte, present []byte, children []*NodeRef, numChildren uint16, keyOffset int, zeroChild *NodeRef) {
+func (ts *treeStringer) node(pad string, prefixLen uint16, prefix []byte, keys []byte, present []byte, children []NodeRef, numChildren uint16, keyOffset int, zeroChild NodeRef) {
 	if prefix != nil {
 		ts.append(pad).
 			append(fmt.Sprintf("prefix(%x): ", prefixLen)).
@@ -310,18 +361,27 @@ func (ts *treeStringer) node(pad string, prefixLen uint16, prefix []byte, keys [
 			append("\n")
 	}
 
+	children, more := ts.limitChildren(children)
+
 	ts.append(pad).
 		append(fmt.Sprintf("children(%v): %+v <%v>\n",
 			numChildren,
 			ts.regNodes(children),
 			ts.regNode(zeroChild)))
 
-	ts.children(children, numChildren, keyOffset+1, zeroChild)
+	if ts.opts.maxDepth >= 0 && keyOffset >= ts.opts.maxDepth {
+		ts.append(pad).
+			append("...\n")
+
+		return
+	}
+
+	ts.children(children, more, keyOffset+1, zeroChild)
 }
 
-func (ts *treeStringer) baseNode(an *NodeRef, depth int, childNum int, childrenTotal int) {
+func (ts *treeStringer) baseNode(an NodeRef, depth int, childNum int, childrenTotal int) {
 	padHeader, pad := ts.generatePads(depth, childNum, childrenTotal)
-	if an == nil {
+	if an.isNil() {
 		ts.append(padHeader).
 			append("nil").
 			append("\n")
@@ -330,10 +390,10 @@ func (ts *treeStringer) baseNode(an *NodeRef, depth int, childNum int, childrenT
 
 	ts.append(padHeader).
 		append(fmt.Sprintf("%v (%v)\n",
-			an.kind,
+			kindName(an.kind()),
 			ts.regNode(an)))
 
-	switch an.kind {
+	switch an.kind() {
 	case Node4Kind:
 		nn := an.node4()
 
@@ -408,13 +468,23 @@ func (ts *treeStringer) baseNode(an *NodeRef, depth int, childNum int, childrenT
 		n := an.Leaf()
 
 		ts.append(pad).
-			append(fmt.Sprintf("key(%d): ", len(n.key))).
-			append(n.key).
-			append(" ").
-			append(fmt.Sprintf("%v", n.key)).
-			append("\n")
+			append(fmt.Sprintf("key(%d): ", len(n.key)))
 
-		if s, ok := n.value.(string); ok {
+		if ts.opts.keyFormatter != nil {
+			ts.append(ts.opts.keyFormatter(n.key))
+		} else {
+			ts.append(n.key).
+				append(" ").
+				append(fmt.Sprintf("%v", n.key))
+		}
+
+		ts.append("\n")
+
+		if ts.opts.valueFormatter != nil {
+			ts.append(pad).
+				append(fmt.Sprintf("val: %v\n",
+					ts.opts.valueFormatter(n.value)))
+		} else if s, ok := n.value.(string); ok {
 			ts.append(pad).
 				append(fmt.Sprintf("val: %v\n",
 					s))
@@ -434,7 +504,7 @@ func (ts *treeStringer) baseNode(an *NodeRef, depth int, childNum int, childrenT
 		append("\n")
 }
 
-func (ts *treeStringer) startFromNode(an *NodeRef) {
+func (ts *treeStringer) startFromNode(an NodeRef) {
 	ts.baseNode(an, 0, 0, 0)
 }
 
@@ -491,7 +561,7 @@ DumpNode returns Tree in the human readable format:
 		├── nil
 		└── nil
 */
-func DumpNode(root *NodeRef) string {
+func DumpNode(root NodeRef) string {
 	opts := createTreeStringerOptions(WithRefFormatter(RefAddrFormatter))
 	trs := newTreeStringer(opts)
 	trs.startFromNode(root)
@@ -500,44 +570,93 @@ func DumpNode(root *NodeRef) string {
 
 // treeStringerOptions contains options for DumpTree function.
 type treeStringerOptions struct {
-	storageSize int
-	formatter   refFormatter
+	storageSize    int
+	formatter      RefFormatter
+	valueFormatter func(Value) string // valueFormatter formats the Leaf values, nil for the default format
+	keyFormatter   func(Key) string   // keyFormatter formats the Leaf keys, nil for the default format
+	maxDepth       int                // maxDepth is the number of levels printed below the root, negative for all
+	subtree        Key                // subtree selects the subtree holding the keys with the prefix
+	maxChildren    int                // maxChildren is the number of children printed per Node, 0 for all
 }
 
-// treeStringerOption is a function that sets an option for DumpTree.
-type treeStringerOption func(opts *treeStringerOptions)
+// TreeStringerOption is a function that sets an option for TreeStringer.
+type TreeStringerOption func(opts *treeStringerOptions)
 
 // WithStorageSize sets the size of the storage for depth information.
-func WithStorageSize(size int) treeStringerOption {
+func WithStorageSize(size int) TreeStringerOption {
 	return func(opts *treeStringerOptions) {
 		opts.storageSize = size
 	}
 }
 
 // WithRefFormatter sets the formatter for Node references.
-func WithRefFormatter(formatter refFormatter) treeStringerOption {
+func WithRefFormatter(formatter RefFormatter) TreeStringerOption {
 	return func(opts *treeStringerOptions) {
 		opts.formatter = formatter
 	}
 }
 
+// WithValueFormatter sets the formatter for Leaf values.
+// By default, strings and byte slices are printed as is and other values with fmt.
+func WithValueFormatter(formatter func(Value) string) TreeStringerOption {
+	return func(opts *treeStringerOptions) {
+		opts.valueFormatter = formatter
+	}
+}
+
+// WithKeyFormatter sets the formatter for Leaf keys.
+// By default, keys are printed both as characters and as decimal numbers.
+func WithKeyFormatter(formatter func(Key) string) TreeStringerOption {
+	return func(opts *treeStringerOptions) {
+		opts.keyFormatter = formatter
+	}
+}
+
+// WithMaxDepth limits the output to the nodes at most depth levels below the printed root,
+// the children of the deepest nodes are elided.
+// A negative depth prints the whole tree, it is the default.
+func WithMaxDepth(depth int) TreeStringerOption {
+	return func(opts *treeStringerOptions) {
+		opts.maxDepth = depth
+	}
+}
+
+// WithSubtree prints only the smallest subtree holding all keys with the prefix.
+func WithSubtree(prefix Key) TreeStringerOption {
+	return func(opts *treeStringerOptions) {
+		opts.subtree = prefix
+	}
+}
+
+// WithMaxChildren limits the number of children printed per Node.
+// The empty child slots are skipped and the children past the limit are elided
+// with a "... N more" line. Zero prints all child slots, it is the default.
+func WithMaxChildren(n int) TreeStringerOption {
+	return func(opts *treeStringerOptions) {
+		opts.maxChildren = n
+	}
+}
+
 // TreeStringer returns the string representation of the tree.
 // The tree must be of type *art.tree.
-func TreeStringer(t Tree, opts ...treeStringerOption) string {
+func TreeStringer(t Tree, opts ...TreeStringerOption) string {
 	tr, ok := t.(*tree)
 	if !ok {
 		return "expected *art.tree"
 	}
 
-	trs := newTreeStringer(createTreeStringerOptions(opts...))
-	trs.startFromNode(tr.root)
+	trsOpts := createTreeStringerOptions(opts...)
+	trs := newTreeStringer(trsOpts)
+	root, _ := tr.findPrefixRoot(trsOpts.subtree)
+	trs.startFromNode(root)
 	return trs.String()
 }
 
-func createTreeStringerOptions(opts ...treeStringerOption) treeStringerOptions {
+func createTreeStringerOptions(opts ...TreeStringerOption) treeStringerOptions {
 	defOpts := treeStringerOptions{
 		storageSize: 4096,
 		formatter:   RefShortFormatter,
+		maxDepth:    -1,
 	}
 
 	for _, opt := range opts {
@@ -549,10 +668,11 @@ func createTreeStringerOptions(opts ...treeStringerOption) treeStringerOptions {
 
 func newTreeStringer(opts treeStringerOptions) *treeStringer {
 	return &treeStringer{
+		opts:    opts,
 		storage: make([]depthStorage, opts.storageSize),
 		buf:     bytes.NewBufferString(""),
 		nodeRegistry: &nodeRegistry{
-			ptrToID:   make(map[*NodeRef]int),
+			ptrToID:   make(map[NodeRef]int),
 			formatter: opts.formatter,
 		},
 	}
@@ -2,7 +2,9 @@ package art
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,14 +17,15 @@ func TestTreeStringer(t *testing.T) {
 	tests := []struct {
 		name   string
 		tree   func() *tree
+		opts   []TreeStringerOption
 		golden string
 	}{
 		{
 			name: "Dump4",
 			tree: func() *tree {
-				n4 := factory.newNode4()
-				n4leaf := factory.newLeaf([]byte("key4"), "value4")
-				n4.addChild(keyChar{ch: 'k'}, n4leaf)
+				n4 := factory.NewNode4()
+				n4leaf := factory.NewLeaf([]byte("key4"), "value4")
+				n4.addChild(keyChar{ch: 'k'}, n4leaf, &testOpts)
 				return &tree{root: n4}
 			},
 			golden: "test/stringer/dump4.golden",
@@ -30,9 +33,9 @@ func TestTreeStringer(t *testing.T) {
 		{
 			name: "Dump4BinaryValue",
 			tree: func() *tree {
-				n4 := factory.newNode4()
-				n4leaf := factory.newLeaf([]byte("key4"), []byte("value4"))
-				n4.addChild(keyChar{ch: 'k'}, n4leaf)
+				n4 := factory.NewNode4()
+				n4leaf := factory.NewLeaf([]byte("key4"), []byte("value4"))
+				n4.addChild(keyChar{ch: 'k'}, n4leaf, &testOpts)
 				return &tree{root: n4}
 			},
 			golden: "test/stringer/dump4_binary_value.golden",
@@ -40,9 +43,9 @@ func TestTreeStringer(t *testing.T) {
 		{
 			name: "Dump4Int",
 			tree: func() *tree {
-				n4 := factory.newNode4()
-				n4leaf := factory.newLeaf([]byte("key4"), 4)
-				n4.addChild(keyChar{ch: 'k'}, n4leaf)
+				n4 := factory.NewNode4()
+				n4leaf := factory.NewLeaf([]byte("key4"), 4)
+				n4.addChild(keyChar{ch: 'k'}, n4leaf, &testOpts)
 				return &tree{root: n4}
 			},
 			golden: "test/stringer/dump4_int.golden",
@@ -50,16 +53,16 @@ func TestTreeStringer(t *testing.T) {
 		{
 			name: "Dump16IntValue",
 			tree: func() *tree {
-				n16 := factory.newNode16()
-				n16_2 := factory.newNode16()
-				n16_2leaf := factory.newLeaf([]byte("4yek"), 4)
-				n16_2.addChild(keyChar{ch: 'z'}, n16_2leaf)
-
-				n16leaf := factory.newLeaf([]byte("key4"), 4)
-				c4leaf := factory.newLeaf([]byte("cey4"), 44)
-				n16.addChild(keyChar{ch: 'k'}, n16leaf)
-				n16.addChild(keyChar{ch: 'c'}, c4leaf)
-				n16.addChild(keyChar{ch: 'z'}, n16_2)
+				n16 := factory.NewNode16()
+				n16_2 := factory.NewNode16()
+				n16_2leaf := factory.NewLeaf([]byte("4yek"), 4)
+				n16_2.addChild(keyChar{ch: 'z'}, n16_2leaf, &testOpts)
+
+				n16leaf := factory.NewLeaf([]byte("key4"), 4)
+				c4leaf := factory.NewLeaf([]byte("cey4"), 44)
+				n16.addChild(keyChar{ch: 'k'}, n16leaf, &testOpts)
+				n16.addChild(keyChar{ch: 'c'}, c4leaf, &testOpts)
+				n16.addChild(keyChar{ch: 'z'}, n16_2, &testOpts)
 				return &tree{root: n16}
 			},
 			golden: "test/stringer/dump16_int_value.golden",
@@ -67,9 +70,9 @@ func TestTreeStringer(t *testing.T) {
 		{
 			name: "Dump16",
 			tree: func() *tree {
-				n16 := factory.newNode16()
-				n16leaf := factory.newLeaf([]byte("key16"), "value16")
-				n16.addChild(keyChar{ch: 'k'}, n16leaf)
+				n16 := factory.NewNode16()
+				n16leaf := factory.NewLeaf([]byte("key16"), "value16")
+				n16.addChild(keyChar{ch: 'k'}, n16leaf, &testOpts)
 				return &tree{root: n16}
 			},
 			golden: "test/stringer/dump16.golden",
@@ -77,9 +80,9 @@ func TestTreeStringer(t *testing.T) {
 		{
 			name: "Dump48",
 			tree: func() *tree {
-				n48 := factory.newNode48()
-				n48leaf := factory.newLeaf([]byte("key48"), "value48")
-				n48.addChild(keyChar{ch: 'k'}, n48leaf)
+				n48 := factory.NewNode48()
+				n48leaf := factory.NewLeaf([]byte("key48"), "value48")
+				n48.addChild(keyChar{ch: 'k'}, n48leaf, &testOpts)
 				return &tree{root: n48}
 			},
 			golden: "test/stringer/dump48.golden",
@@ -87,18 +90,45 @@ func TestTreeStringer(t *testing.T) {
 		{
 			name: "Dump256",
 			tree: func() *tree {
-				n256 := factory.newNode256()
-				n256leaf := factory.newLeaf([]byte("key256"), "value256")
-				n256.addChild(keyChar{ch: 'k'}, n256leaf)
+				n256 := factory.NewNode256()
+				n256leaf := factory.NewLeaf([]byte("key256"), "value256")
+				n256.addChild(keyChar{ch: 'k'}, n256leaf, &testOpts)
 				return &tree{root: n256}
 			},
 			golden: "test/stringer/dump256.golden",
 		},
+		{
+			name: "DumpFormatters",
+			tree: newStringerTree,
+			opts: []TreeStringerOption{
+				WithKeyFormatter(func(k Key) string { return strings.ToUpper(string(k)) }),
+				WithValueFormatter(func(v Value) string { return fmt.Sprintf("<%v>", v) }),
+			},
+			golden: "test/stringer/dump_formatters.golden",
+		},
+		{
+			name:   "DumpMaxDepth",
+			tree:   newStringerTree,
+			opts:   []TreeStringerOption{WithMaxDepth(1)},
+			golden: "test/stringer/dump_max_depth.golden",
+		},
+		{
+			name:   "DumpSubtree",
+			tree:   newStringerTree,
+			opts:   []TreeStringerOption{WithSubtree(Key("api/v"))},
+			golden: "test/stringer/dump_subtree.golden",
+		},
+		{
+			name:   "DumpMaxChildren",
+			tree:   newStringerTree,
+			opts:   []TreeStringerOption{WithMaxChildren(2)},
+			golden: "test/stringer/dump_max_children.golden",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			actualOut := TreeStringer(tt.tree())
+			actualOut := TreeStringer(tt.tree(), tt.opts...)
 
 			if *updateGolden {
 				t.Logf("%s: updating golden file %s...", tt.name, tt.golden)
@@ -113,3 +143,52 @@ func TestTreeStringer(t *testing.T) {
 		})
 	}
 }
+
+// newStringerTree creates the tree shared by the TreeStringer options tests.
+func newStringerTree() *tree {
+	tr := newTree()
+	for i, key := range []string{"api/v1", "api/v2", "api/v3", "api/v4", "api/v5", "app", "apple", "b"} {
+		tr.Insert(Key(key), i)
+	}
+
+	return tr
+}
+
+func TestTreeStringerMaxChildrenElided(t *testing.T) {
+	t.Parallel()
+
+	tr := newTree()
+	for i := 0; i < 202; i++ {
+		tr.Insert(Key{byte(i)}, i)
+	}
+
+	out := TreeStringer(tr, WithMaxChildren(2), WithMaxDepth(1))
+	assert.Contains(t, out, "... 200 more")
+	assert.Equal(t, 1, strings.Count(out, "Node256"))
+	assert.Equal(t, 2, strings.Count(out, "Leaf"))
+}
+
+func TestTreeStringerSubtreeNotFound(t *testing.T) {
+	t.Parallel()
+
+	out := TreeStringer(newStringerTree(), WithSubtree(Key("zz")))
+	assert.Equal(t, TreeStringer(newTree()), out)
+}
+
+func TestRefFormatters(t *testing.T) {
+	t.Parallel()
+
+	leaf := factory.NewLeaf(Key("key"), "value")
+	ref := &DumpNodeRef{id: 7, ref: leaf}
+
+	assert.Equal(t, 7, ref.ID())
+	assert.False(t, ref.IsNil())
+	assert.Equal(t, uintptr(leaf.pointer()), ref.Addr())
+	assert.Equal(t, "#7", RefShortFormatter(ref))
+	assert.True(t, (&DumpNodeRef{}).IsNil())
+
+	out := TreeStringer(&tree{root: leaf}, WithRefFormatter(func(r *DumpNodeRef) string {
+		return fmt.Sprintf("ref-%d", r.ID())
+	}))
+	assert.Contains(t, out, "ref-")
+}
@@ -0,0 +1,282 @@
+package art
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Key formats used to render the keys and prefixes by ExportDOT and ExportJSON.
+const (
+	KeyFormatChar    = printValuesAsChar    // KeyFormatChar renders bytes as characters.
+	KeyFormatDecimal = printValuesAsDecimal // KeyFormatDecimal renders bytes as decimal numbers.
+	KeyFormatHex     = printValuesAsHex     // KeyFormatHex renders bytes as hexadecimal numbers.
+)
+
+// exportOptions contains options for ExportDOT and ExportJSON.
+type exportOptions struct {
+	maxDepth  int // maxDepth is the number of levels exported below the root, negative for all
+	prefix    Key // prefix selects the subtree holding the keys with the prefix
+	keyFormat int // keyFormat is one of the KeyFormat* constants
+}
+
+// ExportOption is a function that sets an option for ExportDOT and ExportJSON.
+type ExportOption func(opts *exportOptions)
+
+// WithExportMaxDepth limits the export to the nodes at most depth levels below the exported root.
+// The nodes whose children are cut off are marked as truncated.
+// A negative depth exports the whole tree, it is the default.
+func WithExportMaxDepth(depth int) ExportOption {
+	return func(opts *exportOptions) {
+		opts.maxDepth = depth
+	}
+}
+
+// WithExportPrefix roots the export at the smallest subtree holding all keys with the prefix.
+func WithExportPrefix(prefix Key) ExportOption {
+	return func(opts *exportOptions) {
+		opts.prefix = prefix
+	}
+}
+
+// WithExportKeyFormat sets the format of the keys and the prefixes,
+// one of KeyFormatChar (default), KeyFormatDecimal or KeyFormatHex.
+func WithExportKeyFormat(format int) ExportOption {
+	return func(opts *exportOptions) {
+		opts.keyFormat = format
+	}
+}
+
+func createExportOptions(opts ...ExportOption) exportOptions {
+	defOpts := exportOptions{
+		maxDepth:  -1,
+		keyFormat: KeyFormatChar,
+	}
+
+	for _, opt := range opts {
+		opt(&defOpts)
+	}
+
+	return defOpts
+}
+
+// exportNode is the exported representation of a tree Node.
+type exportNode struct {
+	ID              int          `json:"id"`
+	Kind            string       `json:"kind"`
+	PrefixLen       int          `json:"prefixLen,omitempty"`
+	Prefix          string       `json:"prefix,omitempty"`
+	PrefixTruncated bool         `json:"prefixTruncated,omitempty"` // the prefix is longer than the stored bytes
+	Key             string       `json:"key,omitempty"`
+	Value           string       `json:"value,omitempty"`
+	Children        []exportEdge `json:"children,omitempty"`
+	Truncated       bool         `json:"truncated,omitempty"` // the children are cut off by the depth limit
+}
+
+// exportEdge links a Node to its child stored under the key byte.
+type exportEdge struct {
+	Key  string      `json:"key,omitempty"`
+	Zero bool        `json:"zero,omitempty"` // the child is the zero byte child
+	Node *exportNode `json:"node"`
+}
+
+// exporter builds the exported representation of a tree.
+type exporter struct {
+	opts     exportOptions
+	registry *nodeRegistry
+}
+
+// exportTree returns the exported representation of the tree, nil if it is empty.
+func exportTree(t Tree, opts ...ExportOption) (*exportNode, error) {
+	tr, ok := t.(*tree)
+	if !ok {
+		return nil, ErrUnsupportedTree
+	}
+
+	exp := &exporter{
+		opts:     createExportOptions(opts...),
+		registry: &nodeRegistry{ptrToID: make(map[NodeRef]int), formatter: RefShortFormatter},
+	}
+
+	root, _ := tr.findPrefixRoot(exp.opts.prefix)
+	if root.isNil() {
+		return nil, nil //nolint:nilnil
+	}
+
+	return exp.node(root, 0), nil
+}
+
+// node exports the Node located level levels below the exported root.
+func (exp *exporter) node(nr NodeRef, level int) *exportNode {
+	en := &exportNode{
+		ID:   exp.registry.register(nr).id,
+		Kind: kindName(nr.kind()),
+	}
+
+	if nr.isLeaf() {
+		leaf := nr.Leaf()
+		en.Key = formatBytes(leaf.key, exp.opts.keyFormat)
+		en.Value = formatValue(leaf.value)
+
+		return en
+	}
+
+	n := nr.node()
+	en.PrefixLen = int(n.prefixLen)
+	en.Prefix = formatBytes(n.storedPrefix(), exp.opts.keyFormat)
+	en.PrefixTruncated = !n.hasFullPrefix()
+
+	if exp.opts.maxDepth >= 0 && level >= exp.opts.maxDepth {
+		en.Truncated = true
+
+		return en
+	}
+
+	for _, ref := range nr.childRefs() {
+		edge := exportEdge{Zero: ref.kc.invalid}
+		if !ref.kc.invalid {
+			edge.Key = formatBytes([]byte{ref.kc.ch}, exp.opts.keyFormat)
+		}
+
+		edge.Node = exp.node(ref.ref, level+1)
+		en.Children = append(en.Children, edge)
+	}
+
+	return en
+}
+
+// kindName returns the Kind name without the Kind suffix.
+func kindName(kind Kind) string {
+	return strings.TrimSuffix(kind.String(), "Kind")
+}
+
+// formatBytes formats the bytes with one of the KeyFormat* constants.
+func formatBytes(b []byte, format int) string {
+	var sb strings.Builder
+
+	for i, c := range b {
+		switch {
+		case format&KeyFormatDecimal != 0:
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+
+			fmt.Fprintf(&sb, "%d", c)
+		case format&KeyFormatHex != 0:
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+
+			fmt.Fprintf(&sb, "%02x", c)
+		case c == 0:
+			sb.WriteString("·")
+		default:
+			sb.WriteRune(rune(c))
+		}
+	}
+
+	return sb.String()
+}
+
+// formatValue formats the Leaf value, strings and byte slices are written as is.
+func formatValue(v Value) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// ExportJSON writes the structure of the tree to w as a JSON document.
+// Each Node is an object with its registry id, its kind, its prefix and either
+// the Leaf key and value or the list of children along with their key bytes.
+// An empty tree is written as null.
+// Only trees created by New are supported, otherwise ErrUnsupportedTree is returned.
+func ExportJSON(w io.Writer, t Tree, opts ...ExportOption) error {
+	root, err := exportTree(t, opts...)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(root)
+}
+
+// ExportDOT writes the structure of the tree to w in the Graphviz DOT format.
+// The edges are labeled with the child key bytes, ∅ marks the zero byte child.
+// A prefix longer than its stored bytes ends with …, the nodes whose children
+// are cut off by WithExportMaxDepth are linked to a dashed … node.
+// Only trees created by New are supported, otherwise ErrUnsupportedTree is returned.
+func ExportDOT(w io.Writer, t Tree, opts ...ExportOption) error {
+	root, err := exportTree(t, opts...)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("digraph art {\n")
+	buf.WriteString("\tnode [shape=box, fontname=monospace];\n")
+
+	if root != nil {
+		writeDOTNode(&buf, root)
+	}
+
+	buf.WriteString("}\n")
+
+	_, err = w.Write(buf.Bytes())
+
+	return err
+}
+
+// writeDOTNode writes the Node, its children and the edges to them.
+func writeDOTNode(buf *bytes.Buffer, en *exportNode) {
+	label := fmt.Sprintf("%s #%d", en.Kind, en.ID)
+
+	if en.Kind == kindName(LeafKind) {
+		label += fmt.Sprintf("\nkey: %s\nval: %s", en.Key, en.Value)
+	} else if en.PrefixLen > 0 {
+		label += fmt.Sprintf("\nprefix(%d): %s", en.PrefixLen, en.Prefix)
+		if en.PrefixTruncated {
+			label += "…"
+		}
+	}
+
+	fmt.Fprintf(buf, "\tn%d [label=%s];\n", en.ID, dotQuote(label))
+
+	for _, edge := range en.Children {
+		writeDOTNode(buf, edge.Node)
+
+		key := edge.Key
+		if edge.Zero {
+			key = "∅"
+		}
+
+		fmt.Fprintf(buf, "\tn%d -> n%d [label=%s];\n", en.ID, edge.Node.ID, dotQuote(key))
+	}
+
+	if en.Truncated {
+		fmt.Fprintf(buf, "\tn%d_more [label=\"…\", shape=plaintext];\n", en.ID)
+		fmt.Fprintf(buf, "\tn%d -> n%d_more [style=dashed];\n", en.ID, en.ID)
+	}
+}
+
+// dotQuote returns the DOT quoted string, the new lines are rendered as left-justified lines.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\l`)
+
+	if strings.Contains(s, `\l`) {
+		s += `\l`
+	}
+
+	return `"` + s + `"`
+}
@@ -0,0 +1,110 @@
+package art
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportTestTree() Tree {
+	tree := New()
+	for _, k := range []string{"a", "ab", "abcdefghijklmnopqrs1", "abcdefghijklmnopqrs2", "b\"q"} {
+		tree.Insert(Key(k), k)
+	}
+
+	return tree
+}
+
+func TestTreeExport(t *testing.T) {
+	tests := []struct {
+		name   string
+		export func(buf *bytes.Buffer) error
+		golden string
+	}{
+		{
+			name:   "DOT",
+			export: func(buf *bytes.Buffer) error { return ExportDOT(buf, exportTestTree()) },
+			golden: "test/export/tree.dot.golden",
+		},
+		{
+			name: "DOTMaxDepth",
+			export: func(buf *bytes.Buffer) error {
+				return ExportDOT(buf, exportTestTree(), WithExportMaxDepth(1))
+			},
+			golden: "test/export/tree_max_depth.dot.golden",
+		},
+		{
+			name:   "JSON",
+			export: func(buf *bytes.Buffer) error { return ExportJSON(buf, exportTestTree()) },
+			golden: "test/export/tree.json.golden",
+		},
+		{
+			name: "JSONPrefixHex",
+			export: func(buf *bytes.Buffer) error {
+				return ExportJSON(buf, exportTestTree(), WithExportPrefix(Key("abc")), WithExportKeyFormat(KeyFormatHex))
+			},
+			golden: "test/export/tree_prefix_hex.json.golden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, tt.export(&buf))
+
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(tt.golden, buf.Bytes(), 0o644)) //nolint:gosec
+			}
+
+			goldenOut, err := os.ReadFile(tt.golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(goldenOut), buf.String())
+		})
+	}
+}
+
+func TestTreeExportJSONStructure(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportJSON(&buf, exportTestTree(), WithExportPrefix(Key("ab")), WithExportMaxDepth(1)))
+
+	var root exportNode
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &root))
+
+	assert.Equal(t, "Node4", root.Kind)
+	require.Len(t, root.Children, 2)
+
+	assert.True(t, root.Children[0].Zero)
+	assert.Equal(t, "Leaf", root.Children[0].Node.Kind)
+	assert.Equal(t, "ab", root.Children[0].Node.Key)
+
+	assert.Equal(t, "c", root.Children[1].Key)
+	assert.Equal(t, 16, root.Children[1].Node.PrefixLen)
+	assert.True(t, root.Children[1].Node.PrefixTruncated)
+	assert.True(t, root.Children[1].Node.Truncated)
+	assert.Empty(t, root.Children[1].Node.Children)
+}
+
+func TestTreeExportEmpty(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportJSON(&buf, New()))
+	assert.Equal(t, "null\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, ExportJSON(&buf, exportTestTree(), WithExportPrefix(Key("zz"))))
+	assert.Equal(t, "null\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, ExportDOT(&buf, New()))
+	assert.Equal(t, "digraph art {\n\tnode [shape=box, fontname=monospace];\n}\n", buf.String())
+
+	assert.ErrorIs(t, ExportDOT(&buf, NewCompact()), ErrUnsupportedTree)
+	assert.ErrorIs(t, ExportJSON(&buf, NewCompact()), ErrUnsupportedTree)
+}
@@ -0,0 +1,188 @@
+package art
+
+import "unicode/utf8"
+
+// fuzzyOptions contains options for FuzzySearch.
+type fuzzyOptions struct {
+	runes bool // runes enables the edit distance in UTF-8 runes
+}
+
+func createFuzzyOptions(opts ...FuzzyOption) fuzzyOptions {
+	var defOpts fuzzyOptions
+
+	for _, opt := range opts {
+		opt(&defOpts)
+	}
+
+	return defOpts
+}
+
+// levenshtein is a Levenshtein automaton accepting the keys within maxEdits of the query.
+// Its state after consuming n symbols of a key is the row n of the edit distance matrix,
+// the rows are kept for the whole path so that sibling subtrees share the state of their parent.
+type levenshtein struct {
+	query    []rune  // query symbols, the bytes are stored as runes when the distance is counted in bytes
+	maxEdits int     // maxEdits is the maximum accepted edit distance
+	runes    bool    // runes indicates the keys are decoded as UTF-8
+	rows     [][]int // rows[n] is the edit distance row after n consumed symbols
+	rowMins  []int   // rowMins[n] is the minimum of rows[n]
+}
+
+// levenshteinState is the position of the automaton along a key.
+type levenshteinState struct {
+	symbols    int               // symbols is the number of consumed symbols
+	pending    [utf8.UTFMax]byte // pending holds the bytes of an incomplete rune
+	pendingLen int               // pendingLen is the number of pending bytes
+}
+
+func newLevenshtein(query Key, maxEdits int, opts fuzzyOptions) *levenshtein {
+	lev := &levenshtein{
+		maxEdits: maxEdits,
+		runes:    opts.runes,
+	}
+
+	if opts.runes {
+		lev.query = []rune(string(query))
+	} else {
+		lev.query = make([]rune, len(query))
+		for i, b := range query {
+			lev.query[i] = rune(b)
+		}
+	}
+
+	first := make([]int, len(lev.query)+1)
+	for i := range first {
+		first[i] = i
+	}
+
+	lev.rows = [][]int{first}
+	lev.rowMins = []int{0}
+
+	return lev
+}
+
+// step computes the row following the consumed symbols and reports whether the state is alive.
+func (lev *levenshtein) step(st *levenshteinState, sym rune) bool {
+	next := st.symbols + 1
+	if next == len(lev.rows) {
+		lev.rows = append(lev.rows, make([]int, len(lev.query)+1))
+		lev.rowMins = append(lev.rowMins, 0)
+	}
+
+	prev, cur := lev.rows[st.symbols], lev.rows[next]
+	cur[0] = prev[0] + 1
+	rowMin := cur[0]
+
+	for i, q := range lev.query {
+		cost := ternary(q == sym, 0, 1)
+		cur[i+1] = minInt(minInt(prev[i+1]+1, cur[i]+1), prev[i]+cost)
+		rowMin = minInt(rowMin, cur[i+1])
+	}
+
+	lev.rowMins[next] = rowMin
+	st.symbols = next
+
+	return rowMin <= lev.maxEdits
+}
+
+// consume feeds the bytes to the automaton, it returns false as soon as the state is dead.
+func (lev *levenshtein) consume(st *levenshteinState, b []byte) bool {
+	for _, c := range b {
+		if !lev.runes {
+			if !lev.step(st, rune(c)) {
+				return false
+			}
+
+			continue
+		}
+
+		st.pending[st.pendingLen] = c
+		st.pendingLen++
+
+		if pending := st.pending[:st.pendingLen]; utf8.FullRune(pending) {
+			r, size := utf8.DecodeRune(pending)
+			st.pendingLen = copy(st.pending[:], pending[size:])
+
+			if !lev.step(st, r) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// accepts reports whether the key ending at the state is within the maximum edit distance.
+// The incomplete rune left at the end of the key counts as a single utf8.RuneError.
+func (lev *levenshtein) accepts(st levenshteinState) bool {
+	if st.pendingLen > 0 && !lev.step(&st, utf8.RuneError) {
+		return false
+	}
+
+	return lev.rows[st.symbols][len(lev.query)] <= lev.maxEdits
+}
+
+// matches reports whether the key is within the maximum edit distance of the query.
+func (lev *levenshtein) matches(key Key) bool {
+	var st levenshteinState
+
+	return lev.consume(&st, key) && lev.accepts(st)
+}
+
+// FuzzySearch invokes the callback for the keys within maxEdits of the query.
+// The automaton consumes the Node prefixes and the child key bytes along the descent,
+// a subtree is skipped as soon as no edit can bring its keys within maxEdits.
+func (tr *tree) FuzzySearch(query Key, maxEdits int, cb Callback, opts ...FuzzyOption) {
+	if maxEdits < 0 {
+		return
+	}
+
+	lev := newLevenshtein(query, maxEdits, createFuzzyOptions(opts...))
+	tr.fuzzySearchRecursively(lev, tr.root, nil, levenshteinState{}, cb)
+}
+
+// fuzzySearchRecursively matches the subtree whose path led the automaton to the state.
+// The path is extended in place, the siblings of a Node reuse the bytes of its path.
+func (tr *tree) fuzzySearchRecursively(
+	lev *levenshtein,
+	nr NodeRef,
+	path Key,
+	st levenshteinState,
+	cb Callback,
+) traverseAction {
+	if nr.isNil() {
+		return traverseContinue
+	}
+
+	if nr.isLeaf() {
+		if lev.consume(&st, tr.leafSuffix(nr.Leaf(), len(path))) && lev.accepts(st) && !cb(tr.nodeKV(nr, path)) {
+			return traverseStop
+		}
+
+		return traverseContinue
+	}
+
+	prefix := nr.fullPrefix(len(path))
+	if !lev.consume(&st, prefix) {
+		return traverseContinue
+	}
+
+	path = append(path, prefix...)
+
+	for _, ref := range nr.childRefs() {
+		childSt, childPath := st, path
+		if !ref.kc.invalid {
+			childPath = append(path, ref.kc.ch)
+
+			if !lev.consume(&childSt, []byte{ref.kc.ch}) {
+				continue
+			}
+		}
+
+		if tr.fuzzySearchRecursively(lev, ref.ref, childPath, childSt, cb) == traverseStop {
+			return traverseStop
+		}
+	}
+
+	return traverseContinue
+}
@@ -0,0 +1,168 @@
+package art
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// editDistance is the reference Levenshtein distance between the symbol sequences.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		cur[0] = i + 1
+
+		for j := range b {
+			cost := ternary(a[i] == b[j], 0, 1)
+			cur[j+1] = minInt(minInt(prev[j+1]+1, cur[j]+1), prev[j]+cost)
+		}
+
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
+
+func byteSymbols(b []byte) []rune {
+	symbols := make([]rune, len(b))
+	for i, c := range b {
+		symbols[i] = rune(c)
+	}
+
+	return symbols
+}
+
+func TestTreeFuzzySearch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		query    string
+		maxEdits int
+		expected []string
+	}{
+		{"Exact", "apple", 0, []string{"apple"}},
+		{"Substitution", "appla", 1, []string{"apple", "apply"}},
+		{"InsertionDeletion", "aple", 1, []string{"ape", "apple"}},
+		{"TwoEdits", "app", 2, []string{"a", "ape", "apple", "apply"}},
+		{"Negative", "apple", -1, nil},
+		{"NoMatch", "zzzzz", 1, nil},
+	}
+
+	for _, e := range engines {
+		e := e
+		tree := e.newTree()
+
+		for _, k := range []string{"a", "ape", "apple", "apply", "applesauce", "bat", "battle"} {
+			tree.Insert(Key(k), k)
+		}
+
+		for _, tt := range tests {
+			tt := tt
+			t.Run(e.name+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				var actual []string
+
+				tree.FuzzySearch(Key(tt.query), tt.maxEdits, func(node NodeKV) bool {
+					actual = append(actual, string(node.Key()))
+
+					return true
+				})
+
+				assert.Equal(t, tt.expected, actual)
+			})
+		}
+	}
+}
+
+func TestTreeFuzzySearchStop(t *testing.T) {
+	t.Parallel()
+
+	for _, e := range engines {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := e.newTree()
+			for _, k := range []string{"cat", "cot", "cut", "dog"} {
+				tree.Insert(Key(k), k)
+			}
+
+			calls := 0
+			tree.FuzzySearch(Key("cxt"), 1, func(NodeKV) bool {
+				calls++
+
+				return false
+			})
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
+
+func TestTreeFuzzySearchWords(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		file  string
+		query string
+		runes bool
+	}{
+		{"Bytes", "test/assets/words.txt", "algoritm", false},
+		{"BytesShort", "test/assets/words.txt", "tre", false},
+		{"BytesHSK", "test/assets/hsk_words.txt", "你好", false},
+		{"Runes", "test/assets/hsk_words.txt", "你好", true},
+		{"RunesLong", "test/assets/hsk_words.txt", "图书馆", true},
+	}
+
+	for _, e := range engines {
+		e := e
+
+		for _, tt := range tests {
+			tt := tt
+			t.Run(e.name+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				words := loadTestFile(tt.file)
+				tree := e.newTree()
+
+				for _, w := range words {
+					tree.Insert(w, w)
+				}
+
+				var opts []FuzzyOption
+
+				symbols := byteSymbols
+				if tt.runes {
+					opts = append(opts, WithFuzzyRunes())
+					symbols = func(b []byte) []rune { return []rune(string(b)) }
+				}
+
+				for maxEdits := 0; maxEdits <= 2; maxEdits++ {
+					expected := make(map[string]bool)
+
+					for _, w := range words {
+						if editDistance(symbols(w), symbols([]byte(tt.query))) <= maxEdits {
+							expected[string(w)] = true
+						}
+					}
+
+					actual := make(map[string]bool)
+					tree.FuzzySearch(Key(tt.query), maxEdits, func(node NodeKV) bool {
+						actual[string(node.Key())] = true
+
+						return true
+					}, opts...)
+
+					assert.Equal(t, expected, actual, "maxEdits=%d", maxEdits)
+				}
+			})
+		}
+	}
+}
@@ -1,11 +1,11 @@
 package art
 
 // insertRecursively inserts a new key-value pair into the tree.
-// nrp means NodeKV Reference Pointer.
-func (tr *tree) insertRecursively(nrp **NodeRef, key Key, value Value, keyOffset int) (Value, treeOpResult) {
+// nrp means NodeKV Reference Pointer, it points to the child slot holding the Node.
+func (tr *tree) insertRecursively(nrp *NodeRef, key Key, value Value, keyOffset int) (Value, treeOpResult) {
 	nr := *nrp
-	if nr == nil {
-		return tr.insertNewLeaf(nrp, key, value)
+	if nr.isNil() {
+		return tr.insertNewLeaf(nrp, key, value, keyOffset)
 	}
 
 	if nr.isLeaf() {
@@ -15,16 +15,17 @@ func (tr *tree) insertRecursively(nrp **NodeRef, key Key, value Value, keyOffset
 	return tr.handleNodeInsertion(nrp, key, value, keyOffset)
 }
 
-func (tr *tree) insertNewLeaf(nrp **NodeRef, key Key, value Value) (Value, treeOpResult) {
-	replaceRef(nrp, factory.newLeaf(key, value))
+func (tr *tree) insertNewLeaf(nrp *NodeRef, key Key, value Value, keyOffset int) (Value, treeOpResult) {
+	replaceRef(nrp, tr.opts.factory.NewLeaf(tr.leafPart(key, keyOffset), value))
+	tr.opts.track(*nrp)
 
 	return nil, treeOpInserted
 }
 
-func (tr *tree) handleLeafInsertion(nrp **NodeRef, key Key, value Value, keyOffset int) (Value, treeOpResult) {
+func (tr *tree) handleLeafInsertion(nrp *NodeRef, key Key, value Value, keyOffset int) (Value, treeOpResult) {
 	nr := *nrp
 
-	if leaf := nr.Leaf(); leaf.Match(key) {
+	if leaf := nr.Leaf(); leaf.Match(tr.leafPart(key, keyOffset)) {
 		oldValue := leaf.value
 		leaf.value = value
 
@@ -36,23 +37,31 @@ func (tr *tree) handleLeafInsertion(nrp **NodeRef, key Key, value Value, keyOffs
 	return tr.splitLeaf(nrp, key, value, keyOffset)
 }
 
-func (tr *tree) splitLeaf(nrpCurLeaf **NodeRef, key Key, value Value, keyOffset int) (Value, treeOpResult) {
+func (tr *tree) splitLeaf(nrpCurLeaf *NodeRef, key Key, value Value, keyOffset int) (Value, treeOpResult) {
 	nrCurLeaf := *nrpCurLeaf
-	curLeaf := nrCurLeaf.Leaf()
+	curKey := tr.leafKey(nrCurLeaf.Leaf(), key[:keyOffset])
 
-	keysLCP := findLongestCommonPrefix(curLeaf.key, key, keyOffset)
+	// the old LeafKind may be relocated, it is tracked again below the new Node4
+	tr.opts.untrack(nrCurLeaf)
+
+	keysLCP := findLongestCommonPrefix(curKey, key, keyOffset)
 
 	// Create a new Node4 with the longest common prefix
 	// between the old LeafKind and the new LeafKind key.
-	nr4 := factory.newNode4()
-	nr4.setPrefix(key[keyOffset:], keysLCP)
+	nr4 := tr.opts.factory.NewNode4()
+	nr4.setPrefix(key[keyOffset:], keysLCP, tr.opts.maxPrefixLen)
+	tr.opts.track(nr4)
 	keyOffset += keysLCP
 
 	// branch by the first differing character
 	// add the old LeafKind and the new LeafKind as children
-	// to a newly created Node4.
-	nr4.addChild(curLeaf.key.charAt(keyOffset), nrCurLeaf)           // old LeafKind
-	nr4.addChild(key.charAt(keyOffset), factory.newLeaf(key, value)) // new LeafKind
+	// to a newly created Node4, the leaves storing key suffixes keep the bytes below it.
+	newLeaf := tr.opts.factory.NewLeaf(tr.leafPart(key, keyOffset+1), value)
+	curLeaf := trimLeaf(nrCurLeaf, keysLCP+1, &tr.opts)
+	tr.opts.track(newLeaf)
+	tr.opts.track(curLeaf)
+	nr4.addChild(curKey.charAt(keyOffset), curLeaf, &tr.opts) // old LeafKind
+	nr4.addChild(key.charAt(keyOffset), newLeaf, &tr.opts)    // new LeafKind
 
 	// replace the old LeafKind with the new Node4
 	replaceRef(nrpCurLeaf, nr4)
@@ -60,10 +69,13 @@ func (tr *tree) splitLeaf(nrpCurLeaf **NodeRef, key Key, value Value, keyOffset
 	return nil, treeOpInserted
 }
 
-func (tr *tree) handleNodeInsertion(nrp **NodeRef, key Key, value Value, keyOffset int) (Value, treeOpResult) {
+func (tr *tree) handleNodeInsertion(nrp *NodeRef, key Key, value Value, keyOffset int) (Value, treeOpResult) {
 	nr := *nrp
 
+	// the key is stored in the subtree of the Node, whichever way it is inserted
 	n := nr.node()
+	n.suffixes |= suffixBit(key)
+
 	if n.prefixLen > 0 {
 		prefixMismatchIdx := nr.matchDeep(key, keyOffset)
 		if prefixMismatchIdx < int(n.prefixLen) {
@@ -76,49 +88,59 @@ func (tr *tree) handleNodeInsertion(nrp **NodeRef, key Key, value Value, keyOffs
 	return tr.continueInsertion(nrp, key, value, keyOffset)
 }
 
-func (tr *tree) splitNode(nrp **NodeRef, key Key, value Value, keyOffset int, mismatchIdx int) (Value, treeOpResult) {
+func (tr *tree) splitNode(nrp *NodeRef, key Key, value Value, keyOffset int, mismatchIdx int) (Value, treeOpResult) {
 	nr := *nrp
-	n := nr.node()
 
-	nr4 := factory.newNode4()
-	nr4.setPrefix(n.prefix[:], mismatchIdx)
+	// the key matches the Node prefix up to the mismatch index
+	nr4 := tr.opts.factory.NewNode4()
+	nr4.setPrefix(key[keyOffset:], mismatchIdx, tr.opts.maxPrefixLen)
+	tr.opts.track(nr4)
 
-	tr.reassignPrefix(nr4, nr, key, value, keyOffset, mismatchIdx)
+	tr.reassignPrefix(&nr4, nr, key, value, keyOffset, mismatchIdx)
 
 	replaceRef(nrp, nr4)
 
 	return nil, treeOpInserted
 }
 
-func (tr *tree) reassignPrefix(newNRP *NodeRef, curNRP *NodeRef, key Key, value Value, keyOffset int, mismatchIdx int) {
+func (tr *tree) reassignPrefix(newNRP *NodeRef, curNRP NodeRef, key Key, value Value, keyOffset int, mismatchIdx int) {
 	curNode := curNRP.node()
-	curNode.prefixLen -= uint16(mismatchIdx + 1) //#nosec:G115
-
-	idx := keyOffset + mismatchIdx
+	prefixLen := int(curNode.prefixLen) - mismatchIdx - 1
+
+	// the rest of the prefix is taken from the stored prefix if it is complete,
+	// otherwise from the minimum Leaf key
+	var curPrefix []byte
+	if curNode.hasFullPrefix() {
+		curPrefix = curNode.storedPrefix()
+	} else {
+		curPrefix = curNRP.minimum().key[keyOffset:]
+	}
 
 	// Adjust prefix and add children
-	leaf := curNRP.minimum()
-	newNRP.addChild(leaf.key.charAt(idx), curNRP)
+	newNRP.addChild(keyChar{ch: curPrefix[mismatchIdx]}, curNRP, &tr.opts)
+	tr.opts.untrack(curNRP)
+	curNRP.setPrefix(curPrefix[mismatchIdx+1:], prefixLen, tr.opts.maxPrefixLen)
+	tr.opts.track(curNRP)
 
-	for i := 0; i < minInt(int(curNode.prefixLen), maxPrefixLen); i++ {
-		curNode.prefix[i] = leaf.key[keyOffset+mismatchIdx+i+1]
-	}
+	idx := keyOffset + mismatchIdx
 
 	// Insert the new LeafKind
-	newNRP.addChild(key.charAt(idx), factory.newLeaf(key, value))
+	newLeaf := tr.opts.factory.NewLeaf(tr.leafPart(key, idx+1), value)
+	tr.opts.track(newLeaf)
+	newNRP.addChild(key.charAt(idx), newLeaf, &tr.opts)
 }
 
-func (tr *tree) continueInsertion(nrp **NodeRef, key Key, value Value, keyOffset int) (Value, treeOpResult) {
-	nr := *nrp
-
-	nextNRP := nr.findChildByKey(key, keyOffset)
-	if *nextNRP != nil {
+func (tr *tree) continueInsertion(nrp *NodeRef, key Key, value Value, keyOffset int) (Value, treeOpResult) {
+	nextNRP := nrp.findChildByKey(key, keyOffset)
+	if !nextNRP.isNil() {
 		// Found a partial Match, continue inserting
 		return tr.insertRecursively(nextNRP, key, value, keyOffset+1)
 	}
 
 	// No child found, create a new LeafKind Node
-	nr.addChild(key.charAt(keyOffset), factory.newLeaf(key, value))
+	newLeaf := tr.opts.factory.NewLeaf(tr.leafPart(key, keyOffset+1), value)
+	tr.opts.track(newLeaf)
+	nrp.addChild(key.charAt(keyOffset), newLeaf, &tr.opts)
 
 	return nil, treeOpInserted
 }
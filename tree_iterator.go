@@ -1,6 +1,9 @@
 package art
 
-import "errors"
+import (
+	"bytes"
+	"errors"
+)
 
 // state represents the iteration state during tree traversal.
 type state struct {
@@ -33,11 +36,11 @@ func (s *state) discard() {
 // iteratorContext represents the context of the tree iterator for one Node.
 type iteratorContext struct {
 	nextChildFn traverseFunc
-	children    []*NodeRef
+	children    []NodeRef
 }
 
 // newIteratorContext creates a new iterator context for the given Node.
-func newIteratorContext(nr *NodeRef, reverse bool) *iteratorContext {
+func newIteratorContext(nr NodeRef, reverse bool) *iteratorContext {
 	return &iteratorContext{
 		nextChildFn: newTraverseFunc(nr, reverse),
 		children:    toNode(nr).allChildren(),
@@ -45,28 +48,28 @@ func newIteratorContext(nr *NodeRef, reverse bool) *iteratorContext {
 }
 
 // next returns the next Node reference and a flag indicating if there are more nodes.
-func (ic *iteratorContext) next() (*NodeRef, bool) {
+func (ic *iteratorContext) next() (NodeRef, bool) {
 	for {
 		idx, ok := ic.nextChildFn()
 		if !ok {
 			break
 		}
 
-		if child := ic.children[idx]; child != nil {
+		if child := ic.children[idx]; !child.isNil() {
 			return child, true
 		}
 	}
 
-	return nil, false
+	return NodeRef{}, false
 }
 
 // iterator is a struct for tree traversal iteration.
 type iterator struct {
-	version  int      // tree version at the time of iterator creation
-	tree     *tree    // tree to iterate
-	state    *state   // iteration state
-	nextNode *NodeRef // next Node to iterate
-	reverse  bool     // indicates if the iteration is in reverse order
+	version  int     // tree version at the time of iterator creation
+	tree     *tree   // tree to iterate
+	state    *state  // iteration state
+	nextNode NodeRef // next Node to iterate
+	reverse  bool    // indicates if the iteration is in reverse order
 }
 
 // assert that iterator implements the Iterator interface.
@@ -74,15 +77,37 @@ var _ Iterator = (*iterator)(nil)
 
 // newTreeIterator creates a new tree iterator.
 func newTreeIterator(tr *tree, opts traverseOpts) Iterator {
-	state := &state{}
-	state.push(newIteratorContext(tr.root, opts.hasReverse()))
+	if opts.hasResilient() {
+		it := &pathIterator{
+			version:  tr.version,
+			tree:     tr,
+			nextNode: tr.root,
+			reverse:  opts.hasReverse(),
+		}
+
+		return &resilientIterator{opts: opts, it: it, tree: tr}
+	}
+
+	var it Iterator
 
-	it := &iterator{
-		version:  tr.version,
-		tree:     tr,
-		nextNode: tr.root,
-		state:    state,
-		reverse:  opts.hasReverse(),
+	if tr.opts.leafSuffixes {
+		it = &pathIterator{
+			version:  tr.version,
+			tree:     tr,
+			nextNode: tr.root,
+			reverse:  opts.hasReverse(),
+		}
+	} else {
+		state := &state{}
+		state.push(newIteratorContext(tr.root, opts.hasReverse()))
+
+		it = &iterator{
+			version:  tr.version,
+			tree:     tr,
+			nextNode: tr.root,
+			state:    state,
+			reverse:  opts.hasReverse(),
+		}
 	}
 
 	if opts&TraverseAll == TraverseAll {
@@ -107,7 +132,7 @@ func (it *iterator) hasConcurrentModification() bool {
 
 // HasNext returns true if there are more nodes to iterate.
 func (it *iterator) HasNext() bool {
-	return it.nextNode != nil
+	return !it.nextNode.isNil()
 }
 
 // Next returns the next Node and an error if any.
@@ -133,7 +158,7 @@ func (it *iterator) next() {
 	for {
 		ctx, ok := it.state.current()
 		if !ok {
-			it.nextNode = nil // no more nodes to iterate
+			it.nextNode = NodeRef{} // no more nodes to iterate
 
 			return
 		}
@@ -150,6 +175,274 @@ func (it *iterator) next() {
 	}
 }
 
+// pathFrame holds the children left to visit of a Node visited by the pathIterator.
+type pathFrame struct {
+	path Key        // path is the path of the Node, its prefix included
+	refs []childRef // refs are the children left to visit in ascending key order
+}
+
+// pathIterator iterates over all nodes of a tree storing the key suffixes in pre-order,
+// it tracks the path of the visited nodes to restore the keys of the leaves.
+type pathIterator struct {
+	version  int         // tree version at the time of iterator creation
+	tree     *tree       // tree to iterate
+	stack    []pathFrame // stack holds the children left to visit of each visited Node
+	nextNode NodeRef     // next Node to iterate
+	nextPath Key         // nextPath is the path of the next Node
+	reverse  bool        // indicates if the iteration is in reverse order
+}
+
+// assert that pathIterator implements the Iterator interface.
+var _ Iterator = (*pathIterator)(nil)
+
+// HasNext returns true if there are more nodes to iterate.
+func (it *pathIterator) HasNext() bool {
+	return !it.nextNode.isNil()
+}
+
+// Next returns the next Node and an error if any.
+// It returns ErrNoMoreNodes if there are no more nodes to iterate.
+// It returns ErrConcurrentModification if the tree has been modified concurrently.
+func (it *pathIterator) Next() (NodeKV, error) {
+	if !it.HasNext() {
+		return nil, ErrNoMoreNodes
+	}
+
+	if it.version != it.tree.version {
+		return nil, ErrConcurrentModification
+	}
+
+	current, path := it.nextNode, it.nextPath
+	it.next(current, path)
+
+	return it.tree.nodeKV(current, path), nil
+}
+
+// next moves the iterator to the Node following the current one.
+func (it *pathIterator) next(current NodeRef, path Key) {
+	if !current.isLeaf() {
+		it.stack = append(it.stack, pathFrame{
+			path: append(path[:len(path):len(path)], current.fullPrefix(len(path))...),
+			refs: current.childRefs(),
+		})
+	}
+
+	it.advance()
+}
+
+// advance moves the iterator to the next child left to visit.
+func (it *pathIterator) advance() {
+	for last := len(it.stack) - 1; last >= 0; last = len(it.stack) - 1 {
+		frame := &it.stack[last]
+		if len(frame.refs) == 0 {
+			it.stack = it.stack[:last]
+
+			continue
+		}
+
+		var ref childRef
+		if it.reverse {
+			ref, frame.refs = frame.refs[len(frame.refs)-1], frame.refs[:len(frame.refs)-1]
+		} else {
+			ref, frame.refs = frame.refs[0], frame.refs[1:]
+		}
+
+		it.nextNode, it.nextPath = ref.ref, frame.path
+		if !ref.kc.invalid {
+			it.nextPath = append(frame.path[:len(frame.path):len(frame.path)], ref.kc.ch)
+		}
+
+		return
+	}
+
+	it.nextNode = NodeRef{}
+}
+
+// seekAfter moves the iterator to the first Node following the key in the iteration order,
+// the nodes on the path of the key, which precede it, are not visited.
+func (it *pathIterator) seekAfter(key Key) {
+	it.stack, it.nextNode, it.nextPath = it.stack[:0], NodeRef{}, nil
+
+	nr, path := it.tree.root, Key(nil)
+	for !nr.isNil() {
+		if nr.isLeaf() {
+			if it.follows(bytes.Compare(it.tree.leafKey(nr.Leaf(), path), key)) {
+				it.nextNode, it.nextPath = nr, path
+			}
+
+			break
+		}
+
+		// the whole subtree follows or precedes the key unless the key extends its path
+		nodePath := append(path[:len(path):len(path)], nr.fullPrefix(len(path))...)
+		if !bytes.HasPrefix(key, nodePath) {
+			if it.follows(bytes.Compare(nodePath, key)) {
+				it.nextNode, it.nextPath = nr, path
+			}
+
+			break
+		}
+
+		// keep the children following the key character, the child under it is visited next
+		kc := key.charAt(len(nodePath))
+
+		var (
+			child NodeRef
+			refs  []childRef
+		)
+
+		for _, ref := range nr.childRefs() {
+			switch {
+			case ref.kc == kc:
+				child = ref.ref
+			case (ref.kc.invalid || (!kc.invalid && ref.kc.ch < kc.ch)) == it.reverse:
+				refs = append(refs, ref)
+			}
+		}
+
+		it.stack = append(it.stack, pathFrame{path: nodePath, refs: refs})
+
+		nr, path = child, nodePath
+		if !kc.invalid {
+			path = append(nodePath[:len(nodePath):len(nodePath)], kc.ch)
+		}
+	}
+
+	if it.nextNode.isNil() {
+		it.advance()
+	}
+}
+
+// follows reports whether the result of comparing a key to another one places it after the other one
+// in the iteration order.
+func (it *pathIterator) follows(cmp int) bool {
+	return follows(cmp, it.reverse)
+}
+
+// resync adopts the current tree version, it returns false if the tree has not been modified.
+func (it *pathIterator) resync() bool {
+	if it.version == it.tree.version {
+		return false
+	}
+
+	it.version = it.tree.version
+
+	return true
+}
+
+// rewind moves the iterator back to the root of the tree.
+func (it *pathIterator) rewind() {
+	it.stack, it.nextNode, it.nextPath = it.stack[:0], it.tree.root, nil
+}
+
+// follows reports whether the result of comparing a key to another one places it after the other one
+// in the ascending order, or in the descending order if reverse is set.
+func follows(cmp int, reverse bool) bool {
+	return ternary(reverse, cmp < 0, cmp > 0)
+}
+
+// seekingIterator is a pre-order iterator over all nodes of a tree which can resume after a key,
+// it is implemented by the iterators of both engines.
+type seekingIterator interface {
+	Iterator
+
+	// resync adopts the current tree version, it returns false if the tree has not been modified.
+	resync() bool
+
+	// seekAfter moves the iterator to the first Node following the key in the iteration order.
+	seekAfter(key Key)
+
+	// rewind moves the iterator back to the root of the tree.
+	rewind()
+}
+
+// resilientIterator returns the nodes of a seekingIterator matching the traversal options,
+// it re-seeks the seekingIterator after the last returned key once the tree has been modified.
+type resilientIterator struct {
+	opts     traverseOpts    // opts filters the nodes and sets the iteration order
+	it       seekingIterator // it iterates over all nodes of the tree in pre-order
+	tree     Tree            // tree is the iterated tree, Delete removes the keys from it
+	last     Key             // last is the key of the last returned LeafKind Node
+	hasLast  bool            // hasLast reports whether a LeafKind Node has been returned
+	nextNode NodeKV          // nextNode is the peeked Node matching the options, nil if none
+	peeked   bool            // peeked reports whether nextNode is up to date
+}
+
+// assert that resilientIterator implements the ResilientIterator interface.
+var _ ResilientIterator = (*resilientIterator)(nil)
+
+// sync re-seeks the iterator after the last returned key if the tree has been modified,
+// the iteration restarts from the beginning if no key has been returned yet.
+func (rit *resilientIterator) sync() {
+	if !rit.it.resync() {
+		return
+	}
+
+	rit.peeked = false
+
+	if rit.hasLast {
+		rit.it.seekAfter(rit.last)
+	} else {
+		rit.it.rewind()
+	}
+}
+
+// peek looks for the next Node matching the options.
+func (rit *resilientIterator) peek() {
+	if rit.peeked {
+		return
+	}
+
+	rit.peeked, rit.nextNode = true, nil
+
+	for rit.it.HasNext() {
+		node, err := rit.it.Next()
+		if err != nil {
+			return
+		}
+
+		if node.Kind() == LeafKind && rit.opts.hasLeaf() || node.Kind() != LeafKind && rit.opts.hasNode() {
+			rit.nextNode = node
+
+			return
+		}
+	}
+}
+
+// HasNext returns true if there are more nodes to iterate.
+func (rit *resilientIterator) HasNext() bool {
+	rit.sync()
+	rit.peek()
+
+	return rit.nextNode != nil
+}
+
+// Next returns the next Node, or ErrNoMoreNodes if there are no more nodes to iterate.
+func (rit *resilientIterator) Next() (NodeKV, error) {
+	if !rit.HasNext() {
+		return nil, ErrNoMoreNodes
+	}
+
+	current := rit.nextNode
+	rit.peeked = false
+
+	// the key is copied, the Leaf may be released by a later deletion
+	if current.Kind() == LeafKind {
+		rit.last, rit.hasLast = append(rit.last[:0], current.Key()...), true
+	}
+
+	return current, nil
+}
+
+// Delete removes the key of the last returned LeafKind Node from the tree.
+func (rit *resilientIterator) Delete() (Value, bool) {
+	if !rit.hasLast {
+		return nil, false
+	}
+
+	return rit.tree.Delete(rit.last)
+}
+
 // BufferedIterator implements HasNext and Next methods for buffered iteration.
 // It allows to iterate over Leaf or non-LeafKind nodes only.
 type bufferedIterator struct {
@@ -0,0 +1,150 @@
+package art
+
+import "bytes"
+
+// listOptions contains options for List.
+type listOptions struct {
+	startAfter Key // startAfter skips the entries less than or equal to it, nil for none
+	maxKeys    int // maxKeys is the maximum number of entries, 0 for all
+}
+
+func createListOptions(opts ...ListOption) listOptions {
+	var defOpts listOptions
+
+	for _, opt := range opts {
+		opt(&defOpts)
+	}
+
+	return defOpts
+}
+
+// lister collects the entries of a List page.
+type lister struct {
+	prefix    Key
+	delimiter byte
+	opts      listOptions
+	result    ListResult
+}
+
+func newLister(prefix Key, delimiter byte, opts ...ListOption) *lister {
+	return &lister{
+		prefix:    prefix,
+		delimiter: delimiter,
+		opts:      createListOptions(opts...),
+	}
+}
+
+// commonPrefix returns the common prefix the path rolls up into,
+// nil if the path holds no delimiter after the listed prefix.
+func (l *lister) commonPrefix(path Key) Key {
+	if len(path) <= len(l.prefix) {
+		return nil
+	}
+
+	idx := bytes.IndexByte(path[len(l.prefix):], l.delimiter)
+	if idx < 0 {
+		return nil
+	}
+
+	return path[:len(l.prefix)+idx+1]
+}
+
+// afterStart reports whether the entry is greater than the start after key.
+func (l *lister) afterStart(entry Key) bool {
+	return l.opts.startAfter == nil || bytes.Compare(entry, l.opts.startAfter) > 0
+}
+
+// beforeStart reports whether all keys starting with the path are less than the start after key.
+func (l *lister) beforeStart(path Key) bool {
+	if l.opts.startAfter == nil {
+		return false
+	}
+
+	n := minInt(len(path), len(l.opts.startAfter))
+
+	return bytes.Compare(path[:n], l.opts.startAfter[:n]) < 0
+}
+
+// full reports whether the page can't hold another entry, the result is marked as truncated then.
+func (l *lister) full() bool {
+	if l.opts.maxKeys > 0 && len(l.result.Leaves)+len(l.result.CommonPrefixes) >= l.opts.maxKeys {
+		l.result.IsTruncated = true
+
+		return true
+	}
+
+	return false
+}
+
+// addLeaf lists the Leaf or the common prefix its key rolls up into.
+// It returns false once the page is full.
+func (l *lister) addLeaf(node NodeKV) bool {
+	key := node.Key()
+	if !bytes.HasPrefix(key, l.prefix) {
+		return true
+	}
+
+	if cp := l.commonPrefix(key); cp != nil {
+		return l.addCommonPrefix(cp)
+	}
+
+	if !l.afterStart(key) {
+		return true
+	}
+
+	if l.full() {
+		return false
+	}
+
+	l.result.Leaves = append(l.result.Leaves, node)
+	l.result.NextStartAfter = key
+
+	return true
+}
+
+// addCommonPrefix lists the common prefix unless it is the last listed one.
+// It returns false once the page is full.
+func (l *lister) addCommonPrefix(cp Key) bool {
+	if last := len(l.result.CommonPrefixes) - 1; last >= 0 && bytes.Equal(l.result.CommonPrefixes[last], cp) {
+		return true
+	}
+
+	if !l.afterStart(cp) {
+		return true
+	}
+
+	if l.full() {
+		return false
+	}
+
+	cp = append(Key(nil), cp...)
+	l.result.CommonPrefixes = append(l.result.CommonPrefixes, cp)
+	l.result.NextStartAfter = cp
+
+	return true
+}
+
+// List returns the keys and the common prefixes one level below the prefix.
+// The subtrees whose path holds the delimiter or sorts before the start after key are skipped.
+func (tr *tree) List(prefix Key, delimiter byte, opts ...ListOption) ListResult {
+	l := newLister(prefix, delimiter, opts...)
+
+	root, depth := tr.findPrefixRoot(prefix)
+	walkPath(root, prefix[:depth], false, func(nr NodeRef, path Key) traverseAction {
+		if l.beforeStart(path) {
+			return traverseSkip
+		}
+
+		if nr.isLeaf() {
+			return ternary(l.addLeaf(tr.nodeKV(nr, path)), traverseContinue, traverseStop)
+		}
+
+		if cp := l.commonPrefix(path); cp != nil {
+			return ternary(l.addCommonPrefix(cp), traverseSkip, traverseStop)
+		}
+
+		return traverseContinue
+	})
+
+	return l.result
+}
@@ -0,0 +1,189 @@
+package art
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listKeys returns the keys of the listed leaves and the common prefixes as strings.
+func listKeys(res ListResult) ([]string, []string) {
+	var keys, prefixes []string
+	for _, leaf := range res.Leaves {
+		keys = append(keys, string(leaf.Key()))
+	}
+
+	for _, cp := range res.CommonPrefixes {
+		prefixes = append(prefixes, string(cp))
+	}
+
+	return keys, prefixes
+}
+
+func newListTree(newTree func() Tree) Tree {
+	tree := newTree()
+	for _, k := range []string{
+		"photos/2023/jan/a.jpg",
+		"photos/2023/jan/b.jpg",
+		"photos/2023/feb/c.jpg",
+		"photos/2024/d.jpg",
+		"photos/e.jpg",
+		"photos/f.jpg",
+		"photos/",
+		"readme.md",
+		"src/main.go",
+	} {
+		tree.Insert(Key(k), k)
+	}
+
+	return tree
+}
+
+func TestTreeList(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		prefix   string
+		opts     []ListOption
+		keys     []string
+		prefixes []string
+	}{
+		{"Root", "", nil, []string{"readme.md"}, []string{"photos/", "src/"}},
+		{"Directory", "photos/", nil, []string{"photos/", "photos/e.jpg", "photos/f.jpg"},
+			[]string{"photos/2023/", "photos/2024/"}},
+		{"Subdirectory", "photos/2023/", nil, nil, []string{"photos/2023/feb/", "photos/2023/jan/"}},
+		{"PartialSegment", "photos/202", nil, nil, []string{"photos/2023/", "photos/2024/"}},
+		{"Leaf", "photos/2023/jan/a", nil, []string{"photos/2023/jan/a.jpg"}, nil},
+		{"NoMatch", "videos/", nil, nil, nil},
+		{"StartAfterKey", "photos/", []ListOption{WithListStartAfter(Key("photos/e.jpg"))},
+			[]string{"photos/f.jpg"}, nil},
+		{"StartAfterCommonPrefix", "photos/", []ListOption{WithListStartAfter(Key("photos/2023/"))},
+			[]string{"photos/e.jpg", "photos/f.jpg"}, []string{"photos/2024/"}},
+		{"MaxKeys", "", []ListOption{WithListMaxKeys(2)}, []string{"readme.md"}, []string{"photos/"}},
+	}
+
+	for _, e := range engines {
+		e := e
+		tree := newListTree(e.newTree)
+
+		for _, tt := range tests {
+			tt := tt
+			t.Run(e.name+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				keys, prefixes := listKeys(tree.List(Key(tt.prefix), '/', tt.opts...))
+				assert.Equal(t, tt.keys, keys)
+				assert.Equal(t, tt.prefixes, prefixes)
+			})
+		}
+	}
+}
+
+func TestTreeListPagination(t *testing.T) {
+	t.Parallel()
+
+	for _, e := range engines {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := newListTree(e.newTree)
+
+			var entries []string
+
+			var startAfter Key
+
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, 10)
+
+				res := tree.List(Key("photos/"), '/', WithListMaxKeys(2), WithListStartAfter(startAfter))
+				assert.LessOrEqual(t, len(res.Leaves)+len(res.CommonPrefixes), 2)
+
+				keys, prefixes := listKeys(res)
+				entries = append(entries, prefixes...)
+				entries = append(entries, keys...)
+
+				if !res.IsTruncated {
+					break
+				}
+
+				startAfter = res.NextStartAfter
+			}
+
+			assert.ElementsMatch(t, []string{
+				"photos/", "photos/2023/", "photos/2024/", "photos/e.jpg", "photos/f.jpg",
+			}, entries)
+		})
+	}
+}
+
+func TestTreeForEachPrefixWithSeparator(t *testing.T) {
+	t.Parallel()
+
+	countSlashes := func(prefix, key Key) int {
+		return bytes.Count(key[len(prefix):], []byte{'/'})
+	}
+
+	for _, e := range engines {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := newListTree(e.newTree)
+
+			collect := func(prefix string, maxDepth int, reverse bool, limit int) []string {
+				var keys []string
+
+				tree.ForEachPrefixWithSeparator(Key(prefix), func(node NodeKV) bool {
+					keys = append(keys, string(node.Key()))
+
+					return len(keys) < limit
+				}, countSlashes, maxDepth, reverse)
+
+				return keys
+			}
+
+			assert.Equal(t, []string{"photos/", "photos/2024/d.jpg", "photos/e.jpg", "photos/f.jpg"},
+				collect("photos/", 1, false, 100))
+			assert.Equal(t, []string{"photos/f.jpg", "photos/e.jpg", "photos/2024/d.jpg", "photos/"},
+				collect("photos/", 1, true, 100))
+			assert.Equal(t, []string{"readme.md"}, collect("", 0, false, 100))
+			assert.Len(t, collect("", -1, false, 100), tree.Size())
+
+			// the callback stops the iteration at any level
+			assert.Equal(t, []string{"photos/"}, collect("photos/", -1, false, 1))
+			assert.Equal(t, []string{"photos/2023/feb/c.jpg", "photos/2023/jan/a.jpg"},
+				collect("photos/2023/", -1, false, 2))
+		})
+	}
+}
+
+func TestTreeForEachPrefixWithSeparatorPrunes(t *testing.T) {
+	t.Parallel()
+
+	tree := New()
+	for _, k := range []string{"a/b", "a/b/c", "a/b/d", "a/e"} {
+		tree.Insert(Key(k), k)
+	}
+
+	var counted []string
+
+	var keys []string
+
+	tree.ForEachPrefixWithSeparator(Key("a/"), func(node NodeKV) bool {
+		keys = append(keys, string(node.Key()))
+
+		return true
+	}, func(prefix, key Key) int {
+		counted = append(counted, string(key))
+
+		return bytes.Count(key[len(prefix):], []byte{'/'})
+	}, 0, false)
+
+	assert.Equal(t, []string{"a/b", "a/e"}, keys)
+	assert.NotContains(t, counted, "a/b/c")
+	assert.NotContains(t, counted, "a/b/d")
+}
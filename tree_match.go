@@ -0,0 +1,101 @@
+package art
+
+import "bytes"
+
+// ForEachMatch invokes the callback for the keys accepted by the automaton.
+// The automaton consumes the Node prefixes and the child key bytes along the descent.
+func (tr *tree) ForEachMatch(a Automaton, cb Callback) {
+	tr.matchRecursively(a, tr.root, nil, a.Start(), nil, cb)
+}
+
+// Glob invokes the callback for the keys matching the glob pattern.
+// The descent along the literal prefix of the pattern doesn't run the automaton on the sibling subtrees,
+// and the subtrees without a key ending with the last byte of the literal suffix are skipped.
+func (tr *tree) Glob(pattern string, cb Callback, opts ...GlobOption) error {
+	g, err := parseGlob(pattern, createGlobOptions(opts...))
+	if err != nil {
+		return err
+	}
+
+	a, err := NewRegexpAutomaton(g.expr)
+	if err != nil {
+		return err
+	}
+
+	nr, depth := tr.findPrefixRoot(g.prefix)
+	if state, ok := stepAutomaton(a, a.Start(), g.prefix[:depth]); ok {
+		tr.matchRecursively(a, nr, append(Key(nil), g.prefix[:depth]...), state, g.suffix, cb)
+	}
+
+	return nil
+}
+
+// matchRecursively matches the subtree whose path led the automaton to the state.
+// The subtrees without a key ending with the last byte of the suffix, according to their suffix bits,
+// and the leaves whose key doesn't end with the suffix are skipped without running the automaton.
+// The path is extended in place, the siblings of a Node reuse the bytes of its path.
+func (tr *tree) matchRecursively(
+	a Automaton,
+	nr NodeRef,
+	path Key,
+	state int,
+	suffix Key,
+	cb Callback,
+) traverseAction {
+	if nr.isNil() {
+		return traverseContinue
+	}
+
+	if nr.isLeaf() {
+		key := tr.leafKey(nr.Leaf(), path)
+		if !bytes.HasSuffix(key, suffix) {
+			return traverseContinue
+		}
+
+		if state, ok := stepAutomaton(a, state, key[len(path):]); ok && a.IsMatch(state) && !cb(tr.nodeKV(nr, path)) {
+			return traverseStop
+		}
+
+		return traverseContinue
+	}
+
+	if bit := suffixBit(suffix); nr.node().suffixes&bit != bit {
+		return traverseContinue
+	}
+
+	prefix := nr.fullPrefix(len(path))
+
+	state, ok := stepAutomaton(a, state, prefix)
+	if !ok {
+		return traverseContinue
+	}
+
+	path = append(path, prefix...)
+
+	for _, ref := range nr.childRefs() {
+		childState, childPath := state, path
+		if !ref.kc.invalid {
+			childState, childPath = a.Step(state, ref.kc.ch), append(path, ref.kc.ch)
+			if !a.CanMatch(childState) {
+				continue
+			}
+		}
+
+		if tr.matchRecursively(a, ref.ref, childPath, childState, suffix, cb) == traverseStop {
+			return traverseStop
+		}
+	}
+
+	return traverseContinue
+}
+
+// stepAutomaton feeds the bytes to the automaton, it returns false as soon as the state can't match.
+func stepAutomaton(a Automaton, state int, b []byte) (int, bool) {
+	for _, c := range b {
+		if state = a.Step(state, c); !a.CanMatch(state) {
+			return state, false
+		}
+	}
+
+	return state, true
+}
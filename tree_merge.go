@@ -0,0 +1,189 @@
+package art
+
+import (
+	"bytes"
+	"container/heap"
+)
+
+// tombstone is the type of the Tombstone marker.
+type tombstone struct{}
+
+// Tombstone is a special value marking a key as deleted in a layered set of trees.
+// MergeIterator with the default resolution skips the keys whose newest value is a Tombstone.
+//
+//nolint:gochecknoglobals
+var Tombstone Value = tombstone{}
+
+// MergeIterator returns an iterator over the LeafKind nodes of several trees in key order.
+// The trees must be ordered from the newest to the oldest.
+// Keys present in more than one tree are reported once, resolve is called with
+// the key and its values ordered from the newest to the oldest tree, in a new slice for every key.
+// It returns the value to report and false if the key must be skipped.
+// If resolve is nil, the newest value wins and keys whose newest value is a Tombstone are skipped.
+// Pass the TraverseReverse option to iterate in descending order.
+func MergeIterator(trees []Tree, resolve func(key Key, vals []Value) (Value, bool), options ...int) Iterator {
+	if resolve == nil {
+		resolve = resolveNewest
+	}
+
+	reverse := traverseOptions(options...).hasReverse()
+
+	mit := &mergeIterator{
+		iterators: make([]Iterator, 0, len(trees)),
+		heap:      &mergeHeap{reverse: reverse},
+		resolve:   resolve,
+	}
+
+	for _, t := range trees {
+		mit.iterators = append(mit.iterators, t.Iterator(ternary(reverse, TraverseReverse, TraverseLeaf)))
+		mit.pull(len(mit.iterators) - 1)
+	}
+
+	mit.advance()
+
+	return mit
+}
+
+// resolveNewest is the default MergeIterator resolution, the newest value wins.
+func resolveNewest(_ Key, vals []Value) (Value, bool) {
+	if _, deleted := vals[0].(tombstone); deleted {
+		return nil, false
+	}
+
+	return vals[0], true
+}
+
+// mergedNode is a LeafKind Node reported by the merge iterator.
+type mergedNode struct {
+	key   Key
+	value Value
+}
+
+// assert that mergedNode implements public NodeKV interface.
+var _ NodeKV = (*mergedNode)(nil)
+
+func (mn *mergedNode) Kind() Kind   { return LeafKind } // Kind returns LeafKind.
+func (mn *mergedNode) Key() Key     { return mn.key }   // Key returns the merged key.
+func (mn *mergedNode) Value() Value { return mn.value } // Value returns the resolved value.
+
+// mergeItem is the current Leaf of one of the merged iterators.
+type mergeItem struct {
+	node NodeKV
+	src  int // index of the source iterator, lower is newer
+}
+
+// mergeHeap is a min-heap (max-heap in reverse mode) of the merged iterators heads.
+type mergeHeap struct {
+	items   []mergeItem
+	reverse bool
+}
+
+func (h *mergeHeap) Len() int      { return len(h.items) }
+func (h *mergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *mergeHeap) Push(x any)    { h.items = append(h.items, x.(mergeItem)) } //nolint:forcetypeassert
+
+func (h *mergeHeap) Less(i, j int) bool {
+	cmp := bytes.Compare(h.items[i].node.Key(), h.items[j].node.Key())
+	if cmp == 0 {
+		return h.items[i].src < h.items[j].src
+	}
+
+	return ternary(h.reverse, cmp > 0, cmp < 0)
+}
+
+func (h *mergeHeap) Pop() any {
+	last := len(h.items) - 1
+	item := h.items[last]
+	h.items = h.items[:last]
+
+	return item
+}
+
+// top returns the head with the smallest key (the greatest key in reverse mode).
+func (h *mergeHeap) top() mergeItem {
+	return h.items[0]
+}
+
+// mergeIterator implements k-way merge of several tree iterators.
+type mergeIterator struct {
+	iterators []Iterator
+	heap      *mergeHeap
+	resolve   func(key Key, vals []Value) (Value, bool)
+	nextNode  NodeKV
+	nextErr   error
+}
+
+// assert that mergeIterator implements the Iterator interface.
+var _ Iterator = (*mergeIterator)(nil)
+
+// HasNext returns true if there are more nodes to iterate or an error to report.
+func (mit *mergeIterator) HasNext() bool {
+	return mit.nextNode != nil || mit.nextErr != nil
+}
+
+// Next returns the next merged LeafKind Node.
+// It returns ErrNoMoreNodes if there are no more nodes to iterate.
+// It returns ErrConcurrentModification if any of the trees has been modified concurrently,
+// once the keys resolved before the modification was detected have been returned.
+func (mit *mergeIterator) Next() (NodeKV, error) {
+	current := mit.nextNode
+	if current == nil {
+		if mit.nextErr != nil {
+			return nil, mit.nextErr
+		}
+
+		return nil, ErrNoMoreNodes
+	}
+
+	// the merge can't go on after an error, the error is reported by the following call
+	if mit.nextErr != nil {
+		mit.nextNode = nil
+	} else {
+		mit.advance()
+	}
+
+	return current, nil
+}
+
+// pull pushes the next Leaf of the source iterator to the heap.
+func (mit *mergeIterator) pull(src int) {
+	it := mit.iterators[src]
+	if !it.HasNext() {
+		return
+	}
+
+	node, err := it.Next()
+	if err != nil {
+		mit.nextErr = err
+
+		return
+	}
+
+	heap.Push(mit.heap, mergeItem{node: node, src: src})
+}
+
+// advance resolves the next key to report.
+func (mit *mergeIterator) advance() {
+	for mit.heap.Len() > 0 && mit.nextErr == nil {
+		item := heap.Pop(mit.heap).(mergeItem) //nolint:forcetypeassert
+		key := item.node.Key()
+
+		vals := []Value{item.node.Value()}
+		mit.pull(item.src)
+
+		// collect the values of the same key from the older trees
+		for mit.heap.Len() > 0 && bytes.Equal(mit.heap.top().node.Key(), key) {
+			dup := heap.Pop(mit.heap).(mergeItem) //nolint:forcetypeassert
+			vals = append(vals, dup.node.Value())
+			mit.pull(dup.src)
+		}
+
+		if value, ok := mit.resolve(key, vals); ok {
+			mit.nextNode = &mergedNode{key: key, value: value}
+
+			return
+		}
+	}
+
+	mit.nextNode = nil
+}
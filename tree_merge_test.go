@@ -0,0 +1,164 @@
+package art
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// collectMerged returns the keys and values reported by the iterator.
+func collectMerged(t *testing.T, it Iterator) ([]string, []Value) {
+	t.Helper()
+
+	var (
+		keys []string
+		vals []Value
+	)
+
+	for it.HasNext() {
+		node, err := it.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, LeafKind, node.Kind())
+
+		keys = append(keys, string(node.Key()))
+		vals = append(vals, node.Value())
+	}
+
+	_, err := it.Next()
+	assert.ErrorIs(t, err, ErrNoMoreNodes)
+
+	return keys, vals
+}
+
+func TestMergeIterator(t *testing.T) {
+	t.Parallel()
+
+	newest, middle, oldest := New(), New(), New()
+	oldest.Insert(Key("a"), "a0")
+	oldest.Insert(Key("b"), "b0")
+	oldest.Insert(Key("c"), "c0")
+	oldest.Insert(Key("ca"), "ca0")
+	middle.Insert(Key("b"), "b1")
+	middle.Insert(Key("d"), "d1")
+	newest.Insert(Key("c"), Tombstone)
+	newest.Insert(Key("d"), "d2")
+	newest.Insert(Key(""), "empty2")
+
+	trees := []Tree{newest, middle, oldest}
+
+	keys, vals := collectMerged(t, MergeIterator(trees, nil))
+	assert.Equal(t, []string{"", "a", "b", "ca", "d"}, keys)
+	assert.Equal(t, []Value{"empty2", "a0", "b1", "ca0", "d2"}, vals)
+
+	keys, vals = collectMerged(t, MergeIterator(trees, nil, TraverseReverse))
+	assert.Equal(t, []string{"d", "ca", "b", "a", ""}, keys)
+	assert.Equal(t, []Value{"d2", "ca0", "b1", "a0", "empty2"}, vals)
+}
+
+func TestMergeIteratorCustomResolve(t *testing.T) {
+	t.Parallel()
+
+	newest, oldest := New(), New()
+	newest.Insert(Key("a"), 1)
+	newest.Insert(Key("b"), 2)
+	oldest.Insert(Key("b"), 20)
+	oldest.Insert(Key("c"), 30)
+
+	sum := func(_ Key, vals []Value) (Value, bool) {
+		total := 0
+		for _, v := range vals {
+			total += v.(int)
+		}
+
+		return total, total < 30
+	}
+
+	keys, vals := collectMerged(t, MergeIterator([]Tree{newest, oldest}, sum))
+	assert.Equal(t, []string{"a", "b"}, keys)
+	assert.Equal(t, []Value{1, 22}, vals)
+}
+
+func TestMergeIteratorEmpty(t *testing.T) {
+	t.Parallel()
+
+	keys, _ := collectMerged(t, MergeIterator(nil, nil))
+	assert.Empty(t, keys)
+
+	keys, _ = collectMerged(t, MergeIterator([]Tree{New(), New()}, nil))
+	assert.Empty(t, keys)
+}
+
+func TestMergeIteratorWords(t *testing.T) {
+	t.Parallel()
+
+	data := loadTestFile("test/assets/words.txt")
+
+	// distribute the words between three trees with some overlap
+	trees := []Tree{New(), New(), New()}
+	for i, d := range data {
+		trees[i%3].Insert(d, d)
+		if i%5 == 0 {
+			trees[(i+1)%3].Insert(d, d)
+		}
+	}
+
+	full, _ := treeWithData("test/assets/words.txt")
+
+	assert.True(t, equalIterators(full.Iterator(), MergeIterator(trees, nil), func(a, b Value) bool {
+		return string(a.([]byte)) == string(b.([]byte))
+	}))
+}
+
+func TestMergeIteratorConcurrentModification(t *testing.T) {
+	t.Parallel()
+
+	tree, _ := treeWithData("test/assets/words.txt")
+
+	it := MergeIterator([]Tree{tree}, nil)
+	tree.Insert(Key("new key"), "new value")
+
+	// the keys resolved before the modification are returned first
+	var (
+		keys []Key
+		err  error
+	)
+
+	for it.HasNext() {
+		var node NodeKV
+		if node, err = it.Next(); err != nil {
+			break
+		}
+
+		keys = append(keys, node.Key())
+	}
+
+	assert.ErrorIs(t, err, ErrConcurrentModification)
+	assert.Equal(t, []Key{Key("A"), Key("Aani")}, keys)
+
+	// the error is kept, HasNext reports it like the tree iterators
+	assert.True(t, it.HasNext())
+	_, err = it.Next()
+	assert.ErrorIs(t, err, ErrConcurrentModification)
+}
+
+func TestMergeIteratorResolveKeepsValues(t *testing.T) {
+	t.Parallel()
+
+	newest, oldest := New(), New()
+	for _, k := range []string{"a", "b", "c"} {
+		newest.Insert(Key(k), k+"1")
+		oldest.Insert(Key(k), k+"0")
+	}
+
+	var kept [][]Value
+
+	keep := func(_ Key, vals []Value) (Value, bool) {
+		kept = append(kept, vals)
+
+		return vals[0], true
+	}
+
+	keys, _ := collectMerged(t, MergeIterator([]Tree{newest, oldest}, keep))
+	assert.Equal(t, []string{"a", "b", "c"}, keys)
+	assert.Equal(t, [][]Value{{"a1", "a0"}, {"b1", "b0"}, {"c1", "c0"}}, kept)
+}
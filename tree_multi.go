@@ -0,0 +1,388 @@
+package art
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// multiTreeOptions contains options for the MultiTree, see MultiTreeOption.
+type multiTreeOptions struct {
+	dedup     bool                  // dedup skips the values already held by the key
+	eq        func(a, b Value) bool // eq compares the values
+	countKeys bool                  // countKeys makes Size count the keys
+	treeOpts  []TreeOption          // treeOpts configures the underlying tree
+}
+
+// createMultiTreeOptions applies the options to the default MultiTree options.
+func createMultiTreeOptions(opts ...MultiTreeOption) multiTreeOptions {
+	defOpts := multiTreeOptions{
+		eq: func(a, b Value) bool { return reflect.DeepEqual(a, b) },
+	}
+
+	for _, opt := range opts {
+		opt(&defOpts)
+	}
+
+	return defOpts
+}
+
+// multiEntry holds the values of a MultiTree key, and its original key if the tree has a KeyTransformer.
+// A removal copies the values, so that the slices returned by SearchAll are not affected.
+type multiEntry struct {
+	key    *Key // key is nil without KeyTransformer, a pointer keeps the multiLeaf in the 96 bytes size class
+	values []Value
+}
+
+// index returns the index of the first value equal to the value, -1 if there is none.
+func (e *multiEntry) index(value Value, eq func(a, b Value) bool) int {
+	for i, v := range e.values {
+		if eq(v, value) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// snapshot returns the values, capped so that appending to them doesn't overwrite the later values.
+func (e *multiEntry) snapshot() []Value {
+	return e.values[:len(e.values):len(e.values)]
+}
+
+// nodeKey returns the original key, or the key of the LeafKind Node of the entry if the tree has no KeyTransformer.
+func (e *multiEntry) nodeKey(node NodeKV) Key {
+	if e.key != nil {
+		return *e.key
+	}
+
+	return node.Key()
+}
+
+// multiLeaf is the Leaf of a MultiTree, its entry and its short key are stored in the same allocation
+// and the Leaf value references the entry, so appending a value to a key doesn't box the values again.
+type multiLeaf struct {
+	Leaf
+	entry multiEntry
+	buf   [inlineKeyLen]byte
+}
+
+// multiLeafFactory allocates the multiLeaf leaves of a MultiTree,
+// the inner nodes are allocated by the NodeFactory of the tree options.
+type multiLeafFactory struct {
+	NodeFactory
+}
+
+// NewLeaf creates a new multiLeaf holding the value, or the entry of a moved or cloned Leaf.
+// The values are shared with the copied Leaf, they are capped so that appending to a copy doesn't overwrite them.
+func (f multiLeafFactory) NewLeaf(key Key, value interface{}) NodeRef {
+	leaf := &multiLeaf{}
+	if len(key) <= inlineKeyLen {
+		leaf.key = leaf.buf[:len(key):len(key)]
+	} else {
+		leaf.key = make(Key, len(key))
+	}
+
+	copy(leaf.key, key)
+
+	if entry, ok := value.(*multiEntry); ok {
+		leaf.entry = multiEntry{key: entry.key, values: entry.snapshot()}
+	} else {
+		leaf.entry.values = []Value{value}
+	}
+
+	leaf.value = &leaf.entry
+
+	return newNodeRef(LeafKind, unsafe.Pointer(&leaf.Leaf)) //#nosec:G103
+}
+
+// leafSize returns the size of the multiLeaf including its key, the values are not counted.
+func (f multiLeafFactory) leafSize(leaf *Leaf) int {
+	if len(leaf.key) <= inlineKeyLen {
+		return int(unsafe.Sizeof(multiLeaf{}))
+	}
+
+	return int(unsafe.Sizeof(multiLeaf{})) + cap(leaf.key)
+}
+
+// Release recycles the inner nodes, the leaves are reclaimed by the garbage collector.
+func (f multiLeafFactory) Release(nr NodeRef) {
+	if !nr.isNil() && nr.kind() != LeafKind {
+		f.NodeFactory.Release(nr)
+	}
+}
+
+// multiNode is a LeafKind Node reported by the MultiTree traversals,
+// its value is either the []Value of the key or one of them.
+type multiNode struct {
+	key   Key
+	value Value
+}
+
+// assert that multiNode implements the NodeKV interface.
+var _ NodeKV = (*multiNode)(nil)
+
+// Kind returns LeafKind.
+func (n *multiNode) Kind() Kind { return LeafKind }
+
+// Key returns the key.
+func (n *multiNode) Key() Key { return n.key }
+
+// Value returns the values of the key or one of them.
+func (n *multiNode) Value() Value { return n.value }
+
+// multiTree stores the values of each key in its Leaf.
+type multiTree struct {
+	tree   *tree            // tree maps the keys to their multiLeaf leaves
+	opts   multiTreeOptions // opts is the MultiTree configuration
+	values int              // values is the number of values stored in the tree
+}
+
+// make sure that multiTree implements all methods from the MultiTree interface.
+var _ MultiTree = (*multiTree)(nil)
+
+// newMultiTree creates a new MultiTree, its leaves are allocated by a multiLeafFactory.
+func newMultiTree(opts ...MultiTreeOption) *multiTree {
+	options := createMultiTreeOptions(opts...)
+
+	tr := newTree(options.treeOpts...)
+	tr.opts.factory = multiLeafFactory{NodeFactory: tr.opts.factory}
+
+	return &multiTree{tree: tr, opts: options}
+}
+
+// sortKey returns the sort key of the key if the tree has a KeyTransformer, the key itself otherwise.
+func (mt *multiTree) sortKey(key Key) Key {
+	if mt.tree.opts.transform == nil {
+		return key
+	}
+
+	return sortKey(mt.tree.opts.transform, key)
+}
+
+// searchEntry returns the entry of the key, nil if the key doesn't exist.
+func (mt *multiTree) searchEntry(key Key) *multiEntry {
+	leaf := mt.tree.searchLeaf(key)
+	if leaf == nil {
+		return nil
+	}
+
+	return leaf.value.(*multiEntry) //nolint:forcetypeassert
+}
+
+// Insert appends the value to the entry of the key, or inserts a new Leaf.
+func (mt *multiTree) Insert(key Key, value Value) bool {
+	sk := mt.sortKey(key)
+
+	switch entry := mt.searchEntry(sk); {
+	case entry != nil:
+		if mt.opts.dedup && entry.index(value, mt.opts.eq) >= 0 {
+			return false
+		}
+
+		entry.values = append(entry.values, value)
+		mt.tree.version++
+	case mt.tree.opts.transform != nil:
+		// the new Leaf copies the entry holding the original key
+		original := append(make(Key, 0, len(key)), key...)
+		mt.tree.Insert(sk, &multiEntry{key: &original, values: []Value{value}})
+	default:
+		mt.tree.Insert(sk, value)
+	}
+
+	mt.values++
+
+	return true
+}
+
+// DeleteValue removes the first value equal to the value from the entry of the key.
+func (mt *multiTree) DeleteValue(key Key, value Value) bool {
+	sk := mt.sortKey(key)
+
+	entry := mt.searchEntry(sk)
+	if entry == nil {
+		return false
+	}
+
+	idx := entry.index(value, mt.opts.eq)
+	if idx < 0 {
+		return false
+	}
+
+	if len(entry.values) == 1 {
+		mt.tree.Delete(sk)
+	} else {
+		values := make([]Value, 0, len(entry.values)-1)
+		values = append(values, entry.values[:idx]...)
+		entry.values = append(values, entry.values[idx+1:]...)
+		mt.tree.version++
+	}
+
+	mt.values--
+
+	return true
+}
+
+// Delete removes the Leaf of the key.
+func (mt *multiTree) Delete(key Key) ([]Value, bool) {
+	v, deleted := mt.tree.Delete(mt.sortKey(key))
+	if !deleted {
+		return nil, false
+	}
+
+	entry := v.(*multiEntry) //nolint:forcetypeassert
+	mt.values -= len(entry.values)
+
+	return entry.snapshot(), true
+}
+
+// SearchAll returns the values held by the entry of the key.
+func (mt *multiTree) SearchAll(key Key) ([]Value, bool) {
+	entry := mt.searchEntry(mt.sortKey(key))
+	if entry == nil {
+		return nil, false
+	}
+
+	return entry.snapshot(), true
+}
+
+// Size returns the number of values or keys.
+func (mt *multiTree) Size() int {
+	if mt.opts.countKeys {
+		return mt.tree.Size()
+	}
+
+	return mt.values
+}
+
+// ForEach iterates over all keys or values in the tree.
+func (mt *multiTree) ForEach(callback Callback, opts ...int) {
+	mt.tree.ForEach(multiCallback(callback, mergeOptions(opts...)), opts...)
+}
+
+// ForEachPrefix iterates over all keys or values whose keys start with the given prefix.
+func (mt *multiTree) ForEachPrefix(key Key, callback Callback, opts ...int) {
+	mt.tree.ForEachPrefix(mt.sortKey(key), multiCallback(callback, mergeOptions(opts...)), opts...)
+}
+
+// multiCallback returns a callback passing the LeafKind nodes to the callback once per key or once per value.
+func multiCallback(callback Callback, opts int) Callback {
+	return func(node NodeKV) bool {
+		if node.Kind() != LeafKind {
+			return callback(node)
+		}
+
+		entry := node.Value().(*multiEntry) //nolint:forcetypeassert
+		key, values := entry.nodeKey(node), entry.snapshot()
+
+		if opts&TraverseValues == 0 {
+			return callback(&multiNode{key: key, value: values})
+		}
+
+		reverse := opts&TraverseReverse != 0
+		for i := range values {
+			if reverse {
+				i = len(values) - 1 - i
+			}
+
+			if !callback(&multiNode{key: key, value: values[i]}) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// multiIterator yields the LeafKind nodes of the underlying iterator once per key or once per value.
+type multiIterator struct {
+	it      Iterator // it iterates over the tree nodes
+	values  bool     // values yields the values one by one
+	reverse bool     // reverse yields the values of a key in reverse order
+	key     Key      // key is the key of the pending values
+	pending []Value  // pending holds the values of the current key not yet yielded
+	last    NodeKV   // last is the last returned LeafKind Node
+}
+
+// assert that multiIterator implements the Iterator interface.
+var _ Iterator = (*multiIterator)(nil)
+
+// multiResilientIterator is the multiIterator of a resilient iteration, it deletes through the MultiTree
+// so that the number of values is kept up to date.
+type multiResilientIterator struct {
+	*multiIterator
+	tree *multiTree
+}
+
+// assert that multiResilientIterator implements the ResilientIterator interface.
+var _ ResilientIterator = (*multiResilientIterator)(nil)
+
+// Iterator returns a new iterator over the keys or the values.
+func (mt *multiTree) Iterator(opts ...int) Iterator {
+	options := mergeOptions(opts...)
+
+	it := &multiIterator{
+		it:      mt.tree.Iterator(opts...),
+		values:  options&TraverseValues != 0,
+		reverse: options&TraverseReverse != 0,
+	}
+
+	if _, ok := it.it.(ResilientIterator); ok {
+		return &multiResilientIterator{multiIterator: it, tree: mt}
+	}
+
+	return it
+}
+
+// Delete removes the last returned value when iterating over the values,
+// otherwise it removes the key of the last returned LeafKind Node with all its values and returns them.
+func (it *multiResilientIterator) Delete() (Value, bool) {
+	if it.last == nil {
+		return nil, false
+	}
+
+	if it.values {
+		return it.last.Value(), it.tree.DeleteValue(it.last.Key(), it.last.Value())
+	}
+
+	values, deleted := it.tree.Delete(it.last.Key())
+	if !deleted {
+		return nil, false
+	}
+
+	return values, true
+}
+
+// HasNext returns true if there are more nodes or values to visit.
+func (it *multiIterator) HasNext() bool {
+	return len(it.pending) > 0 || it.it.HasNext()
+}
+
+// Next returns the next Node or value.
+func (it *multiIterator) Next() (NodeKV, error) {
+	if len(it.pending) == 0 {
+		node, err := it.it.Next()
+		if err != nil || node.Kind() != LeafKind {
+			return node, err
+		}
+
+		entry := node.Value().(*multiEntry) //nolint:forcetypeassert
+		if !it.values {
+			it.last = &multiNode{key: entry.nodeKey(node), value: entry.snapshot()}
+
+			return it.last, nil
+		}
+
+		it.key, it.pending = entry.nodeKey(node), entry.snapshot()
+	}
+
+	var value Value
+	if it.reverse {
+		value, it.pending = it.pending[len(it.pending)-1], it.pending[:len(it.pending)-1]
+	} else {
+		value, it.pending = it.pending[0], it.pending[1:]
+	}
+
+	it.last = &multiNode{key: it.key, value: value}
+
+	return it.last, nil
+}
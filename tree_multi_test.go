@@ -0,0 +1,323 @@
+package art
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// multiEntries returns the key=value entries visited by ForEach.
+func multiEntries(tree MultiTree, opts ...int) []string {
+	var entries []string
+
+	tree.ForEach(func(node NodeKV) bool {
+		entries = append(entries, string(node.Key())+"="+formatValue(node.Value()))
+
+		return true
+	}, opts...)
+
+	return entries
+}
+
+func newTagTree(opts ...MultiTreeOption) MultiTree {
+	tree := NewMultiTree(opts...)
+	for _, kv := range [][2]string{
+		{"go", "art"}, {"rust", "tokio"}, {"go", "testify"}, {"c", "sqlite"}, {"go", "cobra"}, {"go", "art"},
+	} {
+		tree.Insert(Key(kv[0]), kv[1])
+	}
+
+	return tree
+}
+
+func TestMultiTreeInsertSearch(t *testing.T) {
+	t.Parallel()
+
+	tree := newTagTree()
+	assert.Equal(t, 6, tree.Size())
+
+	values, found := tree.SearchAll(Key("go"))
+	require.True(t, found)
+	assert.Equal(t, []Value{"art", "testify", "cobra", "art"}, values)
+
+	// the returned values are not affected by the later modifications
+	tree.Insert(Key("go"), "chi")
+	tree.DeleteValue(Key("go"), "testify")
+	assert.Equal(t, []Value{"art", "testify", "cobra", "art"}, values)
+
+	values, _ = tree.SearchAll(Key("go"))
+	assert.Equal(t, []Value{"art", "cobra", "art", "chi"}, values)
+
+	_, found = tree.SearchAll(Key("java"))
+	assert.False(t, found)
+}
+
+func TestMultiTreeDedup(t *testing.T) {
+	t.Parallel()
+
+	tree := newTagTree(WithDeduplication())
+	assert.Equal(t, 5, tree.Size())
+
+	values, _ := tree.SearchAll(Key("go"))
+	assert.Equal(t, []Value{"art", "testify", "cobra"}, values)
+
+	assert.False(t, tree.Insert(Key("go"), "testify"))
+	assert.True(t, tree.Insert(Key("c"), "zlib"))
+
+	// the values are compared with the configured function
+	folded := newTagTree(WithDeduplication(), WithValueEqual(func(a, b Value) bool {
+		return strings.EqualFold(a.(string), b.(string)) //nolint:forcetypeassert
+	}))
+	assert.False(t, folded.Insert(Key("go"), "COBRA"))
+	assert.True(t, folded.DeleteValue(Key("go"), "Testify"))
+
+	values, _ = folded.SearchAll(Key("go"))
+	assert.Equal(t, []Value{"art", "cobra"}, values)
+}
+
+func TestMultiTreeDelete(t *testing.T) {
+	t.Parallel()
+
+	tree := newTagTree(WithKeyCount())
+	assert.Equal(t, 3, tree.Size())
+
+	assert.True(t, tree.DeleteValue(Key("go"), "art"))
+	assert.False(t, tree.DeleteValue(Key("go"), "gin"))
+	assert.False(t, tree.DeleteValue(Key("java"), "spring"))
+
+	values, _ := tree.SearchAll(Key("go"))
+	assert.Equal(t, []Value{"testify", "cobra", "art"}, values)
+
+	// the key is removed along with its last value
+	assert.True(t, tree.DeleteValue(Key("c"), "sqlite"))
+	assert.Equal(t, 2, tree.Size())
+
+	_, found := tree.SearchAll(Key("c"))
+	assert.False(t, found)
+
+	values, deleted := tree.Delete(Key("go"))
+	assert.True(t, deleted)
+	assert.Equal(t, []Value{"testify", "cobra", "art"}, values)
+	assert.Equal(t, 1, tree.Size())
+
+	_, deleted = tree.Delete(Key("go"))
+	assert.False(t, deleted)
+
+	counted := newTagTree()
+	counted.Delete(Key("go"))
+	assert.Equal(t, 2, counted.Size())
+}
+
+func TestMultiTreeTraversal(t *testing.T) {
+	t.Parallel()
+
+	tree := newTagTree()
+	tree.Insert(Key("golang"), "gopls")
+
+	assert.Equal(t, []string{
+		"c=[sqlite]", "go=[art testify cobra art]", "golang=[gopls]", "rust=[tokio]",
+	}, multiEntries(tree))
+
+	assert.Equal(t, []string{
+		"c=sqlite", "go=art", "go=testify", "go=cobra", "go=art", "golang=gopls", "rust=tokio",
+	}, multiEntries(tree, TraverseValues))
+
+	assert.Equal(t, []string{
+		"rust=tokio", "golang=gopls", "go=art", "go=cobra", "go=testify", "go=art", "c=sqlite",
+	}, multiEntries(tree, TraverseValues, TraverseReverse))
+
+	var entries []string
+
+	tree.ForEachPrefix(Key("go"), func(node NodeKV) bool {
+		entries = append(entries, string(node.Key())+"="+formatValue(node.Value()))
+
+		return len(entries) < 3
+	}, TraverseValues)
+	assert.Equal(t, []string{"go=art", "go=testify", "go=cobra"}, entries)
+
+	nodes := 0
+
+	tree.ForEach(func(node NodeKV) bool {
+		if node.Kind() != LeafKind {
+			nodes++
+		}
+
+		return true
+	}, TraverseAll|TraverseValues)
+	assert.Positive(t, nodes)
+}
+
+func TestMultiTreeIterator(t *testing.T) {
+	t.Parallel()
+
+	tree := newTagTree()
+
+	collect := func(opts ...int) []string {
+		var entries []string
+
+		for it := tree.Iterator(opts...); it.HasNext(); {
+			node, err := it.Next()
+			require.NoError(t, err)
+
+			entries = append(entries, string(node.Key())+"="+formatValue(node.Value()))
+		}
+
+		return entries
+	}
+
+	assert.Equal(t, []string{"c=[sqlite]", "go=[art testify cobra art]", "rust=[tokio]"}, collect())
+	assert.Equal(t, multiEntries(tree, TraverseValues), collect(TraverseValues))
+	assert.Equal(t, multiEntries(tree, TraverseValues, TraverseReverse), collect(TraverseValues, TraverseReverse))
+
+	it := tree.Iterator(TraverseValues)
+	_, err := it.Next()
+	require.NoError(t, err)
+
+	// appending a value in place is a modification
+	tree.Insert(Key("c"), "zlib")
+
+	_, err = it.Next()
+	assert.ErrorIs(t, err, ErrConcurrentModification)
+}
+
+func TestMultiTreeResilientIterator(t *testing.T) {
+	t.Parallel()
+
+	_, ok := newTagTree().Iterator(TraverseValues).(ResilientIterator)
+	assert.False(t, ok)
+
+	// the keys are deleted with all their values
+	tree := newTagTree()
+
+	it, ok := tree.Iterator(TraverseResilient).(ResilientIterator)
+	require.True(t, ok)
+
+	for it.HasNext() {
+		node, err := it.Next()
+		require.NoError(t, err)
+
+		if string(node.Key()) == "go" {
+			values, deleted := it.Delete()
+			assert.True(t, deleted)
+			assert.Equal(t, []Value{"art", "testify", "cobra", "art"}, values)
+		}
+	}
+
+	assert.Equal(t, []string{"c=[sqlite]", "rust=[tokio]"}, multiEntries(tree))
+	assert.Equal(t, 2, tree.Size())
+
+	// the values are deleted one by one
+	tree = newTagTree()
+
+	it, ok = tree.Iterator(TraverseResilient, TraverseValues).(ResilientIterator)
+	require.True(t, ok)
+
+	var seen []string
+
+	for it.HasNext() {
+		node, err := it.Next()
+		require.NoError(t, err)
+
+		seen = append(seen, string(node.Key())+"="+formatValue(node.Value()))
+
+		if node.Value() == "art" || node.Value() == "tokio" {
+			value, deleted := it.Delete()
+			assert.True(t, deleted)
+			assert.Equal(t, node.Value(), value)
+		}
+	}
+
+	assert.Equal(t, []string{"c=sqlite", "go=art", "go=testify", "go=cobra", "go=art", "rust=tokio"}, seen)
+	assert.Equal(t, []string{"c=[sqlite]", "go=[testify cobra]"}, multiEntries(tree))
+	assert.Equal(t, 3, tree.Size())
+}
+
+func TestMultiTreeTreeOptions(t *testing.T) {
+	t.Parallel()
+
+	words := loadTestFile("test/assets/words.txt")
+
+	options := map[string][]TreeOption{
+		"Allocator":    {WithAllocator(NewArenaAllocator(64))},
+		"MaxPrefixLen": {WithMaxPrefixLen(1)},
+		"LeafSuffixes": {WithLeafSuffixes()},
+	}
+
+	for name, opts := range options {
+		name, opts := name, opts
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := newMultiTree(WithTreeOptions(opts...))
+			for i, w := range words {
+				tree.Insert(w, i)
+				tree.Insert(w, -i)
+			}
+
+			for i, w := range words[:len(words)/2] {
+				assert.True(t, tree.DeleteValue(w, i))
+			}
+
+			require.NoError(t, tree.tree.Validate())
+			assert.Equal(t, 2*len(words)-len(words)/2, tree.Size())
+
+			for i, w := range words {
+				values, found := tree.SearchAll(w)
+				require.True(t, found, string(w))
+
+				if i < len(words)/2 {
+					assert.Equal(t, []Value{-i}, values)
+				} else {
+					assert.Equal(t, []Value{i, -i}, values)
+				}
+			}
+
+			// the values are stored in the Leaf allocation, the Leaf value references them
+			leaf := tree.tree.searchLeaf(words[0])
+			assert.Same(t, &(*multiLeaf)(unsafe.Pointer(leaf)).entry, leaf.value) //#nosec:G103
+		})
+	}
+}
+
+func TestMultiTreeKeyTransformer(t *testing.T) {
+	t.Parallel()
+
+	tree := NewMultiTree(WithTreeOptions(WithKeyTransformer(FoldCase)))
+	tree.Insert(Key("Go"), "art")
+	tree.Insert(Key("go"), "cobra")
+	tree.Insert(Key("GOPHER"), "mascot")
+	tree.Insert(Key("rust"), "tokio")
+
+	values, found := tree.SearchAll(Key("GO"))
+	assert.True(t, found)
+	assert.Equal(t, []Value{"art", "cobra"}, values)
+
+	assert.Equal(t, []string{"Go=[art cobra]", "GOPHER=[mascot]", "rust=[tokio]"}, multiEntries(tree))
+
+	var keys []string
+
+	tree.ForEachPrefix(Key("gO"), func(node NodeKV) bool {
+		keys = append(keys, string(node.Key())+"="+formatValue(node.Value()))
+
+		return true
+	}, TraverseValues)
+
+	assert.Equal(t, []string{"Go=art", "Go=cobra", "GOPHER=mascot"}, keys)
+
+	assert.True(t, tree.DeleteValue(Key("gO"), "art"))
+
+	values, deleted := tree.Delete(Key("gopher"))
+	assert.True(t, deleted)
+	assert.Equal(t, []Value{"mascot"}, values)
+
+	it := tree.Iterator(TraverseValues)
+
+	node, err := it.Next()
+	require.NoError(t, err)
+	assert.Equal(t, Key("Go"), node.Key())
+	assert.Equal(t, "cobra", node.Value())
+	assert.Equal(t, 2, tree.Size())
+}
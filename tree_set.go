@@ -0,0 +1,206 @@
+package art
+
+import "bytes"
+
+// set stores the keys in a compact tree holding nil values,
+// the compact tree allocates its value pages on the first non-nil value, so a set never allocates them.
+type set struct {
+	tree *compactTree
+}
+
+// make sure that set implements all methods from the Set interface.
+var _ Set = (*set)(nil)
+
+// newSet creates an empty set.
+func newSet() *set {
+	return &set{tree: &compactTree{}}
+}
+
+// Add inserts the key with a nil value.
+func (s *set) Add(key Key) bool {
+	_, updated := s.tree.Insert(key, nil)
+
+	return !updated
+}
+
+// Remove deletes the key.
+func (s *set) Remove(key Key) bool {
+	_, deleted := s.tree.Delete(key)
+
+	return deleted
+}
+
+// Contains searches for the key.
+func (s *set) Contains(key Key) bool {
+	_, found := s.tree.Search(key)
+
+	return found
+}
+
+// Size returns the number of keys.
+func (s *set) Size() int {
+	return s.tree.Size()
+}
+
+// ForEach iterates over all keys.
+func (s *set) ForEach(callback KeyCallback, opts ...int) {
+	s.Range(nil, nil, callback, opts...)
+}
+
+// ForEachPrefix iterates over the keys in the range of the keys starting with the prefix.
+func (s *set) ForEachPrefix(prefix Key, callback KeyCallback, opts ...int) {
+	s.Range(prefix, prefixEnd(prefix), callback, opts...)
+}
+
+// prefixEnd returns the smallest key greater than all keys starting with the prefix,
+// nil if there is none.
+func prefixEnd(prefix Key) Key {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] < 0xff {
+			end := append(Key(nil), prefix[:i+1]...)
+			end[i]++
+
+			return end
+		}
+	}
+
+	return nil
+}
+
+// Range iterates over the keys in the range.
+func (s *set) Range(start, end Key, callback KeyCallback, opts ...int) {
+	if start != nil && end != nil && bytes.Compare(start, end) >= 0 {
+		return
+	}
+
+	rw := &rangeWalker{
+		tree:     s.tree,
+		start:    start,
+		end:      end,
+		reverse:  traverseOptions(opts...).hasReverse(),
+		callback: callback,
+	}
+
+	rw.walk(s.tree.root, start != nil, end != nil)
+}
+
+// rangeWalker visits the leaves of a compact tree in a key range.
+type rangeWalker struct {
+	tree     *compactTree
+	start    Key // start is the inclusive lower bound, nil for none
+	end      Key // end is the exclusive upper bound, nil for none
+	reverse  bool
+	callback KeyCallback
+}
+
+// walk visits the leaves of the subtree in the range, checkStart and checkEnd report whether
+// the subtree may hold keys out of the range on each side. Only the subtrees crossing a bound
+// are compared to it through their minimum and maximum keys, the keys inside the range are not compared.
+// It returns false as soon as the callback returns false or the keys are past the range.
+func (rw *rangeWalker) walk(r cref, checkStart, checkEnd bool) bool {
+	if r == 0 {
+		return true
+	}
+
+	if r.isLeaf() {
+		key := rw.tree.leafKey(r)
+
+		switch {
+		case checkStart && bytes.Compare(key, rw.start) < 0:
+			return !rw.reverse
+		case checkEnd && bytes.Compare(key, rw.end) >= 0:
+			return rw.reverse
+		}
+
+		return rw.callback(key)
+	}
+
+	return rw.tree.view(r).each(rw.reverse, func(_ keyChar, child cref) bool {
+		childStart, childEnd := checkStart, checkEnd
+
+		if checkStart {
+			if bytes.Compare(rw.tree.leafKey(rw.tree.maximum(child)), rw.start) < 0 {
+				return !rw.reverse // the keys before the range are skipped, or end the descending walk
+			}
+
+			childStart = bytes.Compare(rw.tree.leafKey(rw.tree.minimum(child)), rw.start) < 0
+		}
+
+		if checkEnd {
+			if bytes.Compare(rw.tree.leafKey(rw.tree.minimum(child)), rw.end) >= 0 {
+				return rw.reverse // the keys after the range end the ascending walk, or are skipped
+			}
+
+			childEnd = bytes.Compare(rw.tree.leafKey(rw.tree.maximum(child)), rw.end) >= 0
+		}
+
+		return rw.walk(child, childStart, childEnd)
+	})
+}
+
+// Union adds the keys of the smaller set to a copy of the larger one.
+func (s *set) Union(other Set) Set {
+	larger, smaller := Set(s), other
+	if other.Size() > s.Size() {
+		larger, smaller = other, s
+	}
+
+	union := larger.Clone()
+	smaller.ForEach(func(key Key) bool {
+		union.Add(key)
+
+		return true
+	})
+
+	return union
+}
+
+// Intersection adds the keys of the smaller set which are in the larger one.
+func (s *set) Intersection(other Set) Set {
+	larger, smaller := Set(s), other
+	if other.Size() > s.Size() {
+		larger, smaller = other, s
+	}
+
+	intersection := newSet()
+	smaller.ForEach(func(key Key) bool {
+		if larger.Contains(key) {
+			intersection.Add(key)
+		}
+
+		return true
+	})
+
+	return intersection
+}
+
+// Difference removes the keys of the other set from a copy of the set if the other set is smaller,
+// otherwise it adds the keys of the set which are not in the other set.
+func (s *set) Difference(other Set) Set {
+	if other.Size() < s.Size() {
+		difference := s.Clone()
+		other.ForEach(func(key Key) bool {
+			difference.Remove(key)
+
+			return true
+		})
+
+		return difference
+	}
+
+	difference := newSet()
+	s.ForEach(func(key Key) bool {
+		if !other.Contains(key) {
+			difference.Add(key)
+		}
+
+		return true
+	})
+
+	return difference
+}
+
+// Clone copies the pages of the compact tree.
+func (s *set) Clone() Set {
+	return &set{tree: s.tree.Clone().(*compactTree)} //nolint:forcetypeassert
+}
@@ -0,0 +1,190 @@
+package art
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSet(keys ...string) Set {
+	s := NewSet()
+	for _, k := range keys {
+		s.Add(Key(k))
+	}
+
+	return s
+}
+
+// setKeys returns the keys visited by the traversal.
+func setKeys(traverse func(KeyCallback)) []string {
+	keys := []string{}
+
+	traverse(func(key Key) bool {
+		keys = append(keys, string(key))
+
+		return true
+	})
+
+	return keys
+}
+
+func TestSetBasics(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSet("banana", "apple", "cherry", "", "apple")
+	assert.Equal(t, 4, s.Size())
+
+	assert.True(t, s.Contains(Key("apple")))
+	assert.True(t, s.Contains(Key("")))
+	assert.False(t, s.Contains(Key("app")))
+
+	assert.False(t, s.Add(Key("banana")))
+	assert.True(t, s.Add(Key("date")))
+	assert.True(t, s.Remove(Key("apple")))
+	assert.False(t, s.Remove(Key("apple")))
+	assert.Equal(t, 4, s.Size())
+
+	assert.Equal(t, []string{"", "banana", "cherry", "date"}, setKeys(func(cb KeyCallback) { s.ForEach(cb) }))
+	assert.Equal(t, []string{"date", "cherry", "banana", ""},
+		setKeys(func(cb KeyCallback) { s.ForEach(cb, TraverseReverse) }))
+
+	assert.True(t, s.Remove(Key("")))
+	assert.False(t, s.Contains(Key("")))
+	assert.False(t, s.Remove(Key("")))
+	assert.Equal(t, 3, s.Size())
+
+	// the leaves hold no value and the value pages are never allocated
+	assert.Empty(t, s.(*set).tree.values.pages)  //nolint:forcetypeassert
+	require.NoError(t, s.(*set).tree.Validate()) //nolint:forcetypeassert
+}
+
+func TestSetForEachPrefix(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSet("a", "ab", "abc", "abd", "ac", "b", "ab\xff", "ab\xff\xff", "b\xff")
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"a", "ab", "abc", "abd", "ab\xff", "ab\xff\xff", "ac", "b", "b\xff"}},
+		{"ab", []string{"ab", "abc", "abd", "ab\xff", "ab\xff\xff"}},
+		{"ab\xff", []string{"ab\xff", "ab\xff\xff"}},
+		{"b\xff", []string{"b\xff"}},
+		{"abe", []string{}},
+		{"c", []string{}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, setKeys(func(cb KeyCallback) { s.ForEachPrefix(Key(tt.prefix), cb) }), "%q", tt.prefix)
+	}
+
+	assert.Equal(t, Key("ac"), prefixEnd(Key("ab")))
+	assert.Equal(t, Key("b"), prefixEnd(Key("a\xff\xff")))
+	assert.Nil(t, prefixEnd(Key("\xff")))
+	assert.Nil(t, prefixEnd(nil))
+}
+
+func TestSetRange(t *testing.T) {
+	t.Parallel()
+
+	words := loadTestFile("test/assets/words.txt")
+	s := NewSet()
+
+	sorted := make([]string, 0, len(words))
+	for _, w := range words {
+		if s.Add(w) {
+			sorted = append(sorted, string(w))
+		}
+	}
+
+	sort.Strings(sorted)
+
+	rnd := rand.New(rand.NewSource(42)) //nolint:gosec
+
+	for i := 0; i < 200; i++ {
+		start, end := Key(sorted[rnd.Intn(len(sorted))]), Key(sorted[rnd.Intn(len(sorted))])
+		if i%4 == 0 {
+			start = start[:len(start)/2] // a bound which is not a key
+		}
+
+		if i%10 == 0 {
+			start = nil
+		}
+
+		if i%10 == 5 {
+			end = nil
+		}
+
+		want := []string{}
+
+		for _, w := range sorted {
+			if (start == nil || w >= string(start)) && (end == nil || w < string(end)) {
+				want = append(want, w)
+			}
+		}
+
+		assert.Equal(t, want, setKeys(func(cb KeyCallback) { s.Range(start, end, cb) }), "[%q, %q)", start, end)
+
+		reversed := setKeys(func(cb KeyCallback) { s.Range(start, end, cb, TraverseReverse) })
+		sort.Strings(reversed)
+		assert.Equal(t, want, reversed, "reverse [%q, %q)", start, end)
+	}
+}
+
+func TestSetRangeEarlyStop(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSet("a", "b", "c", "d", "e")
+
+	var keys []string
+
+	s.Range(Key("b"), Key("e"), func(key Key) bool {
+		keys = append(keys, string(key))
+
+		return len(keys) < 2
+	})
+	assert.Equal(t, []string{"b", "c"}, keys)
+
+	assert.Empty(t, setKeys(func(cb KeyCallback) { s.Range(Key("d"), Key("b"), cb) }))
+	assert.Equal(t, []string{"c", "b"}, setKeys(func(cb KeyCallback) { s.Range(Key("b"), Key("d"), cb, TraverseReverse) }))
+}
+
+func TestSetAlgebra(t *testing.T) {
+	t.Parallel()
+
+	a := newTestSet("apple", "banana", "cherry", "date")
+	b := newTestSet("banana", "date", "fig")
+	small := newTestSet("date")
+
+	keys := func(s Set) []string {
+		return setKeys(func(cb KeyCallback) { s.ForEach(cb) })
+	}
+
+	assert.Equal(t, []string{"apple", "banana", "cherry", "date", "fig"}, keys(a.Union(b)))
+	assert.Equal(t, []string{"apple", "banana", "cherry", "date", "fig"}, keys(b.Union(a)))
+	assert.Equal(t, []string{"banana", "date"}, keys(a.Intersection(b)))
+	assert.Equal(t, []string{"banana", "date"}, keys(b.Intersection(a)))
+	assert.Equal(t, []string{"apple", "cherry"}, keys(a.Difference(b)))
+	assert.Equal(t, []string{"fig"}, keys(b.Difference(a)))
+	assert.Equal(t, []string{"apple", "banana", "cherry"}, keys(a.Difference(small)))
+	assert.Empty(t, keys(small.Difference(a)))
+	assert.Empty(t, keys(a.Intersection(NewSet())))
+
+	withEmpty := newTestSet("", "apple")
+	assert.Equal(t, []string{"apple"}, keys(withEmpty.Difference(newTestSet(""))))
+	assert.Equal(t, []string{""}, keys(withEmpty.Intersection(newTestSet("", "banana"))))
+	assert.Equal(t, 1, withEmpty.Difference(newTestSet("")).Size())
+
+	// the operands are not modified
+	assert.Equal(t, []string{"apple", "banana", "cherry", "date"}, keys(a))
+	assert.Equal(t, []string{"banana", "date", "fig"}, keys(b))
+
+	clone := a.Clone()
+	clone.Add(Key("elderberry"))
+	assert.False(t, a.Contains(Key("elderberry")))
+	assert.Equal(t, 5, clone.Size())
+}
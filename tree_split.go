@@ -0,0 +1,337 @@
+package art
+
+import "bytes"
+
+// SplitAt moves all keys greater than or equal to the given key into a new tree.
+// The tree is cut along a single root-to-leaf path, the nodes off that path
+// are re-linked to one of the resulting trees without being copied.
+// The leaves of the smaller side are counted to update the tree sizes.
+func (tr *tree) SplitAt(key Key) (Tree, Tree) {
+	var cut splitCut
+
+	right := &tree{opts: tr.opts}
+	right.root = tr.splitRecursively(&tr.root, key, 0, &cut)
+	right.size = cut.movedLeaves(tr.size)
+
+	tr.size -= right.size
+	tr.version++
+
+	tr.retrackStats()
+	right.retrackStats()
+
+	return tr, right
+}
+
+// splitCut collects the subtrees cut off the split path on each side.
+type splitCut struct {
+	kept  []NodeRef // kept are the subtrees left in the tree
+	moved []NodeRef // moved are the subtrees moved to the new tree
+}
+
+// movedLeaves returns the number of leaves in the moved subtrees out of the total number of leaves.
+// Both sides are walked in lock step, so that only the smaller one is walked to its end.
+func (c *splitCut) movedLeaves(total int) int {
+	kept, keptLeaves := c.kept, 0
+	moved, movedLeaves := c.moved, 0
+
+	for len(kept) > 0 && len(moved) > 0 {
+		kept, keptLeaves = countStep(kept, keptLeaves)
+		moved, movedLeaves = countStep(moved, movedLeaves)
+	}
+
+	if len(moved) == 0 {
+		return movedLeaves
+	}
+
+	return total - keptLeaves
+}
+
+// countStep pops a Node from the stack and counts it if it is a Leaf, otherwise it pushes its children.
+// The leaves are not dereferenced, so the ones replaced while re-linking the nodes are still counted.
+func countStep(stack []NodeRef, leaves int) ([]NodeRef, int) {
+	nr := stack[len(stack)-1]
+	stack = stack[:len(stack)-1]
+
+	if nr.isLeaf() {
+		return stack, leaves + 1
+	}
+
+	for _, ref := range nr.childRefs() {
+		stack = append(stack, ref.ref)
+	}
+
+	return stack, leaves
+}
+
+// splitRecursively detaches all keys greater than or equal to the key
+// from the subtree located at the given depth and returns them as a separate subtree.
+// The subtrees cut off the split path are collected by the cut.
+func (tr *tree) splitRecursively(nrp *NodeRef, key Key, depth int, cut *splitCut) NodeRef {
+	nr := *nrp
+	if nr.isNil() {
+		return NodeRef{}
+	}
+
+	if nr.isLeaf() {
+		// the path of the Leaf matches the key
+		if bytes.Compare(nr.Leaf().key, tr.leafPart(key, depth)) < 0 {
+			cut.kept = append(cut.kept, nr)
+
+			return NodeRef{}
+		}
+
+		cut.moved = append(cut.moved, nr)
+		replaceRef(nrp, NodeRef{})
+
+		return nr
+	}
+
+	// compare the key against the whole Node prefix,
+	// the subtree goes entirely to one side on mismatch.
+	prefix := nr.fullPrefix(depth)
+	keyPart := key[depth:minInt(depth+len(prefix), len(key))]
+
+	if cmp := bytes.Compare(keyPart, prefix); cmp > 0 {
+		cut.kept = append(cut.kept, nr)
+
+		return NodeRef{}
+	} else if cmp < 0 {
+		cut.moved = append(cut.moved, nr)
+		replaceRef(nrp, NodeRef{})
+
+		return nr
+	}
+
+	keyOffset := depth + len(prefix)
+
+	kc := key.charAt(keyOffset)
+	if kc.invalid {
+		// the key ends at this Node, so it is less than or equal to all its keys
+		cut.moved = append(cut.moved, nr)
+		replaceRef(nrp, NodeRef{})
+
+		return nr
+	}
+
+	var left, right []childRef
+
+	for _, ref := range nr.childRefs() {
+		switch {
+		case ref.kc.invalid || ref.kc.ch < kc.ch:
+			cut.kept = append(cut.kept, ref.ref)
+			left = append(left, ref)
+		case ref.kc.ch > kc.ch:
+			cut.moved = append(cut.moved, ref.ref)
+			right = append(right, ref)
+		default:
+			child := ref.ref
+			if moved := tr.splitRecursively(&child, key, keyOffset+1, cut); !moved.isNil() {
+				right = append(right, childRef{kc: ref.kc, ref: moved})
+			}
+
+			if !child.isNil() {
+				left = append(left, childRef{kc: ref.kc, ref: child})
+			}
+		}
+	}
+
+	replaceRef(nrp, tr.assembleNode(nr, left, depth))
+	rightNR := tr.assembleNode(nr, right, depth)
+
+	// the children of the Node have been re-linked to the new nodes
+	tr.opts.factory.Release(nr)
+
+	return rightNR
+}
+
+// assembleNode creates the smallest Node holding the given children with the prefix of src.
+// A single child is returned directly, its prefix is extended with the prefix of src.
+func (tr *tree) assembleNode(src NodeRef, refs []childRef, depth int) NodeRef {
+	switch len(refs) {
+	case 0:
+		return NodeRef{}
+	case 1:
+		child := refs[0].ref
+		if child.isLeaf() {
+			return extendLeaf(child, src.fullPrefix(depth), refs[0].kc, &tr.opts)
+		}
+
+		srcNode, childNode := src.node(), child.node()
+		prefixLen := int(srcNode.prefixLen) + 1 + int(childNode.prefixLen)
+
+		// the complete prefixes are joined, the truncated ones are restored from the minimum Leaf
+		var prefix []byte
+		if srcNode.hasFullPrefix() && childNode.hasFullPrefix() {
+			prefix = append(append(append(prefix, srcNode.storedPrefix()...), refs[0].kc.ch), childNode.storedPrefix()...)
+		} else {
+			prefix = child.minimum().key[depth:]
+		}
+
+		child.setPrefix(prefix, prefixLen, tr.opts.maxPrefixLen)
+
+		return child
+	}
+
+	nr := tr.opts.factory.NewNode4()
+	copyNode(nr.node(), src.node())
+
+	for _, ref := range refs {
+		nr.addChild(ref.kc, ref.ref, &tr.opts)
+	}
+
+	return nr
+}
+
+// Join merges two trees whose key ranges don't overlap into a single tree.
+// All keys of the left tree must be less than all keys of the right tree,
+// otherwise ErrOverlappingKeys is returned.
+// Only the trees returned by New without WithKeyTransformer, and by their SplitAt, Clone and Join,
+// are supported. Both trees must also use the same NodeFactory, the same WithMaxPrefixLen setting
+// and the same WithLeafSuffixes setting. Otherwise, including for the trees returned by
+// New with WithKeyTransformer, NewCompact, NewMultiTree and NewSet, ErrUnsupportedTree is returned.
+// The nodes of both trees are re-linked into the resulting tree,
+// so left and right are emptied on success.
+func Join(left, right Tree) (Tree, error) {
+	lt, lok := left.(*tree)
+	rt, rok := right.(*tree)
+
+	// the nodes of the right tree are released into the factory of the joined tree later on
+	if !lok || !rok || lt.opts.factory != rt.opts.factory ||
+		lt.opts.maxPrefixLen != rt.opts.maxPrefixLen || lt.opts.leafSuffixes != rt.opts.leafSuffixes {
+		return nil, ErrUnsupportedTree
+	}
+
+	if !lt.root.isNil() && !rt.root.isNil() &&
+		bytes.Compare(lt.boundaryKey(lt.root, nil, true), rt.boundaryKey(rt.root, nil, false)) >= 0 {
+		return nil, ErrOverlappingKeys
+	}
+
+	joined := &tree{opts: lt.opts}
+	joined.root = joinRecursively(lt.root, rt.root, 0, &joined.opts)
+	joined.size = lt.size + rt.size
+
+	for _, tr := range []*tree{lt, rt} {
+		tr.root = NodeRef{}
+		tr.size = 0
+		tr.version++
+		tr.retrackStats()
+	}
+
+	joined.retrackStats()
+
+	return joined, nil
+}
+
+// joinRecursively merges two subtrees located at the same depth,
+// all keys of the left subtree are less than all keys of the right one.
+func joinRecursively(left, right NodeRef, depth int, opts *treeOptions) NodeRef {
+	if left.isNil() {
+		return right
+	}
+
+	if right.isNil() {
+		return left
+	}
+
+	// the paths alias the stored prefixes and the Leaf keys which are trimmed below,
+	// so the branch bytes are read and the new prefixes are copied before trimming.
+	lp := compressedPath(left, depth, opts)
+	rp := compressedPath(right, depth, opts)
+	lcp := findLongestCommonPrefix(lp, rp, 0)
+
+	switch {
+	case lcp < len(lp) && lcp < len(rp):
+		// the paths diverge inside the prefixes, branch them with a new Node4
+		lc, rc := lp[lcp], rp[lcp]
+
+		nr4 := opts.factory.NewNode4()
+		nr4.setPrefix(lp, lcp, opts.maxPrefixLen)
+		nr4.addChild(keyChar{ch: lc}, trimPrefix(left, depth, lcp+1, opts), opts)
+		nr4.addChild(keyChar{ch: rc}, trimPrefix(right, depth, lcp+1, opts), opts)
+
+		return nr4
+
+	case lcp == len(lp) && left.isLeaf():
+		// the left key is a prefix of all right keys, it becomes a zero byte child
+		if lcp == len(rp) {
+			right.addChild(keyCharInvalid, trimPrefix(left, depth, lcp, opts), opts)
+
+			return right
+		}
+
+		rc := rp[lcp]
+
+		nr4 := opts.factory.NewNode4()
+		nr4.setPrefix(lp, lcp, opts.maxPrefixLen)
+		nr4.addChild(keyCharInvalid, trimPrefix(left, depth, lcp, opts), opts)
+		nr4.addChild(keyChar{ch: rc}, trimPrefix(right, depth, lcp+1, opts), opts)
+
+		return nr4
+
+	case lcp == len(lp) && lcp == len(rp):
+		// both nodes share the same path, merge the right children into the left Node
+		for _, ref := range right.childRefs() {
+			mergeChild(&left, ref.kc, ref.ref, depth+lcp+1, opts)
+		}
+
+		opts.factory.Release(right)
+
+		return left
+
+	case lcp == len(lp):
+		// the right subtree continues below one of the left Node children
+		rc := rp[lcp]
+		mergeChild(&left, keyChar{ch: rc}, trimPrefix(right, depth, lcp+1, opts), depth+lcp+1, opts)
+
+		return left
+
+	default:
+		// the left subtree continues below one of the right Node children
+		kc := keyChar{ch: lp[lcp]}
+		left = trimPrefix(left, depth, lcp+1, opts)
+		n := toNode(right)
+
+		next := n.childAt(n.index(kc))
+		if !next.isNil() {
+			*next = joinRecursively(left, *next, depth+lcp+1, opts)
+			right.node().suffixes |= next.suffixes(kc, opts.leafSuffixes)
+		} else {
+			right.addChild(kc, left, opts)
+		}
+
+		return right
+	}
+}
+
+// mergeChild adds the child subtree with greater keys to the Node under the given key character,
+// joining it with the existing child if there is one.
+func mergeChild(nr *NodeRef, kc keyChar, child NodeRef, depth int, opts *treeOptions) {
+	n := toNode(*nr)
+
+	next := n.childAt(n.index(kc))
+	if !next.isNil() {
+		*next = joinRecursively(*next, child, depth, opts)
+		nr.node().suffixes |= next.suffixes(kc, opts.leafSuffixes)
+	} else {
+		nr.addChild(kc, child, opts)
+	}
+}
+
+// trimPrefix removes the first n bytes of the prefix of the Node located at the given depth,
+// the Leaf storing a key suffix moves n bytes deeper.
+func trimPrefix(nr NodeRef, depth int, n int, opts *treeOptions) NodeRef {
+	if nr.isLeaf() {
+		return trimLeaf(nr, n, opts)
+	}
+
+	node := nr.node()
+	prefixLen := int(node.prefixLen) - n
+
+	if node.hasFullPrefix() {
+		nr.setPrefix(node.storedPrefix()[n:], prefixLen, opts.maxPrefixLen)
+	} else {
+		nr.setPrefix(nr.minimum().key[depth+n:], prefixLen, opts.maxPrefixLen)
+	}
+
+	return nr
+}
@@ -0,0 +1,359 @@
+package art
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectKeys returns all keys of the tree in ascending order.
+func collectKeys(t Tree) []string {
+	var keys []string
+
+	t.ForEach(func(node NodeKV) bool {
+		keys = append(keys, string(node.Key()))
+
+		return true
+	})
+
+	return keys
+}
+
+// assertSplit checks that the left tree holds the keys less than the split key
+// and the right tree holds the rest.
+func assertSplit(t *testing.T, keys []string, splitKey Key, left, right Tree) {
+	t.Helper()
+
+	var expLeft, expRight []string
+
+	for _, k := range keys {
+		if bytes.Compare(Key(k), splitKey) < 0 {
+			expLeft = append(expLeft, k)
+		} else {
+			expRight = append(expRight, k)
+		}
+	}
+
+	assert.Equal(t, expLeft, collectKeys(left))
+	assert.Equal(t, expRight, collectKeys(right))
+	assert.Equal(t, len(expLeft), left.Size())
+	assert.Equal(t, len(expRight), right.Size())
+
+	for _, k := range expLeft {
+		val, found := left.Search(Key(k))
+		assert.True(t, found, k)
+		assert.Equal(t, k, val)
+
+		_, found = right.Search(Key(k))
+		assert.False(t, found, k)
+	}
+
+	for _, k := range expRight {
+		val, found := right.Search(Key(k))
+		assert.True(t, found, k)
+		assert.Equal(t, k, val)
+
+		_, found = left.Search(Key(k))
+		assert.False(t, found, k)
+	}
+}
+
+func TestTreeSplitAt(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{
+		"", "a", "aa", "aab", "ab", "abc", "abcdefghijklmnopqrstuvwxyz",
+		"abcdefghijklmnopqrstuvwxyz1", "abcdefghijklmnopqrstuvwxyz2",
+		"b", "ba", "bb", "c", "cde", "cdef", "z",
+	}
+	sort.Strings(keys)
+
+	splitKeys := []string{
+		"", "0", "a", "aa", "aaa", "ab", "abcdefghijklmnop", "abcdefghijklmnopqrstuvwxyz",
+		"abcdefghijklmnopqrstuvwxyz0", "abcdefghijklmnopqrstuvwxyz2", "abd",
+		"b", "bab", "c", "cd", "cdeg", "y", "z", "zz",
+	}
+
+	for _, splitKey := range splitKeys {
+		splitKey := splitKey
+		t.Run(splitKey, func(t *testing.T) {
+			t.Parallel()
+
+			tree := New()
+			for _, k := range keys {
+				tree.Insert(Key(k), k)
+			}
+
+			left, right := tree.SplitAt(Key(splitKey))
+			assert.Same(t, tree, left)
+			assertSplit(t, keys, Key(splitKey), left, right)
+		})
+	}
+}
+
+func TestTreeSplitAtEmptyTree(t *testing.T) {
+	t.Parallel()
+
+	left, right := New().SplitAt(Key("key"))
+	assert.Equal(t, 0, left.Size())
+	assert.Equal(t, 0, right.Size())
+}
+
+func TestTreeSplitAtWords(t *testing.T) {
+	t.Parallel()
+
+	tree := New()
+	data := loadTestFile("test/assets/words.txt")
+
+	keys := make([]string, 0, len(data))
+	for _, d := range data {
+		tree.Insert(d, string(d))
+		keys = append(keys, string(d))
+	}
+
+	sort.Strings(keys)
+
+	left, right := tree.SplitAt(Key("monkey"))
+	assertSplit(t, keys, Key("monkey"), left, right)
+}
+
+func TestTreeSplitAtAndJoinUUIDs(t *testing.T) {
+	t.Parallel()
+
+	tree, data := treeWithData("test/assets/uuid.txt")
+
+	left, right := tree.SplitAt(data[len(data)/2])
+
+	joined, err := Join(left, right)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), joined.Size())
+	assert.Equal(t, 0, left.Size())
+	assert.Equal(t, 0, right.Size())
+
+	for _, d := range data {
+		val, found := joined.Search(d)
+		assert.True(t, found)
+		assert.Equal(t, d, val)
+	}
+
+	for _, d := range data {
+		_, deleted := joined.Delete(d)
+		assert.True(t, deleted)
+	}
+
+	assert.Equal(t, 0, joined.Size())
+}
+
+func TestTreeJoin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		left  []string
+		right []string
+	}{
+		{"Empty", nil, nil},
+		{"EmptyLeft", nil, []string{"a", "b"}},
+		{"EmptyRight", []string{"a", "b"}, nil},
+		{"Leaves", []string{"a"}, []string{"b"}},
+		{"LeafPrefixOfLeaf", []string{"a"}, []string{"ab"}},
+		{"LeafPrefixOfNode", []string{"a"}, []string{"ab", "ac"}},
+		{"LeafBeforeNode", []string{"a"}, []string{"ba", "baa"}},
+		{"NodeBeforeLeaf", []string{"aa", "ab"}, []string{"b"}},
+		{"LeafAsZeroChild", []string{"ab"}, []string{"abc", "abd"}},
+		{"DivergingPrefixes", []string{"aaaa1", "aaaa2"}, []string{"aabb1", "aabb2"}},
+		{"SamePrefix", []string{"ka", "kb"}, []string{"kc", "kd"}},
+		{"SharedChild", []string{"ka1", "ka2", "kb"}, []string{"kb1", "kc"}},
+		{"NodeBelowRightChild", []string{"kaa1", "kaa2"}, []string{"kab", "kb"}},
+		{"NodeBelowLeftChild", []string{"ka", "kb"}, []string{"kbz1", "kbz2"}},
+		{
+			"LongPrefixes",
+			[]string{"abcdefghijklmnopqrstuvwxyz1", "abcdefghijklmnopqrstuvwxyz2"},
+			[]string{"abcdefghijklmnopqrstuvwxzz1", "abcdefghijklmnopqrstuvwxzz2"},
+		},
+		{
+			"GrowNode",
+			[]string{"k0", "k1", "k2", "k3"},
+			[]string{"k4", "k5", "k6", "k7", "k8", "k9"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			left, right := New(), New()
+			for _, k := range tt.left {
+				left.Insert(Key(k), k)
+			}
+
+			for _, k := range tt.right {
+				right.Insert(Key(k), k)
+			}
+
+			joined, err := Join(left, right)
+			require.NoError(t, err)
+
+			expected := append(append([]string{}, tt.left...), tt.right...)
+			if len(expected) == 0 {
+				expected = nil
+			}
+
+			assert.Equal(t, expected, collectKeys(joined))
+			assert.Equal(t, len(expected), joined.Size())
+			require.NoError(t, joined.Validate())
+
+			for _, k := range expected {
+				val, found := joined.Search(Key(k))
+				assert.True(t, found, k)
+				assert.Equal(t, k, val)
+			}
+		})
+	}
+}
+
+func TestTreeJoinOverlapping(t *testing.T) {
+	t.Parallel()
+
+	left, right := New(), New()
+	left.Insert(Key("a"), 1)
+	left.Insert(Key("c"), 3)
+	right.Insert(Key("b"), 2)
+
+	_, err := Join(left, right)
+	assert.ErrorIs(t, err, ErrOverlappingKeys)
+	assert.Equal(t, 2, left.Size())
+	assert.Equal(t, 1, right.Size())
+
+	_, err = Join(left, left)
+	assert.ErrorIs(t, err, ErrOverlappingKeys)
+}
+
+func TestTreeSplitAtAndJoinMaxPrefixLen(t *testing.T) {
+	t.Parallel()
+
+	data := namespacedKeys(loadTestFile("test/assets/uuid.txt"))[:5000]
+
+	for _, mode := range []int{0, OptimisticPrefixLen, PessimisticPrefixLen} {
+		tree := New(WithMaxPrefixLen(mode))
+		for _, d := range data {
+			tree.Insert(d, d)
+		}
+
+		clone := tree.Clone()
+
+		left, right := tree.SplitAt(data[0])
+		assert.Equal(t, len(data), left.Size()+right.Size())
+
+		joined, err := Join(left, right)
+		require.NoError(t, err)
+		assert.True(t, Equal(clone, joined, nil))
+
+		for _, d := range data {
+			val, found := joined.Search(d)
+			assert.True(t, found)
+			assert.Equal(t, d, val)
+		}
+	}
+}
+
+// randomKeys returns n random keys over a small alphabet, so that they share prefixes and hit every Node kind.
+func randomKeys(rnd *rand.Rand, n int) []Key {
+	const alphabet = "\x00abcdefghijklmnopqrstuvwxyz"
+
+	keys := make([]Key, n)
+	for i := range keys {
+		key := make(Key, rnd.Intn(12))
+		for j := range key {
+			key[j] = alphabet[rnd.Intn(ternary(j < 2, len(alphabet), 3))]
+		}
+
+		keys[i] = key
+	}
+
+	return keys
+}
+
+func TestTreeSplitAtAndJoinRandom(t *testing.T) {
+	t.Parallel()
+
+	options := map[string][]TreeOption{
+		"Default":      nil,
+		"MaxPrefixLen": {WithMaxPrefixLen(1)},
+		"Pessimistic":  {WithMaxPrefixLen(PessimisticPrefixLen)},
+		"LeafSuffixes": {WithLeafSuffixes()},
+		"Arena":        {WithLeafSuffixes(), WithAllocator(NewArenaAllocator(64))},
+	}
+
+	for name, opts := range options {
+		name, opts := name, opts
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rnd := rand.New(rand.NewSource(1)) //nolint:gosec
+
+			for round := 0; round < 300; round++ {
+				keys := randomKeys(rnd, 1+rnd.Intn(200))
+
+				tree := New(opts...)
+				for _, k := range keys {
+					tree.Insert(k, string(k))
+				}
+
+				all := collectKeys(tree)
+				splitKey := randomKeys(rnd, 1)[0]
+
+				left, right := tree.SplitAt(splitKey)
+				require.NoError(t, left.Validate(), "split at %q", splitKey)
+				require.NoError(t, right.Validate(), "split at %q", splitKey)
+				assertSplit(t, all, splitKey, left, right)
+
+				joined, err := Join(left, right)
+				require.NoError(t, err)
+				require.NoError(t, joined.Validate(), "join at %q", splitKey)
+				assert.Equal(t, len(all), joined.Size())
+
+				for _, k := range all {
+					val, found := joined.Search(Key(k))
+					require.True(t, found, "key %q split at %q", k, splitKey)
+					assert.Equal(t, k, val)
+				}
+			}
+		})
+	}
+}
+
+func TestTreeJoinUnsupportedTrees(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		left, right Tree
+	}{
+		{"Allocators", New(), New(WithAllocator(NewArenaAllocator(0)))},
+		{"MaxPrefixLen", New(), New(WithMaxPrefixLen(PessimisticPrefixLen))},
+		{"LeafSuffixes", New(WithMaxPrefixLen(PessimisticPrefixLen)), New(WithLeafSuffixes())},
+		{"KeyTransformer", New(WithKeyTransformer(FoldCase)), New(WithKeyTransformer(FoldCase))},
+		{"Compact", NewCompact(), NewCompact()},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.left.Insert(Key("a"), 1)
+			tt.right.Insert(Key("b"), 2)
+
+			_, err := Join(tt.left, tt.right)
+			assert.ErrorIs(t, err, ErrUnsupportedTree)
+			assert.Equal(t, 1, tt.left.Size())
+			assert.Equal(t, 1, tt.right.Size())
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package art
+
+import "unsafe"
+
+// statsCollector accumulates the statistics of the nodes visited by a tree walk.
+type statsCollector struct {
+	stats    TreeStats
+	depthSum int
+}
+
+// kindCapacity returns the maximum number of children of the Node kind.
+func kindCapacity(kind Kind) int {
+	switch kind { //nolint:exhaustive
+	case Node4Kind:
+		return node4Max
+	case Node16Kind:
+		return node16Max
+	case Node48Kind:
+		return node48Max
+	case Node256Kind:
+		return node256Max
+	}
+
+	return 0
+}
+
+// addNode records an inner Node.
+func (c *statsCollector) addNode(kind Kind, size int, children int, prefixLen int) {
+	ks := &c.stats.Kinds[kind]
+	ks.Count++
+	ks.Bytes += size
+	ks.Children += children
+
+	if c.stats.PrefixLens == nil {
+		c.stats.PrefixLens = make(map[int]int)
+	}
+
+	c.stats.PrefixLens[prefixLen]++
+}
+
+// addLeaf records a Leaf located below depth inner nodes.
+func (c *statsCollector) addLeaf(size int, keyLen int, depth int) {
+	ks := &c.stats.Kinds[LeafKind]
+	ks.Count++
+	ks.Bytes += size
+
+	c.stats.Leaves++
+	c.stats.LeafKeyBytes += keyLen
+	c.stats.MaxDepth = maxInt(c.stats.MaxDepth, depth)
+	c.depthSum += depth
+}
+
+// result computes the derived statistics.
+func (c *statsCollector) result() TreeStats {
+	stats := c.stats
+
+	for kind := range stats.Kinds {
+		ks := &stats.Kinds[kind]
+		stats.Bytes += ks.Bytes
+
+		if capacity := kindCapacity(Kind(kind)); ks.Count > 0 && capacity > 0 {
+			ks.FillFactor = float64(ks.Children) / float64(ks.Count*capacity)
+		}
+	}
+
+	if stats.Leaves > 0 {
+		stats.AvgDepth = float64(c.depthSum) / float64(stats.Leaves)
+	}
+
+	return stats
+}
+
+// record adds the Node, without its children, to the statistics tracked by a tree created with WithStatsTracking,
+// or removes it if sign is -1. The depths are not tracked.
+func (c *statsCollector) record(nr NodeRef, f NodeFactory, sign int) {
+	if nr.isNil() {
+		return
+	}
+
+	ks := &c.stats.Kinds[nr.kind()]
+	ks.Count += sign
+
+	if nr.isLeaf() {
+		leaf := nr.Leaf()
+		ks.Bytes += sign * leafSize(f, leaf)
+		c.stats.Leaves += sign
+		c.stats.LeafKeyBytes += sign * len(leaf.key)
+
+		return
+	}
+
+	n := nr.node()
+	ks.Bytes += sign * innerNodeSize(nr)
+	ks.Children += sign * int(n.childrenLen)
+
+	if c.stats.PrefixLens == nil {
+		c.stats.PrefixLens = make(map[int]int)
+	}
+
+	if c.stats.PrefixLens[int(n.prefixLen)] += sign; c.stats.PrefixLens[int(n.prefixLen)] == 0 {
+		delete(c.stats.PrefixLens, int(n.prefixLen))
+	}
+}
+
+// track adds the Node, without its children, to the tracked statistics of the tree, see WithStatsTracking.
+// It must be called once the Node is linked into the tree, and again after each modification of the Node
+// following an untrack call.
+func (opts *treeOptions) track(nr NodeRef) {
+	if opts.stats != nil {
+		opts.stats.record(nr, opts.factory, 1)
+	}
+}
+
+// untrack removes the Node, without its children, from the tracked statistics of the tree.
+func (opts *treeOptions) untrack(nr NodeRef) {
+	if opts.stats != nil {
+		opts.stats.record(nr, opts.factory, -1)
+	}
+}
+
+// Stats returns the statistics of the tree collected by walking all its nodes,
+// or the tracked statistics if the tree is created with WithStatsTracking.
+func (tr *tree) Stats() TreeStats {
+	if tr.opts.stats != nil {
+		stats := tr.opts.stats.result()
+
+		stats.PrefixLens = nil
+		for prefixLen, count := range tr.opts.stats.stats.PrefixLens {
+			if stats.PrefixLens == nil {
+				stats.PrefixLens = make(map[int]int, len(tr.opts.stats.stats.PrefixLens))
+			}
+
+			stats.PrefixLens[prefixLen] = count
+		}
+
+		return stats
+	}
+
+	var c statsCollector
+	tr.collectStats(&c, tr.root, 0)
+
+	return c.result()
+}
+
+// retrackStats replaces the tracked statistics of a tree created with WithStatsTracking by the ones of a walk,
+// it is called by the operations moving whole subtrees between the trees.
+func (tr *tree) retrackStats() {
+	if tr.opts.stats == nil {
+		return
+	}
+
+	c := &statsCollector{}
+	tr.collectStats(c, tr.root, 0)
+	c.stats.MaxDepth, c.depthSum = 0, 0
+
+	tr.opts.stats = c
+}
+
+// collectStats walks the subtree located below depth inner nodes.
+func (tr *tree) collectStats(c *statsCollector, nr NodeRef, depth int) {
+	if nr.isNil() {
+		return
+	}
+
+	if nr.isLeaf() {
+		c.addLeaf(leafSize(tr.opts.factory, nr.Leaf()), len(nr.Leaf().key), depth)
+
+		return
+	}
+
+	n := nr.node()
+	c.addNode(nr.kind(), innerNodeSize(nr), int(n.childrenLen), int(n.prefixLen))
+
+	for _, child := range toNode(nr).allChildren() {
+		tr.collectStats(c, child, depth+1)
+	}
+}
+
+// nodeSize returns the size of the inner Node structure of the given kind.
+func nodeSize(kind Kind) int {
+	switch kind { //nolint:exhaustive
+	case Node4Kind:
+		return int(unsafe.Sizeof(Node4{}))
+	case Node16Kind:
+		return int(unsafe.Sizeof(Node16{}))
+	case Node48Kind:
+		return int(unsafe.Sizeof(Node48{}))
+	case Node256Kind:
+		return int(unsafe.Sizeof(Node256{}))
+	}
+
+	return 0
+}
+
+// innerNodeSize returns the size of the inner Node including its heap-allocated prefix.
+func innerNodeSize(nr NodeRef) int {
+	size := nodeSize(nr.kind())
+	if n := nr.node(); n.prefixExt != nil {
+		size += int(n.storedLen)
+	}
+
+	return size
+}
+
+// leafSizer is implemented by the NodeFactory allocating the leaves with a layout of their own.
+type leafSizer interface {
+	// leafSize returns the approximate size of the Leaf including its key.
+	leafSize(leaf *Leaf) int
+}
+
+// leafSize returns the approximate size of the Leaf allocated by the NodeFactory including its key.
+// The leaves of the other factories, such as the arena allocator, are assumed to store their key separately.
+func leafSize(f NodeFactory, leaf *Leaf) int {
+	if sizer, ok := f.(leafSizer); ok {
+		return sizer.leafSize(leaf)
+	}
+
+	return int(unsafe.Sizeof(Leaf{})) + cap(leaf.key)
+}
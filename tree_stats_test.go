@@ -0,0 +1,225 @@
+package art
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeStats(t *testing.T) {
+	t.Parallel()
+
+	for _, e := range engines {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := e.newTree()
+			assert.Equal(t, TreeStats{}, tree.Stats())
+
+			for _, k := range []string{"aa", "ab", "b"} {
+				tree.Insert(Key(k), k)
+			}
+
+			stats := tree.Stats()
+			assert.Equal(t, 3, stats.Leaves)
+			assert.Equal(t, 3, stats.Kinds[LeafKind].Count)
+			assert.Equal(t, 2, stats.Kinds[Node4Kind].Count)
+			assert.Equal(t, 4, stats.Kinds[Node4Kind].Children)
+			assert.InDelta(t, 0.5, stats.Kinds[Node4Kind].FillFactor, 1e-9)
+			assert.Equal(t, 0, stats.Kinds[Node16Kind].Count)
+			assert.Equal(t, 5, stats.LeafKeyBytes)
+			assert.Equal(t, 2, stats.MaxDepth)
+			assert.InDelta(t, 5.0/3.0, stats.AvgDepth, 1e-9)
+			assert.Equal(t, map[int]int{0: 2}, stats.PrefixLens)
+			assert.Equal(t, stats.Kinds[LeafKind].Bytes+stats.Kinds[Node4Kind].Bytes, stats.Bytes)
+
+			// each call collects a new histogram
+			stats.PrefixLens[0] = 100
+			assert.Equal(t, map[int]int{0: 2}, tree.Stats().PrefixLens)
+
+			// the stats reflect the modifications
+			tree.Insert(Key("abcdef"), "abcdef")
+			stats = tree.Stats()
+			assert.Equal(t, 4, stats.Leaves)
+			assert.Equal(t, 3, stats.Kinds[Node4Kind].Count)
+			assert.Equal(t, 3, stats.MaxDepth)
+
+			tree.Delete(Key("abcdef"))
+			assert.Equal(t, 3, tree.Stats().Leaves)
+		})
+	}
+}
+
+func TestTreeStatsWords(t *testing.T) {
+	t.Parallel()
+
+	tree, data := treeWithData("test/assets/words.txt")
+
+	stats := tree.Stats()
+	nodes := collectStats(tree.Iterator(TraverseAll))
+
+	assert.Equal(t, nodes.leafCount, stats.Kinds[LeafKind].Count)
+	assert.Equal(t, nodes.node4Count, stats.Kinds[Node4Kind].Count)
+	assert.Equal(t, nodes.node16Count, stats.Kinds[Node16Kind].Count)
+	assert.Equal(t, nodes.node48Count, stats.Kinds[Node48Kind].Count)
+	assert.Equal(t, nodes.node256Count, stats.Kinds[Node256Kind].Count)
+	assert.Equal(t, nodes.node4Count*int(unsafe.Sizeof(Node4{})), stats.Kinds[Node4Kind].Bytes)
+
+	keyBytes := 0
+	for _, d := range data {
+		keyBytes += len(d)
+	}
+
+	assert.Equal(t, keyBytes, stats.LeafKeyBytes)
+
+	innerNodes := 0
+	for _, n := range stats.PrefixLens {
+		innerNodes += n
+	}
+
+	assert.Equal(t, nodes.node4Count+nodes.node16Count+nodes.node48Count+nodes.node256Count, innerNodes)
+
+	compact := NewCompact()
+	for _, d := range data {
+		compact.Insert(d, d)
+	}
+
+	compactStats := compact.Stats()
+	assert.Equal(t, stats.Leaves, compactStats.Leaves)
+	assert.Equal(t, stats.LeafKeyBytes, compactStats.LeafKeyBytes)
+	assert.Equal(t, stats.MaxDepth, compactStats.MaxDepth)
+}
+
+// walkStats returns the statistics of the tree collected by a walk, without the depths which are not tracked.
+func walkStats(tr *tree) TreeStats {
+	var c statsCollector
+	tr.collectStats(&c, tr.root, 0)
+
+	stats := c.result()
+	stats.MaxDepth, stats.AvgDepth = 0, 0
+
+	return stats
+}
+
+func TestTreeStatsTracking(t *testing.T) {
+	t.Parallel()
+
+	options := map[string][]TreeOption{
+		"Default":      nil,
+		"Allocator":    {WithAllocator(NewArenaAllocator(16))},
+		"MaxPrefixLen": {WithMaxPrefixLen(1)},
+		"Pessimistic":  {WithMaxPrefixLen(PessimisticPrefixLen)},
+		"LeafSuffixes": {WithLeafSuffixes()},
+	}
+
+	for name, opts := range options {
+		name, opts := name, opts
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rnd := rand.New(rand.NewSource(1)) //nolint:gosec
+
+			tr := New(append([]TreeOption{WithStatsTracking()}, opts...)...).(*tree) //nolint:forcetypeassert
+			assert.Equal(t, TreeStats{}, tr.Stats())
+
+			for round := 0; round < 50; round++ {
+				keys := randomKeys(rnd, 100)
+				for i, k := range keys {
+					if i%3 == 0 {
+						keys[i] = append(k, strings.Repeat("z", 30)...) // not stored inline
+					}
+				}
+
+				for _, k := range keys {
+					tr.Insert(k, round)
+				}
+
+				require.Equal(t, walkStats(tr), tr.Stats(), "round %d after insertions", round)
+
+				for _, k := range keys[:rnd.Intn(len(keys))] {
+					tr.Delete(k)
+				}
+
+				require.Equal(t, walkStats(tr), tr.Stats(), "round %d after deletions", round)
+			}
+
+			// each call returns a new histogram
+			stats := tr.Stats()
+			stats.PrefixLens[0] = -1
+			assert.NotEqual(t, -1, tr.Stats().PrefixLens[0])
+
+			// the trees returned by the operations moving subtrees track their own statistics
+			clone := tr.Clone().(*tree) //nolint:forcetypeassert
+			assert.Equal(t, walkStats(clone), clone.Stats())
+
+			left, right := tr.SplitAt(Key("b"))
+			lt, rt := left.(*tree), right.(*tree) //nolint:forcetypeassert
+			assert.Equal(t, walkStats(lt), lt.Stats())
+			assert.Equal(t, walkStats(rt), rt.Stats())
+
+			rt.Insert(Key("c"), 0)
+			assert.Equal(t, walkStats(lt), lt.Stats())
+			assert.Equal(t, walkStats(rt), rt.Stats())
+
+			joined, err := Join(lt, rt)
+			require.NoError(t, err)
+
+			jt := joined.(*tree) //nolint:forcetypeassert
+			assert.Equal(t, walkStats(jt), jt.Stats())
+			assert.Equal(t, TreeStats{}, lt.Stats())
+			assert.Equal(t, TreeStats{}, rt.Stats())
+
+			for _, key := range leafKeys(jt) {
+				jt.Delete(Key(key))
+			}
+
+			assert.Equal(t, walkStats(jt), jt.Stats())
+			assert.Equal(t, walkStats(clone), clone.Stats())
+		})
+	}
+}
+
+func TestTreeStatsLeafSize(t *testing.T) {
+	t.Parallel()
+
+	short, long := Key("short"), Key(strings.Repeat("long", 10))
+
+	tests := []struct {
+		name      string
+		tree      *tree
+		shortSize int
+		longSize  int
+	}{
+		{
+			"Default", newTree(),
+			int(unsafe.Sizeof(inlineLeaf{})), int(unsafe.Sizeof(Leaf{})) + len(long),
+		},
+		{
+			// the arena leaves are never inline
+			"Allocator", newTree(WithAllocator(NewArenaAllocator(0))),
+			int(unsafe.Sizeof(Leaf{})) + len(short), int(unsafe.Sizeof(Leaf{})) + len(long),
+		},
+		{
+			"MultiTree", newMultiTree().tree,
+			int(unsafe.Sizeof(multiLeaf{})), int(unsafe.Sizeof(multiLeaf{})) + len(long),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.tree.Insert(short, 1)
+			assert.Equal(t, tt.shortSize, tt.tree.Stats().Kinds[LeafKind].Bytes)
+
+			tt.tree.Insert(long, 2)
+			assert.Equal(t, tt.shortSize+tt.longSize, tt.tree.Stats().Kinds[LeafKind].Bytes)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package art
+
+// The leaves of a tree created with WithLeafSuffixes store the key suffix below their depth.
+// The depth of a Leaf is the length of its path: the Node prefixes and the child key bytes leading to it.
+// A zero byte child ends at the path of its Node, so its suffix is empty.
+
+// suffixLeaf is a LeafKind Node reported by the traversals of a tree storing the key suffixes,
+// its key is restored from the traversal path.
+type suffixLeaf struct {
+	key   Key
+	value Value
+}
+
+// assert that suffixLeaf implements the NodeKV interface.
+var _ NodeKV = (*suffixLeaf)(nil)
+
+// Kind returns LeafKind.
+func (l *suffixLeaf) Kind() Kind { return LeafKind }
+
+// Key returns the full key.
+func (l *suffixLeaf) Key() Key { return l.key }
+
+// Value returns the value.
+func (l *suffixLeaf) Value() Value { return l.value }
+
+// leafPart returns the part of the key stored in a Leaf located depth bytes deep,
+// the whole key unless the tree stores the key suffixes.
+func (tr *tree) leafPart(key Key, depth int) Key {
+	if !tr.opts.leafSuffixes {
+		return key
+	}
+
+	return key[minInt(depth, len(key)):]
+}
+
+// leafSuffix returns the bytes of the key of the Leaf located depth bytes deep below its path.
+func (tr *tree) leafSuffix(leaf *Leaf, depth int) Key {
+	if tr.opts.leafSuffixes {
+		return leaf.key
+	}
+
+	return leaf.key[depth:]
+}
+
+// leafKey returns the full key of the Leaf located at the path.
+func (tr *tree) leafKey(leaf *Leaf, path Key) Key {
+	if !tr.opts.leafSuffixes {
+		return leaf.key
+	}
+
+	key := make(Key, 0, len(path)+len(leaf.key))
+
+	return append(append(key, path...), leaf.key...)
+}
+
+// nodeKV returns the Node located at the path as reported to the callbacks,
+// the LeafKind nodes of a tree storing the key suffixes are reported with their full key.
+func (tr *tree) nodeKV(nr NodeRef, path Key) NodeKV {
+	if !tr.opts.leafSuffixes || !nr.isLeaf() {
+		return nr
+	}
+
+	leaf := nr.Leaf()
+
+	return &suffixLeaf{key: tr.leafKey(leaf, path), value: leaf.value}
+}
+
+// boundaryKey returns the key of the minimum or the maximum Leaf of the subtree located at the path.
+func (tr *tree) boundaryKey(nr NodeRef, path Key, maximum bool) Key {
+	if !tr.opts.leafSuffixes {
+		return ternary(maximum, nr.maximum(), nr.minimum()).key
+	}
+
+	for !nr.isLeaf() {
+		path = append(path[:len(path):len(path)], nr.fullPrefix(len(path))...)
+
+		refs := nr.childRefs()
+
+		ref := refs[ternary(maximum, len(refs)-1, 0)]
+		if !ref.kc.invalid {
+			path = append(path, ref.kc.ch)
+		}
+
+		nr = ref.ref
+	}
+
+	return tr.leafKey(nr.Leaf(), path)
+}
+
+// compressedPath returns the bytes of the path compressed into the Node located at the given depth,
+// the Leaf of a tree storing the key suffixes holds all of them.
+func compressedPath(nr NodeRef, depth int, opts *treeOptions) []byte {
+	if nr.isLeaf() && opts.leafSuffixes {
+		return nr.Leaf().key
+	}
+
+	return nr.fullPrefix(depth)
+}
+
+// trimLeaf returns the Leaf moved n bytes deeper, the first n bytes of its suffix are dropped.
+// The Leaf is returned as is unless the tree stores the key suffixes.
+func trimLeaf(nr NodeRef, n int, opts *treeOptions) NodeRef {
+	if !opts.leafSuffixes || n == 0 {
+		return nr
+	}
+
+	leaf := nr.Leaf()
+
+	return relocateLeaf(nr, leaf.key[minInt(n, len(leaf.key)):], opts)
+}
+
+// extendLeaf returns the Leaf moved up to the Node with the given prefix,
+// the prefix and the key byte the Leaf was stored under are prepended to its suffix.
+// The Leaf is returned as is unless the tree stores the key suffixes.
+func extendLeaf(nr NodeRef, prefix []byte, kc keyChar, opts *treeOptions) NodeRef {
+	if !opts.leafSuffixes {
+		return nr
+	}
+
+	leaf := nr.Leaf()
+
+	suffix := make(Key, 0, len(prefix)+1+len(leaf.key))
+	suffix = append(suffix, prefix...)
+
+	if !kc.invalid {
+		suffix = append(suffix, kc.ch)
+	}
+
+	return relocateLeaf(nr, append(suffix, leaf.key...), opts)
+}
+
+// relocateLeaf replaces the Leaf with a new one storing the suffix,
+// so that the short suffixes are stored in the same allocation as their Leaf.
+func relocateLeaf(nr NodeRef, suffix Key, opts *treeOptions) NodeRef {
+	moved := opts.factory.NewLeaf(suffix, nr.Leaf().value)
+	opts.factory.Release(nr)
+
+	return moved
+}
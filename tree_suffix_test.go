@@ -0,0 +1,311 @@
+package art
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// urlKeys returns long URL keys sharing long prefixes.
+func urlKeys(ids [][]byte) [][]byte {
+	keys := make([][]byte, 0, 2*len(ids))
+	for i, id := range ids {
+		base := fmt.Sprintf("https://api.example.com/v1/tenants/%d/users/%s", i%7, id)
+		keys = append(keys, []byte(base), []byte(base+"/posts/"+fmt.Sprint(i)))
+	}
+
+	return keys
+}
+
+// suffixDatasets returns the datasets of the suffix tests, the keys sharing prefixes with each other included.
+func suffixDatasets() map[string][][]byte {
+	return map[string][][]byte{
+		"Words":    loadTestFile("test/assets/words.txt"),
+		"HSKWords": loadTestFile("test/assets/hsk_words.txt"),
+		"URLs":     urlKeys(loadTestFile("test/assets/uuid.txt")),
+		"Nested": {
+			Key("a"), Key("ab"), Key("abc"), Key("abcd"), Key("abd"), Key("b"),
+			Key("a\x00"), Key("a\x00b"), Key("abcdefghijklmnopqrstuvwxyz"), Key("abcdefghijklmnopqrstuvwxzz"),
+		},
+	}
+}
+
+// leafKeys returns the keys of the leaves visited by ForEach.
+func leafKeys(tree Tree, opts ...int) []string {
+	var keys []string
+
+	tree.ForEach(func(node NodeKV) bool {
+		keys = append(keys, string(node.Key()))
+
+		return true
+	}, opts...)
+
+	return keys
+}
+
+// iteratorKeys returns the keys of the leaves returned by the iterator.
+func iteratorKeys(t *testing.T, it Iterator) []string {
+	t.Helper()
+
+	var keys []string
+
+	for it.HasNext() {
+		node, err := it.Next()
+		require.NoError(t, err)
+
+		keys = append(keys, string(node.Key()))
+	}
+
+	return keys
+}
+
+func newSuffixTrees(data [][]byte) (Tree, Tree) {
+	reference, tree := New(), New(WithLeafSuffixes())
+	for _, d := range data {
+		reference.Insert(d, d)
+		tree.Insert(d, d)
+	}
+
+	return reference, tree
+}
+
+func TestLeafSuffixes(t *testing.T) {
+	t.Parallel()
+
+	for name, data := range suffixDatasets() {
+		name, data := name, data
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			reference, tree := newSuffixTrees(data)
+			require.NoError(t, tree.Validate())
+			assert.Equal(t, reference.Size(), tree.Size())
+			assert.True(t, Equal(reference, tree, nil))
+
+			for _, d := range data {
+				v, found := tree.Search(d)
+				assert.True(t, found)
+				assert.Equal(t, d, v)
+
+				_, found = tree.Search(append(d[:len(d):len(d)], 0xff))
+				assert.False(t, found)
+			}
+
+			assert.Equal(t, leafKeys(reference), leafKeys(tree))
+			assert.Equal(t, leafKeys(reference, TraverseReverse), leafKeys(tree, TraverseReverse))
+			assert.Equal(t, iteratorKeys(t, reference.Iterator()), iteratorKeys(t, tree.Iterator()))
+			assert.Equal(t,
+				iteratorKeys(t, reference.Iterator(TraverseReverse)),
+				iteratorKeys(t, tree.Iterator(TraverseReverse)))
+
+			minValue, _ := tree.Minimum()
+			maxValue, _ := tree.Maximum()
+			refMin, _ := reference.Minimum()
+			refMax, _ := reference.Maximum()
+			assert.Equal(t, refMin, minValue)
+			assert.Equal(t, refMax, maxValue)
+
+			for i, d := range data {
+				if i%2 == 0 {
+					v, deleted := tree.Delete(d)
+					assert.Equal(t, d, v)
+					assert.True(t, deleted)
+					reference.Delete(d)
+				}
+			}
+
+			require.NoError(t, tree.Validate())
+			assert.Equal(t, leafKeys(reference), leafKeys(tree))
+
+			for _, d := range data {
+				_, refFound := reference.Search(d)
+				_, found := tree.Search(d)
+				assert.Equal(t, refFound, found)
+			}
+		})
+	}
+}
+
+func TestLeafSuffixesStoreSuffixes(t *testing.T) {
+	t.Parallel()
+
+	data := urlKeys(loadTestFile("test/assets/uuid.txt"))
+	reference, tree := newSuffixTrees(data)
+
+	refStats, stats := reference.Stats(), tree.Stats()
+	assert.Equal(t, refStats.Leaves, stats.Leaves)
+	assert.Less(t, stats.LeafKeyBytes, refStats.LeafKeyBytes/4)
+	assert.Less(t, stats.Bytes, refStats.Bytes)
+
+	tree.ForEach(func(node NodeKV) bool {
+		n := node.(NodeRef).node() //nolint:forcetypeassert
+		assert.True(t, n.hasFullPrefix())
+
+		return true
+	}, TraverseNode)
+}
+
+func TestLeafSuffixesOverrideMaxPrefixLen(t *testing.T) {
+	t.Parallel()
+
+	data := suffixDatasets()["URLs"]
+
+	tree := newTree(WithMaxPrefixLen(1), WithLeafSuffixes(), WithAllocator(NewArenaAllocator(0)))
+	assert.Equal(t, math.MaxUint16, tree.opts.maxPrefixLen)
+
+	for round := 0; round < 2; round++ {
+		for _, d := range data {
+			tree.Insert(d, d)
+		}
+
+		require.NoError(t, tree.Validate())
+
+		for _, d := range data {
+			v, found := tree.Search(d)
+			assert.True(t, found)
+			assert.Equal(t, d, v)
+		}
+
+		for _, d := range data {
+			_, deleted := tree.Delete(d)
+			assert.True(t, deleted)
+		}
+
+		assert.True(t, tree.root.isNil())
+	}
+}
+
+func TestLeafSuffixesQueries(t *testing.T) {
+	t.Parallel()
+
+	reference, tree := newSuffixTrees(loadTestFile("test/assets/words.txt"))
+
+	prefixKeys := func(tr Tree, prefix string) []string {
+		var keys []string
+
+		tr.ForEachPrefix(Key(prefix), func(node NodeKV) bool {
+			keys = append(keys, string(node.Key()))
+
+			return true
+		})
+
+		return keys
+	}
+
+	for _, prefix := range []string{"", "a", "inter", "zy", "nonexistent"} {
+		assert.Equal(t, prefixKeys(reference, prefix), prefixKeys(tree, prefix), prefix)
+
+		refList, list := reference.List(Key(prefix), 'e'), tree.List(Key(prefix), 'e')
+		assert.Equal(t, refList.CommonPrefixes, list.CommonPrefixes, prefix)
+		assert.Equal(t, len(refList.Leaves), len(list.Leaves), prefix)
+
+		for i := range list.Leaves {
+			assert.Equal(t, refList.Leaves[i].Key(), list.Leaves[i].Key())
+		}
+
+		var refChildren, children []string
+
+		reference.Children(Key(prefix), 'a', func(segment Key, isLeaf bool, count int) bool {
+			refChildren = append(refChildren, fmt.Sprint(string(segment), isLeaf, count))
+
+			return true
+		}, WithChildrenCount())
+		tree.Children(Key(prefix), 'a', func(segment Key, isLeaf bool, count int) bool {
+			children = append(children, fmt.Sprint(string(segment), isLeaf, count))
+
+			return true
+		}, WithChildrenCount())
+		assert.Equal(t, refChildren, children, prefix)
+	}
+
+	for _, query := range []string{"interesting", "apple", "zebra"} {
+		collect := func(tr Tree) []string {
+			var keys []string
+
+			tr.FuzzySearch(Key(query), 2, func(node NodeKV) bool {
+				keys = append(keys, string(node.Key()))
+
+				return true
+			})
+
+			return keys
+		}
+		assert.Equal(t, collect(reference), collect(tree), query)
+	}
+
+	for _, pattern := range []string{"inter*ing", "*zz*", "a?e"} {
+		collect := func(tr Tree) []string {
+			var keys []string
+
+			require.NoError(t, tr.Glob(pattern, func(node NodeKV) bool {
+				keys = append(keys, string(node.Key()))
+
+				return true
+			}))
+
+			return keys
+		}
+		assert.Equal(t, collect(reference), collect(tree), pattern)
+	}
+
+	for _, key := range []string{"interestingly", "abandonments", "zzz", "a"} {
+		refKey, refValue, refFound := reference.LongestPrefix(Key(key))
+		prefix, value, found := tree.LongestPrefix(Key(key))
+		assert.Equal(t, refFound, found, key)
+		assert.Equal(t, refKey, prefix, key)
+		assert.Equal(t, refValue, value, key)
+	}
+}
+
+func TestLeafSuffixesSplitJoin(t *testing.T) {
+	t.Parallel()
+
+	data := suffixDatasets()["URLs"]
+
+	_, tree := newSuffixTrees(data)
+	keys := leafKeys(tree)
+
+	for _, at := range []string{keys[0], keys[len(keys)/3], keys[len(keys)/2] + "\x00", "zzz"} {
+		_, tree := newSuffixTrees(data)
+
+		left, right := tree.SplitAt(Key(at))
+		require.NoError(t, left.Validate())
+		require.NoError(t, right.Validate())
+
+		for _, k := range leafKeys(left) {
+			assert.Less(t, k, at)
+		}
+
+		for _, k := range leafKeys(right) {
+			assert.GreaterOrEqual(t, k, at)
+		}
+
+		joined, err := Join(left, right)
+		require.NoError(t, err)
+		require.NoError(t, joined.Validate())
+		assert.Equal(t, keys, leafKeys(joined))
+	}
+
+	_, err := Join(New(WithLeafSuffixes()), New())
+	assert.ErrorIs(t, err, ErrUnsupportedTree)
+}
+
+func TestLeafSuffixesClone(t *testing.T) {
+	t.Parallel()
+
+	reference, tree := newSuffixTrees(suffixDatasets()["Nested"])
+
+	clone := tree.Clone()
+	require.NoError(t, clone.Validate())
+	assert.True(t, Equal(tree, clone, nil))
+	assert.True(t, Equal(reference, clone, nil))
+
+	clone.Delete(Key("abc"))
+	assert.False(t, Equal(tree, clone, nil))
+
+	_, found := tree.Search(Key("abc"))
+	assert.True(t, found)
+}
@@ -48,8 +48,8 @@ func TestLongestCommonPrefix(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			l1 := factory.newLeaf(tt.l1, string(tt.l1)).Leaf()
-			l2 := factory.newLeaf(tt.l2, string(tt.l2)).Leaf()
+			l1 := factory.NewLeaf(tt.l1, string(tt.l1)).Leaf()
+			l2 := factory.NewLeaf(tt.l2, string(tt.l2)).Leaf()
 			actual := findLongestCommonPrefix(l1.key, l2.key, tt.offset)
 			assert.Equal(t, tt.expected, actual)
 		})
@@ -67,8 +67,8 @@ func TestObjFactory(t *testing.T) {
 	t.Parallel()
 
 	factory := newObjFactory()
-	node48A := factory.newNode48()
-	node48B := factory.newNode48()
+	node48A := factory.NewNode48()
+	node48B := factory.NewNode48()
 
 	assert.NotNil(t, node48A)
 	assert.NotNil(t, node48B)
@@ -171,7 +171,7 @@ func TestTreeInsertAndNodeGrowth(t *testing.T) {
 			}
 
 			assert.Equal(t, int(tc.totalNodes), tree.Size())
-			assert.Equal(t, tc.expected, tree.root.kind)
+			assert.Equal(t, tc.expected, tree.root.kind())
 		})
 	}
 }
@@ -400,7 +400,7 @@ func TestInsertAndDeleteOne(t *testing.T) {
 	assert.True(t, deleted)
 	assert.Equal(t, "data", v)
 	assert.Equal(t, 0, tree.size)
-	assert.Nil(t, tree.root)
+	assert.True(t, tree.root.isNil())
 }
 
 func TestInsertTwoAndDeleteOne(t *testing.T) {
@@ -423,7 +423,7 @@ func TestInsertTwoAndDeleteOne(t *testing.T) {
 	assert.False(t, found)
 
 	assert.Equal(t, 1, tree.size)
-	assert.Equal(t, LeafKind, tree.root.kind)
+	assert.Equal(t, LeafKind, tree.root.kind())
 }
 
 func TestInsertTwoAndDeleteTwo(t *testing.T) {
@@ -453,7 +453,7 @@ func TestInsertTwoAndDeleteTwo(t *testing.T) {
 	assert.False(t, found)
 
 	assert.Equal(t, 0, tree.size)
-	assert.Nil(t, tree.root)
+	assert.True(t, tree.root.isNil())
 }
 
 func TestTreeInsertSearchDeleteWords(t *testing.T) {
@@ -473,7 +473,7 @@ func TestTreeInsertSearchDeleteWords(t *testing.T) {
 	}
 
 	assert.Equal(t, 0, tree.size)
-	assert.Nil(t, tree.root)
+	assert.True(t, tree.root.isNil())
 }
 
 func TestTreeInsertSearchDeleteHSKWords(t *testing.T) {
@@ -493,7 +493,7 @@ func TestTreeInsertSearchDeleteHSKWords(t *testing.T) {
 	}
 
 	assert.Equal(t, 0, tree.size)
-	assert.Nil(t, tree.root)
+	assert.True(t, tree.root.isNil())
 }
 
 func TestTreeInsertSearchDeleteUUIDs(t *testing.T) {
@@ -520,7 +520,7 @@ func TestTreeInsertSearchDeleteUUIDs(t *testing.T) {
 	}
 
 	assert.Equal(t, 0, tree.size)
-	assert.Nil(t, tree.root)
+	assert.True(t, tree.root.isNil())
 }
 
 func TestInsertDeleteWithZeroChild(t *testing.T) {
@@ -540,9 +540,9 @@ func TestInsertDeleteWithZeroChild(t *testing.T) {
 	}
 
 	all := toNode(tree.root).allChildren()
-	childZero := *all[len(all)-1]
-	assert.NotNil(t, childZero)
-	assert.Equal(t, LeafKind, childZero.kind)
+	childZero := all[len(all)-1]
+	assert.False(t, childZero.isNil())
+	assert.Equal(t, LeafKind, childZero.kind())
 	assert.Equal(t, Key("test/a"), childZero.Key())
 
 	for _, w := range keys {
@@ -552,7 +552,7 @@ func TestInsertDeleteWithZeroChild(t *testing.T) {
 	}
 
 	assert.Equal(t, 0, tree.size)
-	assert.Nil(t, tree.root)
+	assert.True(t, tree.root.isNil())
 }
 
 func TestTreeAPI(t *testing.T) { //nolint:funlen
@@ -845,7 +845,7 @@ func TestNodesWithNullKeys48(t *testing.T) { //nolint:funlen
 	}
 
 	assert.Equal(t, 0, tree.size)
-	assert.Nil(t, tree.root)
+	assert.True(t, tree.root.isNil())
 }
 
 func TestNodesWithNullKeys256(t *testing.T) { //nolint:funlen
@@ -914,7 +914,37 @@ func TestNodesWithNullKeys256(t *testing.T) { //nolint:funlen
 	}
 
 	assert.Equal(t, 0, tree.size)
-	assert.Nil(t, tree.root)
+	assert.True(t, tree.root.isNil())
+}
+
+func TestNode256ShrinkWithZeroChild(t *testing.T) {
+	t.Parallel()
+
+	tree := newTree()
+	tree.Insert(Key("k"), "zero")
+
+	for i := 0; i < node256Max; i++ {
+		tree.Insert(Key{'k', byte(i)}, i)
+	}
+
+	// shrink the Node256 to a Node48, the zero byte child must survive
+	for i := 0; i < node256Max-node48Max; i++ {
+		tree.Delete(Key{'k', byte(i)})
+	}
+
+	assert.Equal(t, Node48Kind, tree.root.kind())
+
+	v, found := tree.Search(Key("k"))
+	assert.True(t, found)
+	assert.Equal(t, "zero", v)
+
+	for i := node256Max - node48Max; i < node256Max; i++ {
+		v, found := tree.Search(Key{'k', byte(i)})
+		assert.True(t, found)
+		assert.Equal(t, i, v)
+	}
+
+	assert.Equal(t, node48Max+1, tree.Size())
 }
 
 func TestTreeInsertAndSearchKeyWithUnicodeAccentChar(t *testing.T) {
@@ -969,3 +999,146 @@ func TestTreeInsertNilKeyTwice(t *testing.T) {
 	assert.Equal(t, knilv1, v)
 	assert.True(t, found)
 }
+
+// namespacedKeys returns the keys prefixed with a long shared namespace.
+func namespacedKeys(keys [][]byte) [][]byte {
+	namespaced := make([][]byte, 0, len(keys))
+	for i, k := range keys {
+		ns := fmt.Sprintf("tenant/%d/collection/documents/", i%3)
+		namespaced = append(namespaced, append([]byte(ns), k...))
+	}
+
+	return namespaced
+}
+
+func TestTreeMaxPrefixLen(t *testing.T) {
+	t.Parallel()
+
+	modes := []int{0, 1, 4, OptimisticPrefixLen, 16, PessimisticPrefixLen}
+
+	datasets := map[string][][]byte{
+		"Words":      loadTestFile("test/assets/words.txt"),
+		"HSKWords":   loadTestFile("test/assets/hsk_words.txt"),
+		"Namespaced": namespacedKeys(loadTestFile("test/assets/uuid.txt")),
+	}
+
+	for name, data := range datasets {
+		for _, mode := range modes {
+			name, data, mode := name, data, mode
+			t.Run(fmt.Sprintf("%s/%d", name, mode), func(t *testing.T) {
+				t.Parallel()
+
+				tree := newTree(WithMaxPrefixLen(mode))
+				for _, d := range data {
+					tree.Insert(d, d)
+				}
+
+				assert.Equal(t, len(data), tree.Size())
+
+				var prev Key
+
+				unordered := 0
+				tree.ForEach(func(node NodeKV) bool {
+					if bytes.Compare(prev, node.Key()) >= 0 {
+						unordered++
+					}
+					prev = node.Key()
+
+					return true
+				})
+				assert.Zero(t, unordered)
+
+				badPrefixes := 0
+				tree.ForEach(func(node NodeKV) bool {
+					n := node.(NodeRef).node()
+					if int(n.storedLen) != minInt(int(n.prefixLen), tree.opts.maxPrefixLen) ||
+						(mode == PessimisticPrefixLen && !n.hasFullPrefix()) {
+						badPrefixes++
+					}
+
+					return true
+				}, TraverseNode)
+				assert.Zero(t, badPrefixes)
+
+				for _, d := range data {
+					v, found := tree.Search(d)
+					assert.True(t, found)
+					assert.Equal(t, d, v)
+				}
+
+				for i, d := range data {
+					if i%2 == 0 {
+						v, deleted := tree.Delete(d)
+						assert.True(t, deleted)
+						assert.Equal(t, d, v)
+					}
+				}
+
+				for i, d := range data {
+					_, found := tree.Search(d)
+					assert.Equal(t, i%2 != 0, found)
+				}
+
+				for i, d := range data {
+					if i%2 != 0 {
+						_, deleted := tree.Delete(d)
+						assert.True(t, deleted)
+					}
+				}
+
+				assert.Equal(t, 0, tree.Size())
+				assert.True(t, tree.root.isNil())
+			})
+		}
+	}
+}
+
+func TestTreeLongestPrefix(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{"", "a", "ab", "abcd", "abcdefghijklmnopqrstuvwxyz/1", "abcdefghijklmnopqrstuvwxyz/2", "b"}
+
+	tests := []struct {
+		key      string
+		expected string
+		found    bool
+	}{
+		{"", "", true},
+		{"a", "a", true},
+		{"abc", "ab", true},
+		{"abcd", "abcd", true},
+		{"abcde", "abcd", true},
+		{"abcdefghijklmnopqrstuvwxyz/1/x", "abcdefghijklmnopqrstuvwxyz/1", true},
+		{"abcdefghijklmnopqrstuvwxyz/3", "abcd", true},
+		{"abcdefghijklmnopqrstuvwxy", "abcd", true},
+		{"ba", "b", true},
+		{"c", "", true},
+	}
+
+	for _, e := range engines {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := e.newTree()
+			_, _, found := tree.LongestPrefix(Key("a"))
+			assert.False(t, found)
+
+			for _, k := range keys[1:] {
+				tree.Insert(Key(k), k)
+			}
+
+			_, _, found = tree.LongestPrefix(Key("c"))
+			assert.False(t, found)
+
+			tree.Insert(Key(keys[0]), keys[0])
+
+			for _, tt := range tests {
+				prefix, value, found := tree.LongestPrefix(Key(tt.key))
+				assert.Equal(t, tt.found, found, tt.key)
+				assert.Equal(t, tt.expected, string(prefix), tt.key)
+				assert.Equal(t, tt.expected, value, tt.key)
+			}
+		})
+	}
+}
@@ -0,0 +1,360 @@
+package art
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FoldCase is a KeyTransformer making the keys case-insensitive.
+// The keys equal under the Unicode simple case folding, as reported by bytes.EqualFold,
+// have the same sort key, made of the lowercase form of their runes.
+// The invalid UTF-8 bytes are kept as is.
+func FoldCase(key []byte) []byte {
+	folded := make([]byte, 0, len(key))
+
+	for i := 0; i < len(key); {
+		c := key[i]
+		if c < utf8.RuneSelf {
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+
+			folded = append(folded, c)
+			i++
+
+			continue
+		}
+
+		r, size := utf8.DecodeRune(key[i:])
+		if r == utf8.RuneError && size == 1 {
+			folded = append(folded, c)
+		} else {
+			folded = appendRune(folded, foldRune(r))
+		}
+
+		i += size
+	}
+
+	return folded
+}
+
+// foldRune returns the lowercase form of the smallest rune of the case folding orbit of the rune,
+// so that all the runes of an orbit fold to the same rune.
+func foldRune(r rune) rune {
+	smallest := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < smallest {
+			smallest = f
+		}
+	}
+
+	return unicode.ToLower(smallest)
+}
+
+// appendRune appends the UTF-8 encoding of the rune.
+func appendRune(b []byte, r rune) []byte {
+	var buf [utf8.UTFMax]byte
+
+	n := utf8.EncodeRune(buf[:], r)
+
+	return append(b, buf[:n]...)
+}
+
+// ChainKeyTransformers returns a KeyTransformer applying the transformers in order,
+// for example normalizing the keys before folding their case.
+func ChainKeyTransformers(transformers ...KeyTransformer) KeyTransformer {
+	return func(key []byte) []byte {
+		for _, transform := range transformers {
+			key = transform(key)
+		}
+
+		return key
+	}
+}
+
+// transformedLeaf is the value stored in the LeafKind nodes of a transformed tree,
+// it holds the original key along with the value.
+type transformedLeaf struct {
+	key   Key
+	value Value
+}
+
+// assert that transformedLeaf implements the NodeKV interface.
+var _ NodeKV = (*transformedLeaf)(nil)
+
+// Kind returns LeafKind.
+func (l *transformedLeaf) Kind() Kind { return LeafKind }
+
+// Key returns the original key.
+func (l *transformedLeaf) Key() Key { return l.key }
+
+// Value returns the value.
+func (l *transformedLeaf) Value() Value { return l.value }
+
+// transformedTree stores the keys by their sort key in the underlying tree, see WithKeyTransformer.
+type transformedTree struct {
+	tree      Tree           // tree maps the sort keys to the transformed leaves
+	transform KeyTransformer // transform maps the keys to their sort keys
+}
+
+// make sure that transformedTree implements all methods from the Tree interface.
+var _ Tree = (*transformedTree)(nil)
+
+// newTransformedTree wraps the tree whose options hold the KeyTransformer.
+func newTransformedTree(tr *tree) *transformedTree {
+	return &transformedTree{tree: tr, transform: tr.opts.transform}
+}
+
+// sortKey returns the sort key of the key, an empty key stays non-nil.
+func (t *transformedTree) sortKey(key Key) Key {
+	return sortKey(t.transform, key)
+}
+
+// sortKey returns the sort key of the key, an empty key stays non-nil.
+func sortKey(transform KeyTransformer, key Key) Key {
+	sk := transform(key)
+	if sk == nil && key != nil {
+		return Key{}
+	}
+
+	return sk
+}
+
+// wrap returns the transformed tree using the underlying tree.
+func (t *transformedTree) wrap(tr Tree) Tree {
+	return &transformedTree{tree: tr, transform: t.transform}
+}
+
+// unwrapNode returns the transformed Leaf of a LeafKind Node, the inner nodes are returned as is.
+func unwrapNode(node NodeKV) NodeKV {
+	if leaf, ok := node.Value().(*transformedLeaf); ok && node.Kind() == LeafKind {
+		return leaf
+	}
+
+	return node
+}
+
+// unwrapValue returns the value held by the transformed Leaf.
+func unwrapValue(v Value, found bool) (Value, bool) {
+	if !found {
+		return nil, false
+	}
+
+	return v.(*transformedLeaf).value, true //nolint:forcetypeassert
+}
+
+// unwrapCallback returns a callback passing the transformed leaves to the callback.
+func unwrapCallback(callback Callback) Callback {
+	return func(node NodeKV) bool {
+		return callback(unwrapNode(node))
+	}
+}
+
+// Insert inserts the key by its sort key, an existing Leaf keeps its original key.
+func (t *transformedTree) Insert(key Key, value Value) (Value, bool) {
+	leaf := &transformedLeaf{key: append(make(Key, 0, len(key)), key...), value: value}
+
+	old, updated := t.tree.Insert(t.sortKey(key), leaf)
+	if !updated {
+		return nil, false
+	}
+
+	oldLeaf := old.(*transformedLeaf) //nolint:forcetypeassert
+	leaf.key = oldLeaf.key
+
+	return oldLeaf.value, true
+}
+
+// Delete deletes the key with the same sort key as the given key.
+func (t *transformedTree) Delete(key Key) (Value, bool) {
+	return unwrapValue(t.tree.Delete(t.sortKey(key)))
+}
+
+// Search searches for the key with the same sort key as the given key.
+func (t *transformedTree) Search(key Key) (Value, bool) {
+	return unwrapValue(t.tree.Search(t.sortKey(key)))
+}
+
+// LongestPrefix returns the original key whose sort key is the longest prefix of the sort key of the given key.
+func (t *transformedTree) LongestPrefix(key Key) (Key, Value, bool) {
+	_, v, found := t.tree.LongestPrefix(t.sortKey(key))
+	if !found {
+		return nil, nil, false
+	}
+
+	leaf := v.(*transformedLeaf) //nolint:forcetypeassert
+
+	return leaf.key, leaf.value, true
+}
+
+// ForEach iterates over all keys in the order of their sort keys.
+func (t *transformedTree) ForEach(callback Callback, opts ...int) {
+	t.tree.ForEach(unwrapCallback(callback), opts...)
+}
+
+// ForEachPrefix iterates over all keys whose sort key starts with the sort key of the prefix.
+func (t *transformedTree) ForEachPrefix(key Key, callback Callback, opts ...int) {
+	t.tree.ForEachPrefix(t.sortKey(key), unwrapCallback(callback), opts...)
+}
+
+// transformedIterator returns the transformed leaves of the underlying iterator.
+type transformedIterator struct {
+	Iterator
+}
+
+// Next returns the next Node, a LeafKind Node holds the original key.
+func (it *transformedIterator) Next() (NodeKV, error) {
+	node, err := it.Iterator.Next()
+	if err != nil {
+		return node, err
+	}
+
+	return unwrapNode(node), nil
+}
+
+// transformedResilientIterator is the transformedIterator of a resilient iteration,
+// it deletes the keys through the underlying resilient iterator.
+type transformedResilientIterator struct {
+	transformedIterator
+	resilient ResilientIterator
+}
+
+// Delete removes the key of the last returned LeafKind Node and returns its original value.
+func (it *transformedResilientIterator) Delete() (Value, bool) {
+	return unwrapValue(it.resilient.Delete())
+}
+
+// Iterator returns a new iterator over the keys in the order of their sort keys.
+func (t *transformedTree) Iterator(opts ...int) Iterator {
+	it := t.tree.Iterator(opts...)
+	if resilient, ok := it.(ResilientIterator); ok {
+		return &transformedResilientIterator{transformedIterator: transformedIterator{Iterator: it}, resilient: resilient}
+	}
+
+	return &transformedIterator{Iterator: it}
+}
+
+// Minimum returns the value of the key with the smallest sort key.
+func (t *transformedTree) Minimum() (Value, bool) {
+	return unwrapValue(t.tree.Minimum())
+}
+
+// Maximum returns the value of the key with the largest sort key.
+func (t *transformedTree) Maximum() (Value, bool) {
+	return unwrapValue(t.tree.Maximum())
+}
+
+// Size returns the number of elements in the tree.
+func (t *transformedTree) Size() int {
+	return t.tree.Size()
+}
+
+// SplitAt moves the keys whose sort key is greater than or equal to the sort key of the given key into a new tree.
+func (t *transformedTree) SplitAt(key Key) (Tree, Tree) {
+	_, right := t.tree.SplitAt(t.sortKey(key))
+
+	return t, t.wrap(right)
+}
+
+// Clone returns a deep copy of the tree, the values are shared.
+func (t *transformedTree) Clone() Tree {
+	return t.CloneWith(nil)
+}
+
+// CloneWith returns a deep copy of the tree, each value is copied with the cloneValue function.
+// The transformed leaves are copied, so that updating a key of the copy doesn't affect the tree.
+func (t *transformedTree) CloneWith(cloneValue func(Value) Value) Tree {
+	if cloneValue == nil {
+		cloneValue = func(v Value) Value { return v }
+	}
+
+	return t.wrap(t.tree.CloneWith(func(v Value) Value {
+		leaf := v.(*transformedLeaf) //nolint:forcetypeassert
+
+		return &transformedLeaf{key: leaf.key, value: cloneValue(leaf.value)}
+	}))
+}
+
+// Stats returns the statistics of the underlying tree, the original keys are not counted.
+func (t *transformedTree) Stats() TreeStats {
+	return t.tree.Stats()
+}
+
+// Validate verifies the underlying tree and that the sort key of every Leaf is the sort key of its original key.
+func (t *transformedTree) Validate() error {
+	if err := t.tree.Validate(); err != nil {
+		return err
+	}
+
+	var err error
+
+	t.tree.ForEach(func(node NodeKV) bool {
+		leaf, ok := node.Value().(*transformedLeaf)
+		if !ok {
+			err = invalid(node.Key(), LeafKind, "value %T is not a transformed Leaf", node.Value())
+
+			return false
+		}
+
+		if !bytes.Equal(node.Key(), t.sortKey(leaf.key)) {
+			err = invalid(node.Key(), LeafKind, "key %q doesn't transform to its sort key", []byte(leaf.key))
+
+			return false
+		}
+
+		return true
+	})
+
+	return err
+}
+
+// ForEachPrefixWithSeparator iterates over the keys whose sort key starts with the sort key of the prefix,
+// the separators are counted in the sort keys.
+func (t *transformedTree) ForEachPrefixWithSeparator(
+	keyPrefix Key,
+	callback Callback,
+	countSeparator func(Key, Key) int,
+	maxDepth int,
+	reverse bool,
+) {
+	t.tree.ForEachPrefixWithSeparator(t.sortKey(keyPrefix), unwrapCallback(callback), countSeparator, maxDepth, reverse)
+}
+
+// List lists the keys by their sort key, the common prefixes and NextStartAfter are sort keys.
+func (t *transformedTree) List(prefix Key, delimiter byte, opts ...ListOption) ListResult {
+	options := createListOptions(opts...)
+	if options.startAfter != nil {
+		options.startAfter = t.sortKey(options.startAfter)
+	}
+
+	res := t.tree.List(t.sortKey(prefix), delimiter, func(opts *listOptions) {
+		*opts = options
+	})
+
+	for i, leaf := range res.Leaves {
+		res.Leaves[i] = unwrapNode(leaf)
+	}
+
+	return res
+}
+
+// Children enumerates the segments of the sort keys following the sort key of the prefix.
+func (t *transformedTree) Children(prefix Key, sep byte, cb ChildrenCallback, opts ...ChildrenOption) {
+	t.tree.Children(t.sortKey(prefix), sep, cb, opts...)
+}
+
+// FuzzySearch matches the sort keys against the sort key of the query.
+func (t *transformedTree) FuzzySearch(query Key, maxEdits int, cb Callback, opts ...FuzzyOption) {
+	t.tree.FuzzySearch(t.sortKey(query), maxEdits, unwrapCallback(cb), opts...)
+}
+
+// ForEachMatch runs the automaton on the sort keys.
+func (t *transformedTree) ForEachMatch(a Automaton, cb Callback) {
+	t.tree.ForEachMatch(a, unwrapCallback(cb))
+}
+
+// Glob matches the sort keys against the transformed pattern.
+func (t *transformedTree) Glob(pattern string, cb Callback, opts ...GlobOption) error {
+	return t.tree.Glob(string(t.transform([]byte(pattern))), unwrapCallback(cb), opts...)
+}
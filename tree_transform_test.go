@@ -0,0 +1,330 @@
+package art
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decomposeAccents is a KeyTransformer decomposing the e with an acute accent
+// into an e followed by a combining acute accent, like the NFD normalization of the key.
+func decomposeAccents(key []byte) []byte {
+	return bytes.ReplaceAll(key, []byte("\u00e9"), []byte("e\u0301"))
+}
+
+func newFoldedTree(keys ...string) Tree {
+	tree := New(WithKeyTransformer(FoldCase))
+	for _, k := range keys {
+		tree.Insert(Key(k), k)
+	}
+
+	return tree
+}
+
+// transformedKeys returns the original keys of the leaves visited by ForEach.
+func transformedKeys(tree Tree, opts ...int) []string {
+	var keys []string
+
+	tree.ForEach(func(node NodeKV) bool {
+		keys = append(keys, string(node.Key()))
+
+		return true
+	}, opts...)
+
+	return keys
+}
+
+func TestFoldCase(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"", ""},
+		{"Hello, World 42", "hello, world 42"},
+		{"ÉCOLE", "école"},
+		{"\u212a", "k"},                      // Kelvin sign
+		{"\u017f", "s"},                      // long s
+		{"ΣΊΣΥΦΟΣ", "σίσυφοσ"},               // final sigma
+		{"STRASSE", "strasse"},               // no full case folding
+		{"a\xffB\xe2\x84", "a\xffb\xe2\x84"}, // invalid UTF-8
+	}
+
+	for _, tt := range tests {
+		got := FoldCase([]byte(tt.key))
+		assert.Equal(t, tt.want, string(got), tt.key)
+		assert.Equal(t, got, FoldCase(got), "idempotent %q", tt.key)
+	}
+
+	assert.Equal(t, FoldCase([]byte("Sisyphus")), FoldCase([]byte("ſiſyphuS")))
+}
+
+func TestChainKeyTransformers(t *testing.T) {
+	t.Parallel()
+
+	transform := ChainKeyTransformers(decomposeAccents, FoldCase)
+	assert.Equal(t, "cafe\u0301", string(transform([]byte("CAF\u00e9"))))
+	assert.Equal(t, "key", string(ChainKeyTransformers()([]byte("key"))))
+}
+
+func TestTransformedTreeBasics(t *testing.T) {
+	t.Parallel()
+
+	tree := newFoldedTree("Alice", "bob", "CHARLIE")
+	require.Equal(t, 3, tree.Size())
+
+	v, found := tree.Search(Key("ALICE"))
+	assert.True(t, found)
+	assert.Equal(t, "Alice", v)
+
+	_, found = tree.Search(Key("alicia"))
+	assert.False(t, found)
+
+	// the update keeps the original key
+	old, updated := tree.Insert(Key("BOB"), "Bob")
+	assert.True(t, updated)
+	assert.Equal(t, "bob", old)
+	assert.Equal(t, 3, tree.Size())
+
+	var keys []string
+
+	tree.ForEach(func(node NodeKV) bool {
+		keys = append(keys, string(node.Key()))
+
+		return true
+	})
+	assert.Equal(t, []string{"Alice", "bob", "CHARLIE"}, keys)
+
+	v, deleted := tree.Delete(Key("charlie"))
+	assert.True(t, deleted)
+	assert.Equal(t, "CHARLIE", v)
+	assert.Equal(t, 2, tree.Size())
+
+	_, deleted = tree.Delete(Key("charlie"))
+	assert.False(t, deleted)
+
+	v, found = tree.Minimum()
+	assert.True(t, found)
+	assert.Equal(t, "Alice", v)
+
+	v, found = tree.Maximum()
+	assert.True(t, found)
+	assert.Equal(t, "Bob", v)
+
+	require.NoError(t, tree.Validate())
+}
+
+func TestTransformedTreeOrder(t *testing.T) {
+	t.Parallel()
+
+	tree := newFoldedTree("banana", "Apple", "cherry", "apricot", "Blueberry", "ÉCLAIR", "éclairs", "eclipse")
+
+	want := []string{"Apple", "apricot", "banana", "Blueberry", "cherry", "eclipse", "ÉCLAIR", "éclairs"}
+	assert.Equal(t, want, transformedKeys(tree))
+
+	reversed := transformedKeys(tree, TraverseReverse)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+
+	assert.Equal(t, want, reversed)
+
+	var got []string
+
+	for it := tree.Iterator(); it.HasNext(); {
+		node, err := it.Next()
+		require.NoError(t, err)
+
+		got = append(got, string(node.Key()))
+	}
+
+	assert.Equal(t, want, got)
+
+	it := tree.Iterator()
+	tree.Insert(Key("date"), "date")
+
+	_, err := it.Next()
+	assert.ErrorIs(t, err, ErrConcurrentModification)
+}
+
+func TestTransformedTreePrefixQueries(t *testing.T) {
+	t.Parallel()
+
+	tree := newFoldedTree("Users/Alice", "users/bob", "USERS/carol/notes", "groups/Admins", "users")
+
+	var keys []string
+
+	tree.ForEachPrefix(Key("USERS/"), func(node NodeKV) bool {
+		keys = append(keys, string(node.Key()))
+
+		return true
+	})
+	assert.Equal(t, []string{"Users/Alice", "users/bob", "USERS/carol/notes"}, keys)
+
+	keys = nil
+
+	tree.ForEachPrefixWithSeparator(Key("Users/"), func(node NodeKV) bool {
+		keys = append(keys, string(node.Key()))
+
+		return true
+	}, func(prefix, key Key) int {
+		return bytes.Count(key[len(prefix):], []byte("/"))
+	}, 0, false)
+	assert.Equal(t, []string{"Users/Alice", "users/bob"}, keys)
+
+	prefix, v, found := tree.LongestPrefix(Key("Users/Bob/Inbox"))
+	assert.True(t, found)
+	assert.Equal(t, "users/bob", string(prefix))
+	assert.Equal(t, "users/bob", v)
+
+	prefix, _, found = tree.LongestPrefix(Key("USERS/Dave"))
+	assert.True(t, found)
+	assert.Equal(t, "users", string(prefix))
+
+	res := tree.List(Key("USERS/"), '/', WithListMaxKeys(1))
+	leaves, prefixes := listKeys(res)
+	assert.Equal(t, []string{"Users/Alice"}, leaves)
+	assert.Empty(t, prefixes)
+	require.True(t, res.IsTruncated)
+
+	res = tree.List(Key("USERS/"), '/', WithListStartAfter(res.NextStartAfter))
+	leaves, prefixes = listKeys(res)
+	assert.Equal(t, []string{"users/bob"}, leaves)
+	assert.Equal(t, []string{"users/carol/"}, prefixes)
+
+	var segments []string
+
+	tree.Children(Key("Users/"), '/', func(segment Key, isLeaf bool, _ int) bool {
+		segments = append(segments, string(segment))
+
+		return true
+	})
+	assert.Equal(t, []string{"alice", "bob", "carol"}, segments)
+}
+
+func TestTransformedTreeMatching(t *testing.T) {
+	t.Parallel()
+
+	tree := newFoldedTree("README.md", "docs/Guide.MD", "docs/api.md", "main.go")
+
+	var keys []string
+
+	collect := func(node NodeKV) bool {
+		keys = append(keys, string(node.Key()))
+
+		return true
+	}
+
+	require.NoError(t, tree.Glob("docs/*.md", collect))
+	assert.Equal(t, []string{"docs/api.md", "docs/Guide.MD"}, keys)
+
+	keys = nil
+
+	tree.FuzzySearch(Key("MAIN.GO"), 0, collect)
+	assert.Equal(t, []string{"main.go"}, keys)
+
+	keys = nil
+
+	a, err := NewRegexpAutomaton(`readme\..*`)
+	require.NoError(t, err)
+	tree.ForEachMatch(a, collect)
+	assert.Equal(t, []string{"README.md"}, keys)
+}
+
+func TestTransformedTreeNormalization(t *testing.T) {
+	t.Parallel()
+
+	tree := New(WithKeyTransformer(ChainKeyTransformers(FoldCase, decomposeAccents)))
+	tree.Insert(Key("Café"), 1)
+
+	v, found := tree.Search(Key("CAFÉ"))
+	assert.True(t, found)
+	assert.Equal(t, 1, v)
+
+	_, updated := tree.Insert(Key("café"), 2)
+	assert.True(t, updated)
+	assert.Equal(t, 1, tree.Size())
+
+	tree.ForEach(func(node NodeKV) bool {
+		assert.Equal(t, "Café", string(node.Key()))
+
+		return true
+	})
+}
+
+func TestTransformedTreeCloneAndSplit(t *testing.T) {
+	t.Parallel()
+
+	tree := newFoldedTree("a", "B", "c", "D")
+
+	clone := tree.Clone()
+	clone.Insert(Key("b"), "b")
+	clone.Insert(Key("E"), "E")
+
+	v, _ := tree.Search(Key("b"))
+	assert.Equal(t, "B", v)
+	assert.Equal(t, 4, tree.Size())
+	assert.Equal(t, []string{"a", "B", "c", "D", "E"}, transformedKeys(clone))
+	assert.True(t, Equal(tree, newFoldedTree("a", "B", "c", "D"), nil))
+	assert.False(t, Equal(tree, newFoldedTree("A", "b", "C", "d"), func(Value, Value) bool { return true }))
+
+	left, right := tree.SplitAt(Key("C"))
+	assert.Equal(t, []string{"a", "B"}, transformedKeys(left))
+	assert.Equal(t, []string{"c", "D"}, transformedKeys(right))
+
+	right.Insert(Key("d"), "d")
+	v, _ = right.Search(Key("D"))
+	assert.Equal(t, "d", v)
+	require.NoError(t, right.Validate())
+}
+
+func TestTransformedTreeValidate(t *testing.T) {
+	t.Parallel()
+
+	tree := newFoldedTree("a", "b")
+	tr := tree.(*transformedTree) //nolint:forcetypeassert
+
+	// corrupt the original key of a Leaf
+	v, _ := tr.tree.Search(Key("b"))
+	v.(*transformedLeaf).key = Key("C") //nolint:forcetypeassert
+
+	err := tree.Validate()
+	require.ErrorIs(t, err, ErrInvalidTree)
+	assert.Contains(t, err.Error(), "doesn't transform to its sort key")
+}
+
+func TestTransformedTreeResilientIterator(t *testing.T) {
+	t.Parallel()
+
+	tree := newFoldedTree("Alice", "bob", "CHARLIE", "dave")
+
+	_, ok := tree.Iterator().(ResilientIterator)
+	assert.False(t, ok)
+
+	it, ok := tree.Iterator(TraverseResilient).(ResilientIterator)
+	require.True(t, ok)
+
+	var seen []string
+
+	for it.HasNext() {
+		node, err := it.Next()
+		require.NoError(t, err)
+
+		seen = append(seen, string(node.Key()))
+
+		if string(node.Key()) == "bob" {
+			v, deleted := it.Delete()
+			assert.True(t, deleted)
+			assert.Equal(t, "bob", v)
+
+			tree.Insert(Key("Eve"), "Eve")
+		}
+	}
+
+	assert.Equal(t, []string{"Alice", "bob", "CHARLIE", "dave", "Eve"}, seen)
+	assert.Equal(t, []string{"Alice", "CHARLIE", "dave", "Eve"}, transformedKeys(tree))
+	require.NoError(t, tree.Validate())
+}
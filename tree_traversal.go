@@ -1,11 +1,14 @@
 package art
 
+import "bytes"
+
 // traverseAction is an action to be taken during tree traversal.
 type traverseAction int
 
 const (
 	traverseStop     traverseAction = iota // traverseStop stops the tree traversal.
 	traverseContinue                       // traverseContinue continues the tree traversal.
+	traverseSkip                           // traverseSkip skips the children of the current Node.
 )
 
 // traverseFunc defines the function for tree traversal.
@@ -37,6 +40,10 @@ func (opts traverseOpts) hasReverse() bool {
 	return opts&TraverseReverse == TraverseReverse
 }
 
+func (opts traverseOpts) hasResilient() bool {
+	return opts&TraverseResilient == TraverseResilient
+}
+
 // traverseContext is a context for traversing nodes with 4, 16, or 256 children.
 type traverseContext struct {
 	numChildren   int
@@ -118,7 +125,7 @@ func (ctx *traverse48Context) ascTraversal() (int, bool) {
 
 // descTraversal traverses the children in descending order.
 func (ctx *traverse48Context) descTraversal() (int, bool) {
-	for ; ctx.curKeyIdx > 0; ctx.curKeyIdx-- {
+	for ; ctx.curKeyIdx >= 0; ctx.curKeyIdx-- {
 		if ctx.n48.hasChild(ctx.curKeyIdx) {
 			ctx.curKeyCh = ctx.n48.keys[ctx.curKeyIdx]
 			ctx.curKeyIdx--
@@ -147,12 +154,12 @@ func newTraverse48Func(n48 *Node48, reverse bool) traverseFunc {
 	return ternary(reverse, ctx.descTraversal, ctx.ascTraversal)
 }
 
-func newTraverseFunc(n *NodeRef, reverse bool) traverseFunc {
-	if n == nil {
+func newTraverseFunc(n NodeRef, reverse bool) traverseFunc {
+	if n.isNil() {
 		return noopTraverseFunc
 	}
 
-	switch n.kind { //nolint:exhaustive
+	switch n.kind() { //nolint:exhaustive
 	case Node4Kind:
 		return newTraverseGenericFunc(node4Max, reverse)
 	case Node16Kind:
@@ -183,7 +190,7 @@ func traverseOptions(options ...int) traverseOpts {
 		typeOpts = TraverseLeaf // By default filter only leafs
 	}
 
-	orderOpts := opts & TraverseReverse
+	orderOpts := opts & (TraverseReverse | TraverseResilient)
 
 	return traverseOpts(typeOpts | orderOpts)
 }
@@ -206,8 +213,8 @@ func traverseFilter(opts traverseOpts, callback Callback) Callback {
 	}
 }
 
-func (tr *tree) forEachRecursively(current *NodeRef, callback Callback, reverse bool) traverseAction {
-	if current == nil {
+func (tr *tree) forEachRecursively(current NodeRef, callback Callback, reverse bool) traverseAction {
+	if current.isNil() {
 		return traverseContinue
 	}
 
@@ -221,14 +228,14 @@ func (tr *tree) forEachRecursively(current *NodeRef, callback Callback, reverse
 	return tr.traverseChildren(nextFn, children, callback, reverse)
 }
 
-func (tr *tree) traverseChildren(nextFn traverseFunc, children []*NodeRef, cb Callback, reverse bool) traverseAction {
+func (tr *tree) traverseChildren(nextFn traverseFunc, children []NodeRef, cb Callback, reverse bool) traverseAction {
 	for {
 		idx, hasMore := nextFn()
 		if !hasMore {
 			break
 		}
 
-		if child := children[idx]; child != nil {
+		if child := children[idx]; !child.isNil() {
 			if tr.forEachRecursively(child, cb, reverse) == traverseStop {
 				return traverseStop
 			}
@@ -238,21 +245,97 @@ func (tr *tree) traverseChildren(nextFn traverseFunc, children []*NodeRef, cb Ca
 	return traverseContinue
 }
 
-func (tr *tree) forEachPrefix(key Key, callback Callback, opts int) traverseAction {
-	opts &= (TraverseLeaf | TraverseReverse) // keep only LeafKind and reverse options
+// walkPath walks the subtree in key order, calling fn with every Node and its path.
+// The path of an inner Node is the sequence of key bytes leading to it, its prefix included,
+// the path of a Leaf ends with the key byte it is stored under.
+func walkPath(nr NodeRef, path Key, reverse bool, fn func(nr NodeRef, path Key) traverseAction) traverseAction {
+	if nr.isNil() {
+		return traverseContinue
+	}
 
-	tr.ForEach(func(n NodeKV) bool {
-		current, ok := n.(*NodeRef)
-		if !ok {
-			return false
-		}
+	if !nr.isLeaf() {
+		path = append(path[:len(path):len(path)], nr.fullPrefix(len(path))...)
+	}
 
-		if leaf := current.Leaf(); leaf.PrefixMatch(key) {
-			return callback(current)
+	switch fn(nr, path) {
+	case traverseStop:
+		return traverseStop
+	case traverseSkip:
+		return traverseContinue
+	case traverseContinue:
+	}
+
+	refs := nr.childRefs()
+	for i := range refs {
+		ref := refs[ternary(reverse, len(refs)-1-i, i)]
+
+		childPath := path
+		if !ref.kc.invalid {
+			childPath = append(path[:len(path):len(path)], ref.kc.ch)
 		}
 
-		return true
-	}, opts)
+		if walkPath(ref.ref, childPath, reverse, fn) == traverseStop {
+			return traverseStop
+		}
+	}
 
 	return traverseContinue
 }
+
+// findPrefixRoot returns the smallest subtree holding all keys with the prefix
+// and the length of the path leading to it, the Node prefix excluded.
+// A nil NodeRef is returned if no key starts with the prefix.
+func (tr *tree) findPrefixRoot(prefix Key) (NodeRef, int) {
+	nr, depth := tr.root, 0
+
+	for !nr.isNil() && depth < len(prefix) {
+		if nr.isLeaf() {
+			if !nr.Leaf().PrefixMatch(tr.leafPart(prefix, depth)) {
+				return NodeRef{}, 0
+			}
+
+			return nr, depth
+		}
+
+		nodePrefix := nr.fullPrefix(depth)
+		rest := prefix[depth:]
+
+		n := minInt(len(nodePrefix), len(rest))
+		if !bytes.Equal(nodePrefix[:n], rest[:n]) {
+			return NodeRef{}, 0
+		}
+
+		if len(rest) <= len(nodePrefix) {
+			return nr, depth
+		}
+
+		depth += len(nodePrefix)
+		nr = *nr.findChildByKey(prefix, depth)
+		depth++
+	}
+
+	return nr, depth
+}
+
+// forEachPrefix invokes the callback for the leaves of the subtree holding the keys with the prefix,
+// the rest of the tree is not visited.
+func (tr *tree) forEachPrefix(key Key, callback Callback, opts int) traverseAction {
+	if key == nil {
+		return traverseContinue
+	}
+
+	root, depth := tr.findPrefixRoot(key)
+
+	return walkPath(root, key[:depth], opts&TraverseReverse == TraverseReverse, func(nr NodeRef, path Key) traverseAction {
+		if !nr.isLeaf() {
+			return traverseContinue
+		}
+
+		node := tr.nodeKV(nr, path)
+		if !bytes.HasPrefix(node.Key(), key) {
+			return traverseContinue
+		}
+
+		return ternary(callback(node), traverseContinue, traverseStop)
+	})
+}
@@ -1,6 +1,8 @@
 package art
 
 import (
+	"bytes"
+	"math/rand"
 	"sort"
 	"testing"
 
@@ -71,6 +73,41 @@ func TestTreeTraversalNode48(t *testing.T) {
 	}
 }
 
+func TestTreeTraversalNode48Reverse(t *testing.T) {
+	t.Parallel()
+
+	tree := newTree()
+	for i := 0; i < 48; i++ {
+		tree.Insert(Key{byte(i)}, i)
+	}
+
+	var keys []Key
+
+	tree.ForEach(func(node NodeKV) bool {
+		keys = append(keys, node.Key())
+
+		return true
+	}, TraverseLeaf|TraverseReverse)
+
+	require.Len(t, keys, 48)
+
+	for i, key := range keys {
+		assert.Equal(t, Key{byte(47 - i)}, key)
+	}
+
+	keys = keys[:0]
+
+	for it := tree.Iterator(TraverseReverse); it.HasNext(); {
+		node, err := it.Next()
+		require.NoError(t, err)
+
+		keys = append(keys, node.Key())
+	}
+
+	require.Len(t, keys, 48)
+	assert.Equal(t, Key{0}, keys[47])
+}
+
 func TestTreeTraversalCancelEarly(t *testing.T) {
 	t.Parallel()
 
@@ -589,3 +626,267 @@ func TestIteratorEmptyTreeBehavior(t *testing.T) {
 	assert.Nil(t, n)
 	assert.Equal(t, ErrNoMoreNodes, err)
 }
+
+// resilientTrees returns the constructors of the trees supporting the TraverseResilient option.
+func resilientTrees() []func() Tree {
+	return []func() Tree{
+		func() Tree { return New() },
+		func() Tree { return New(WithLeafSuffixes()) },
+		NewCompact,
+	}
+}
+
+func TestTreeIteratorResilientWithoutModification(t *testing.T) {
+	t.Parallel()
+
+	words := loadTestFile("test/assets/words.txt")
+
+	for _, build := range resilientTrees() {
+		tree := build()
+		for _, w := range words {
+			tree.Insert(w, w)
+		}
+
+		for _, traverse := range []int{TraverseLeaf, TraverseAll, TraverseNode, TraverseLeaf | TraverseReverse} {
+			it, rit := tree.Iterator(traverse), tree.Iterator(traverse|TraverseResilient)
+			for it.HasNext() {
+				want, err := it.Next()
+				require.NoError(t, err)
+
+				require.True(t, rit.HasNext())
+				got, err := rit.Next()
+				require.NoError(t, err)
+
+				assert.Equal(t, want.Kind(), got.Kind())
+				assert.Equal(t, want.Key(), got.Key())
+			}
+
+			assert.False(t, rit.HasNext())
+		}
+	}
+}
+
+func TestTreeIteratorResilientDelete(t *testing.T) {
+	t.Parallel()
+
+	words := loadTestFile("test/assets/words.txt")
+
+	for _, build := range resilientTrees() {
+		for _, reverse := range []int{0, TraverseReverse} {
+			tree := build()
+			for _, w := range words {
+				tree.Insert(w, w)
+			}
+
+			all := leafKeys(tree, reverse)
+
+			it, ok := tree.Iterator(TraverseResilient | reverse).(ResilientIterator)
+			require.True(t, ok)
+
+			// deleting the last returned key before any key is returned does nothing
+			_, deleted := it.Delete()
+			assert.False(t, deleted)
+
+			var seen, kept []string
+
+			for i := 0; it.HasNext(); i++ {
+				node, err := it.Next()
+				require.NoError(t, err)
+
+				seen = append(seen, string(node.Key()))
+
+				if i%2 == 0 {
+					v, deleted := it.Delete()
+					assert.True(t, deleted)
+					assert.Equal(t, node.Value(), v)
+
+					_, deleted = it.Delete()
+					assert.False(t, deleted)
+				} else {
+					kept = append(kept, string(node.Key()))
+				}
+			}
+
+			assert.Equal(t, all, seen)
+			assert.Equal(t, kept, leafKeys(tree, reverse))
+			require.NoError(t, tree.Validate())
+		}
+	}
+}
+
+func TestTreeIteratorResilientModifications(t *testing.T) {
+	t.Parallel()
+
+	for _, build := range resilientTrees() {
+		tree := build()
+		for _, k := range []string{"b", "d", "f", "h"} {
+			tree.Insert(Key(k), k)
+		}
+
+		it := tree.Iterator(TraverseResilient)
+
+		var seen []string
+
+		for it.HasNext() {
+			node, err := it.Next()
+			require.NoError(t, err)
+
+			seen = append(seen, string(node.Key()))
+
+			switch string(node.Key()) {
+			case "b":
+				tree.Insert(Key("a"), "a")   // before the last returned key, not returned
+				tree.Insert(Key("c"), "c")   // after the last returned key, returned
+				tree.Insert(Key("b\x00"), 0) // extends the last returned key, returned
+			case "d":
+				tree.Delete(Key("f"))
+				tree.Insert(Key("g"), "g")
+			}
+		}
+
+		assert.Equal(t, []string{"b", "b\x00", "c", "d", "g", "h"}, seen)
+		assert.False(t, it.HasNext())
+
+		_, err := it.Next()
+		assert.Equal(t, ErrNoMoreNodes, err)
+
+		// the iteration restarts from the first key if the tree is modified before any key is returned
+		it = tree.Iterator(TraverseResilient | TraverseReverse)
+		tree.Insert(Key("z"), "z")
+
+		node, err := it.Next()
+		require.NoError(t, err)
+		assert.Equal(t, Key("z"), node.Key())
+	}
+}
+
+func TestTreeIteratorResilientRandomModifications(t *testing.T) {
+	t.Parallel()
+
+	words := loadTestFile("test/assets/hsk_words.txt")
+	rnd := rand.New(rand.NewSource(42)) //nolint:gosec
+
+	for _, build := range resilientTrees() {
+		for _, reverse := range []int{0, TraverseReverse} {
+			tree := build()
+			for _, w := range words[:len(words)/2] {
+				tree.Insert(w, w)
+			}
+
+			// the keys deleted or inserted again during the iteration are not required to be returned
+			touched := map[string]bool{}
+
+			var seen []string
+
+			for it := tree.Iterator(TraverseResilient | reverse); it.HasNext(); {
+				node, err := it.Next()
+				require.NoError(t, err)
+
+				seen = append(seen, string(node.Key()))
+
+				for i := 0; i < 3; i++ {
+					w := words[rnd.Intn(len(words))]
+					touched[string(w)] = true
+
+					if rnd.Intn(2) == 0 {
+						tree.Insert(w, w)
+					} else {
+						tree.Delete(w)
+					}
+				}
+			}
+
+			for i := 1; i < len(seen); i++ {
+				assert.Equal(t, reverse == 0, seen[i-1] < seen[i])
+			}
+
+			seenKeys := map[string]bool{}
+			for _, k := range seen {
+				seenKeys[k] = true
+			}
+
+			for _, w := range words[:len(words)/2] {
+				if !touched[string(w)] {
+					assert.True(t, seenKeys[string(w)], string(w))
+				}
+			}
+
+			require.NoError(t, tree.Validate())
+		}
+	}
+}
+
+func TestTreeFindPrefixRoot(t *testing.T) {
+	t.Parallel()
+
+	tree := newTree()
+	for _, k := range []string{"user/1/settings", "user/2/settings", "users", "admin"} {
+		tree.Insert(Key(k), k)
+	}
+
+	nr, depth := tree.findPrefixRoot(Key("user/1/"))
+	assert.Equal(t, 6, depth)
+	assert.True(t, nr.isLeaf())
+	assert.Equal(t, Key("user/1/settings"), nr.Leaf().key)
+
+	nr, depth = tree.findPrefixRoot(Key("user/"))
+	assert.Equal(t, 5, depth)
+	assert.Equal(t, 2, int(nr.node().childrenLen))
+
+	nr, _ = tree.findPrefixRoot(Key("user/1/x"))
+	assert.True(t, nr.isNil())
+
+	nr, _ = tree.findPrefixRoot(Key("zzz"))
+	assert.True(t, nr.isNil())
+}
+
+func TestTreeForEachPrefixRandom(t *testing.T) {
+	t.Parallel()
+
+	options := map[string][]TreeOption{
+		"Default":      nil,
+		"MaxPrefixLen": {WithMaxPrefixLen(1)},
+		"LeafSuffixes": {WithLeafSuffixes()},
+	}
+
+	for name, opts := range options {
+		name, opts := name, opts
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rnd := rand.New(rand.NewSource(1)) //nolint:gosec
+
+			for round := 0; round < 100; round++ {
+				keys := randomKeys(rnd, 1+rnd.Intn(200))
+
+				tree := New(opts...)
+				for _, k := range keys {
+					tree.Insert(k, string(k))
+				}
+
+				prefix := keys[rnd.Intn(len(keys))]
+				prefix = append(Key{}, prefix[:rnd.Intn(len(prefix)+1)]...)
+
+				for _, reverse := range []int{TraverseLeaf, TraverseReverse} {
+					var want, got []string
+
+					tree.ForEach(func(node NodeKV) bool {
+						if bytes.HasPrefix(node.Key(), prefix) {
+							want = append(want, string(node.Key()))
+						}
+
+						return true
+					}, reverse)
+
+					tree.ForEachPrefix(prefix, func(node NodeKV) bool {
+						got = append(got, string(node.Key()))
+
+						return true
+					}, reverse)
+
+					require.Equal(t, want, got, "prefix %q", prefix)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package art
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// validator keeps the state shared by the checks of a tree walk.
+type validator struct {
+	leaves   int    // leaves is the number of leaves visited so far
+	lastKey  Key    // lastKey is the key of the previously visited Leaf
+	suffixes uint64 // suffixes has the suffix bits of the keys of the subtree being visited
+}
+
+// invalid creates the error describing the violation found at the Node.
+func invalid(path Key, kind Kind, format string, args ...interface{}) error {
+	return &ValidationError{
+		Path:   append(Key(nil), path...),
+		Kind:   kind,
+		Reason: fmt.Sprintf(format, args...),
+	}
+}
+
+// checkLeaf verifies that the Leaf key continues the path leading to the Leaf
+// and that the keys are visited in ascending order.
+// A Leaf stored as a zero byte child must end exactly at its path.
+func (v *validator) checkLeaf(path Key, key Key, zeroChild bool) error {
+	if !bytes.HasPrefix(key, path) {
+		return invalid(path, LeafKind, "key %q doesn't start with its path", []byte(key))
+	}
+
+	if zeroChild && len(key) != len(path) {
+		return invalid(path, LeafKind, "zero byte child key %q is longer than its path", []byte(key))
+	}
+
+	if v.leaves > 0 && bytes.Compare(key, v.lastKey) <= 0 {
+		return invalid(path, LeafKind, "key %q is not greater than the previous key %q", []byte(key), []byte(v.lastKey))
+	}
+
+	v.leaves++
+	v.lastKey = key
+	v.suffixes |= suffixBit(key)
+
+	return nil
+}
+
+// checkSize verifies that the tree size matches the number of visited leaves.
+func (v *validator) checkSize(size int) error {
+	if v.leaves != size {
+		return invalid(nil, LeafKind, "tree size %d doesn't match the %d leaves", size, v.leaves)
+	}
+
+	return nil
+}
+
+// checkBounds verifies that the number of key children fits the Node kind.
+// The zero byte child counts towards the minimum: a full Node grows to hold it,
+// so the bigger Node may have one key child less than its minimum.
+func checkBounds(path Key, kind Kind, childrenLen int, hasZeroChild bool) error {
+	var minChildren, maxChildren int
+
+	switch kind { //nolint:exhaustive
+	case Node4Kind:
+		minChildren, maxChildren = node4Min, node4Max
+	case Node16Kind:
+		minChildren, maxChildren = node16Min, node16Max
+	case Node48Kind:
+		minChildren, maxChildren = node48Min, node48Max
+	case Node256Kind:
+		minChildren, maxChildren = node256Min, node256Max
+	}
+
+	if hasZeroChild {
+		minChildren--
+	}
+
+	if childrenLen < minChildren || childrenLen > maxChildren {
+		return invalid(path, kind, "%d children out of bounds [%d, %d]", childrenLen, minChildren, maxChildren)
+	}
+
+	return nil
+}
+
+// checkSortedKeys verifies the first childrenLen keys are strictly ascending
+// and refer to existing children, while the remaining slots are empty.
+// The slot function reports whether the key at the index is marked as present and has a child.
+func checkSortedKeys(path Key, kind Kind, keys []byte, childrenLen int, slot func(idx int) (bool, bool)) error {
+	if childrenLen > len(keys) {
+		return invalid(path, kind, "children count %d exceeds the capacity %d", childrenLen, len(keys))
+	}
+
+	for i := range keys {
+		present, hasChild := slot(i)
+
+		if i >= childrenLen {
+			if present || hasChild {
+				return invalid(path, kind, "unused slot %d is not empty", i)
+			}
+
+			continue
+		}
+
+		if !present || !hasChild {
+			return invalid(path, kind, "key %d at slot %d has no child", keys[i], i)
+		}
+
+		if i > 0 && keys[i] <= keys[i-1] {
+			return invalid(path, kind, "keys %v are not sorted", keys[:childrenLen])
+		}
+	}
+
+	return nil
+}
+
+// Validate walks the tree and verifies its structural invariants.
+func (tr *tree) Validate() error {
+	var v validator
+	if err := tr.validateRecursively(&v, tr.root, nil, false); err != nil {
+		return err
+	}
+
+	return v.checkSize(tr.size)
+}
+
+// validateRecursively verifies the subtree reached by the path.
+func (tr *tree) validateRecursively(v *validator, nr NodeRef, path Key, zeroChild bool) error {
+	if nr.isNil() {
+		return nil
+	}
+
+	kind := nr.kind()
+	if nr.isLeaf() {
+		return v.checkLeaf(path, tr.leafKey(nr.Leaf(), path), zeroChild)
+	}
+
+	if zeroChild {
+		return invalid(path, kind, "zero byte child is not a Leaf")
+	}
+
+	// the truncated prefixes can't be restored from the key suffixes
+	if tr.opts.leafSuffixes && !nr.node().hasFullPrefix() {
+		return invalid(path, kind, "prefix of length %d is truncated in a tree storing key suffixes", nr.node().prefixLen)
+	}
+
+	// the children violations are reported at the path extended with the Node prefix
+	nodePath, err := validatePrefix(nr, path)
+	if err != nil {
+		return err
+	}
+
+	if err := validateChildren(nr, nodePath); err != nil {
+		return err
+	}
+
+	outer := v.suffixes
+	v.suffixes = 0
+
+	for _, ref := range nr.childRefs() {
+		childPath := nodePath
+		if !ref.kc.invalid {
+			childPath = append(nodePath[:len(nodePath):len(nodePath)], ref.kc.ch)
+		}
+
+		if err := tr.validateRecursively(v, ref.ref, childPath, ref.kc.invalid); err != nil {
+			return err
+		}
+	}
+
+	// the suffix bits may be stale after deletions, but they must cover the keys of the subtree
+	if missing := v.suffixes &^ nr.node().suffixes; missing != 0 {
+		return invalid(nodePath, kind, "suffix bits %#x don't cover the keys of the subtree", missing)
+	}
+
+	v.suffixes |= outer
+
+	return nil
+}
+
+// validateChildren verifies the children bookkeeping of the inner Node.
+func validateChildren(nr NodeRef, path Key) error {
+	kind := nr.kind()
+	childrenLen := int(nr.node().childrenLen)
+
+	switch kind {
+	case Node4Kind:
+		n := nr.node4()
+		if err := checkSortedKeys(path, kind, n.keys[:], childrenLen, func(i int) (bool, bool) {
+			return n.present[i] != 0, !n.children[i].isNil()
+		}); err != nil {
+			return err
+		}
+
+		return checkBounds(path, kind, childrenLen, !n.children[node4Max].isNil())
+
+	case Node16Kind:
+		n := nr.node16()
+		if err := checkSortedKeys(path, kind, n.keys[:], childrenLen, func(i int) (bool, bool) {
+			return n.hasChild(i), !n.children[i].isNil()
+		}); err != nil {
+			return err
+		}
+
+		return checkBounds(path, kind, childrenLen, !n.children[node16Max].isNil())
+
+	case Node48Kind:
+		n := nr.node48()
+		if err := validateNode48(n, path); err != nil {
+			return err
+		}
+
+		return checkBounds(path, kind, childrenLen, !n.children[node48Max].isNil())
+
+	case Node256Kind:
+		n := nr.node256()
+
+		numChildren := 0
+		for _, child := range n.children[:node256Max] {
+			if !child.isNil() {
+				numChildren++
+			}
+		}
+
+		if numChildren != childrenLen {
+			return invalid(path, kind, "children count %d doesn't match %d children", childrenLen, numChildren)
+		}
+
+		return checkBounds(path, kind, childrenLen, !n.children[node256Max].isNil())
+
+	case LeafKind:
+	}
+
+	return invalid(path, kind, "unknown Node kind")
+}
+
+// validateNode48 verifies that the present bitmap, the keys and the children of the Node48 agree.
+func validateNode48(n *Node48, path Key) error {
+	var used [node48Max]bool
+
+	numPresent := 0
+
+	for ch := 0; ch < node256Max; ch++ {
+		if !n.hasChild(ch) {
+			continue
+		}
+
+		numPresent++
+
+		idx := int(n.keys[ch])
+		if idx >= node48Max {
+			return invalid(path, Node48Kind, "key %d refers to slot %d out of range", ch, idx)
+		}
+
+		if n.children[idx].isNil() {
+			return invalid(path, Node48Kind, "key %d refers to the empty slot %d", ch, idx)
+		}
+
+		if used[idx] {
+			return invalid(path, Node48Kind, "key %d refers to the slot %d used by another key", ch, idx)
+		}
+
+		used[idx] = true
+	}
+
+	for idx, child := range n.children[:node48Max] {
+		if !child.isNil() && !used[idx] {
+			return invalid(path, Node48Kind, "child at slot %d is not referenced by any key", idx)
+		}
+	}
+
+	if numPresent != int(n.childrenLen) {
+		return invalid(path, Node48Kind, "children count %d doesn't match %d present keys", n.childrenLen, numPresent)
+	}
+
+	return nil
+}
+
+// validatePrefix verifies the stored prefix of the inner Node and returns the path extended with the full prefix.
+// The prefix bytes which are not stored are restored from the minimum Leaf,
+// the other leaves are then checked against them.
+func validatePrefix(nr NodeRef, path Key) (Key, error) {
+	n := nr.node()
+	if n.storedLen > n.prefixLen {
+		return nil, invalid(path, nr.kind(), "stored prefix length %d exceeds the prefix length %d", n.storedLen, n.prefixLen)
+	}
+
+	prefix := n.storedPrefix()
+	if !n.hasFullPrefix() {
+		minLeaf := nr.minimum()
+		if minLeaf == nil || len(minLeaf.key) < len(path)+int(n.prefixLen) {
+			return nil, invalid(path, nr.kind(), "prefix of length %d can't be restored from the minimum Leaf", n.prefixLen)
+		}
+
+		prefix = minLeaf.key[len(path) : len(path)+int(n.prefixLen)]
+		if !bytes.Equal(prefix[:n.storedLen], n.storedPrefix()) {
+			return nil, invalid(path, nr.kind(), "stored prefix %q doesn't match the minimum Leaf", n.storedPrefix())
+		}
+	}
+
+	return append(path[:len(path):len(path)], prefix...), nil
+}
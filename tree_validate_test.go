@@ -0,0 +1,201 @@
+package art
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, e := range engines {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := e.newTree()
+			require.NoError(t, tree.Validate())
+
+			data := loadTestFile("test/assets/words.txt")
+			for _, d := range data {
+				tree.Insert(d, d)
+			}
+
+			require.NoError(t, tree.Validate())
+
+			for _, d := range data[:len(data)/2] {
+				tree.Delete(d)
+			}
+
+			require.NoError(t, tree.Validate())
+		})
+	}
+}
+
+func TestTreeValidateNode256ShrinkWithZeroChild(t *testing.T) {
+	t.Parallel()
+
+	for _, e := range engines {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := e.newTree()
+			tree.Insert(Key("k"), "zero")
+
+			for i := 0; i < node256Max; i++ {
+				tree.Insert(Key{'k', byte(i)}, i)
+			}
+
+			// shrink the Node256 to a Node48, the zero byte child must survive
+			for i := 0; i < node256Max-node48Max; i++ {
+				tree.Delete(Key{'k', byte(i)})
+			}
+
+			require.NoError(t, tree.Validate())
+
+			val, found := tree.Search(Key("k"))
+			assert.True(t, found)
+			assert.Equal(t, "zero", val)
+		})
+	}
+}
+
+func TestTreeValidateZeroChildInGrownNode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		size int
+	}{
+		{"Node16", node4Max},
+		{"Node48", node16Max},
+		{"Node256", node48Max},
+	}
+
+	for _, e := range engines {
+		for _, tt := range tests {
+			e, tt := e, tt
+			t.Run(e.name+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				// the full Node grows to add the zero byte child
+				tree := e.newTree()
+				for i := 0; i < tt.size; i++ {
+					tree.Insert(Key{'x', byte('a' + i)}, i)
+				}
+
+				tree.Insert(Key("x"), "zero")
+				require.NoError(t, tree.Validate())
+
+				// the grown Node keeps one key child less than its minimum
+				tree.Delete(Key{'x', 'a'})
+				require.NoError(t, tree.Validate())
+
+				val, found := tree.Search(Key("x"))
+				assert.True(t, found)
+				assert.Equal(t, "zero", val)
+			})
+		}
+	}
+}
+
+func TestTreeValidateCorruption(t *testing.T) {
+	t.Parallel()
+
+	newTestTree := func() *tree {
+		tree := newTree()
+		for _, k := range []string{"a", "b", "c", "ca", "cb"} {
+			tree.Insert(Key(k), k)
+		}
+
+		return tree
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(tr *tree)
+		path    Key
+		kind    Kind
+	}{
+		{
+			name:    "Size",
+			corrupt: func(tr *tree) { tr.size++ },
+			kind:    LeafKind,
+		},
+		{
+			name:    "UnsortedKeys",
+			corrupt: func(tr *tree) { n := tr.root.node4(); n.keys[0], n.keys[1] = n.keys[1], n.keys[0] },
+			kind:    Node4Kind,
+		},
+		{
+			name:    "ChildrenLen",
+			corrupt: func(tr *tree) { tr.root.node4().childrenLen-- },
+			kind:    Node4Kind,
+		},
+		{
+			name:    "MisplacedLeaf",
+			corrupt: func(tr *tree) { tr.root.node4().children[0].Leaf().key = Key("x") },
+			path:    Key("a"),
+			kind:    LeafKind,
+		},
+		{
+			name: "ZeroChildNotLeaf",
+			corrupt: func(tr *tree) {
+				n := tr.root.node4()
+				n.children[node4Max] = n.children[2]
+			},
+			kind: Node4Kind,
+		},
+		{
+			name: "Underfull",
+			corrupt: func(tr *tree) {
+				n := tr.root.node4().children[2].node4()
+				n.deleteChild(keyChar{ch: 'a'})
+				n.deleteChild(keyChar{ch: 'b'})
+			},
+			path: Key("c"),
+			kind: Node4Kind,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := newTestTree()
+			require.NoError(t, tree.Validate())
+
+			tt.corrupt(tree)
+
+			err := tree.Validate()
+			require.ErrorIs(t, err, ErrInvalidTree)
+
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tt.kind, verr.Kind, verr.Error())
+			assert.Equal(t, tt.path, verr.Path, verr.Error())
+		})
+	}
+}
+
+func TestTreeValidatePathWithNodePrefix(t *testing.T) {
+	t.Parallel()
+
+	tree := newTree()
+	for _, k := range []string{"pa", "pqxa", "pqxb"} {
+		tree.Insert(Key(k), k)
+	}
+
+	require.NoError(t, tree.Validate())
+
+	tree.root.node4().children[1].node4().deleteChild(keyChar{ch: 'a'})
+
+	var verr *ValidationError
+	require.ErrorAs(t, tree.Validate(), &verr)
+	assert.Equal(t, Node4Kind, verr.Kind)
+	assert.Equal(t, Key("pqx"), verr.Path, verr.Error())
+}
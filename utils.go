@@ -8,6 +8,14 @@ func minInt(a, b int) int {
 	return b
 }
 
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
 // copy the Node from src to dst.
 func copyNode(dst *Node, src *Node) {
 	if dst == nil || src == nil {
@@ -16,17 +24,9 @@ func copyNode(dst *Node, src *Node) {
 
 	dst.prefixLen = src.prefixLen
 	dst.prefix = src.prefix
-}
-
-// find the child Node index by key.
-func findIndex(keys []byte, ch byte) int {
-	for i, key := range keys {
-		if key == ch {
-			return i
-		}
-	}
-
-	return indexNotFound
+	dst.storedLen = src.storedLen
+	dst.prefixExt = src.prefixExt
+	dst.suffixes = src.suffixes
 }
 
 // findLongestCommonPrefix returns the longest common prefix of key1 and key2.
@@ -44,19 +44,19 @@ func findLongestCommonPrefix(key1 Key, key2 Key, keyOffset int) int {
 }
 
 // nodeMinimum returns the minimum Leaf Node.
-func nodeMinimum(children []*NodeRef) *Leaf {
+func nodeMinimum(children []NodeRef) *Leaf {
 	numChildren := len(children)
 	if numChildren == 0 {
 		return nil
 	}
 
 	// zero byte key
-	if children[numChildren-1] != nil {
+	if !children[numChildren-1].isNil() {
 		return children[numChildren-1].minimum()
 	}
 
 	for i := 0; i < numChildren-1; i++ {
-		if children[i] != nil {
+		if !children[i].isNil() {
 			return children[i].minimum()
 		}
 	}
@@ -65,9 +65,9 @@ func nodeMinimum(children []*NodeRef) *Leaf {
 }
 
 // nodeMaximum returns the maximum Leaf Node.
-func nodeMaximum(children []*NodeRef) *Leaf {
+func nodeMaximum(children []NodeRef) *Leaf {
 	for i := len(children) - 1; i >= 0; i-- {
-		if children[i] != nil {
+		if !children[i].isNil() {
 			return children[i].maximum()
 		}
 	}
@@ -80,11 +80,11 @@ func (ds *testDataset) assert(t *testing.T, tree *tree) {
 
 	switch root := ds.expectedRoot.(type) {
 	case Kind:
-		assert.Equal(t, root, tree.root.kind, ds.name)
-	case *NodeRef:
+		assert.Equal(t, root, tree.root.kind(), ds.name)
+	case NodeRef:
 		assert.Equal(t, root, tree.root, ds.name)
 	case nil:
-		assert.Nil(t, tree.root, ds.name)
+		assert.True(t, tree.root.isNil(), ds.name)
 	}
 }
 